@@ -0,0 +1,85 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ai
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// A CandidateScorer assigns a score to a candidate; higher is better.
+// It is used by [GenerateBestOf] to pick a winner.
+type CandidateScorer func(ctx context.Context, c *Candidate) (float64, error)
+
+// GenerateBestOf runs n generations of the same request concurrently,
+// scores every resulting candidate with the scorer, and returns the
+// highest-scoring candidate along with all candidates produced.
+// This implements best-of-N sampling (also called self-consistency when
+// the scorer votes on agreement between samples).
+//
+// Each generation runs with a copy of req. If some generations fail,
+// GenerateBestOf still picks a winner from the ones that succeeded; it
+// returns an error only if all generations or all scores fail.
+func GenerateBestOf(ctx context.Context, m *Model, req *GenerateRequest, n int, scorer CandidateScorer) (*Candidate, []*Candidate, error) {
+	if n < 1 {
+		return nil, nil, errors.New("ai.GenerateBestOf: n must be at least 1")
+	}
+	if scorer == nil {
+		return nil, nil, errors.New("ai.GenerateBestOf: nil scorer")
+	}
+	responses := make([]*GenerateResponse, n)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			r := *req
+			responses[i], errs[i] = m.Generate(ctx, &r, nil)
+		}(i)
+	}
+	wg.Wait()
+
+	var candidates []*Candidate
+	for _, resp := range responses {
+		if resp != nil {
+			candidates = append(candidates, resp.Candidates...)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, nil, fmt.Errorf("ai.GenerateBestOf: all generations failed: %w", errors.Join(errs...))
+	}
+
+	var best *Candidate
+	var bestScore float64
+	var scoreErrs []error
+	for _, c := range candidates {
+		score, err := scorer(ctx, c)
+		if err != nil {
+			scoreErrs = append(scoreErrs, err)
+			continue
+		}
+		if best == nil || score > bestScore {
+			best = c
+			bestScore = score
+		}
+	}
+	if best == nil {
+		return nil, candidates, fmt.Errorf("ai.GenerateBestOf: scoring failed for all candidates: %w", errors.Join(scoreErrs...))
+	}
+	return best, candidates, nil
+}
@@ -0,0 +1,70 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ai
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+)
+
+func TestGenerateBestOf(t *testing.T) {
+	var calls int32
+	model := DefineModel("test", "bestOfModel", nil,
+		func(ctx context.Context, req *GenerateRequest, cb ModelStreamingCallback) (*GenerateResponse, error) {
+			n := atomic.AddInt32(&calls, 1)
+			if n == 2 {
+				return nil, errors.New("flaky")
+			}
+			text := "short"
+			if n == 3 {
+				text = "a much longer answer"
+			}
+			return &GenerateResponse{
+				Request: req,
+				Candidates: []*Candidate{{
+					Message: &Message{Content: []*Part{NewTextPart(text)}, Role: RoleModel},
+				}},
+			}, nil
+		})
+
+	req := &GenerateRequest{
+		Messages: []*Message{{Content: []*Part{NewTextPart("hi")}, Role: RoleUser}},
+	}
+	// Score by answer length; the longest answer should win even though
+	// one generation failed.
+	best, all, err := GenerateBestOf(context.Background(), model, req, 3,
+		func(ctx context.Context, c *Candidate) (float64, error) {
+			text, err := c.Text()
+			if err != nil {
+				return 0, err
+			}
+			return float64(len(text)), nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := len(all), 2; got != want {
+		t.Errorf("got %d candidates, want %d", got, want)
+	}
+	text, err := best.Text()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := text, "a much longer answer"; got != want {
+		t.Errorf("winner = %q, want %q", got, want)
+	}
+}
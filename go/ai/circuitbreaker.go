@@ -0,0 +1,179 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ai
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// This file implements a circuit breaker for model calls. When a
+// provider is down, every call otherwise waits out its own timeout;
+// the breaker fails fast instead, then probes for recovery.
+
+// ErrCircuitOpen is returned by [Model.Generate] when the model's
+// circuit breaker is open because of consecutive failures.
+var ErrCircuitOpen = errors.New("model circuit breaker is open")
+
+// breakerState is the state of a circuit breaker.
+type breakerState int
+
+const (
+	// breakerClosed is the normal state: calls go through.
+	breakerClosed breakerState = iota
+	// breakerOpen means calls fail fast until the open window elapses.
+	breakerOpen
+	// breakerHalfOpen means a single probe call is allowed through.
+	breakerHalfOpen
+)
+
+func (s breakerState) String() string {
+	switch s {
+	case breakerClosed:
+		return "closed"
+	case breakerOpen:
+		return "open"
+	case breakerHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// A circuitBreaker opens after threshold consecutive failures. While
+// open, calls fail fast with [ErrCircuitOpen]. After openFor elapses,
+// the breaker half-opens and lets a single probe call through: if the
+// probe succeeds the breaker closes, otherwise it reopens.
+type circuitBreaker struct {
+	name      string
+	threshold int
+	openFor   time.Duration
+
+	mu       sync.Mutex
+	state    breakerState
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// allow reports whether a call may proceed, transitioning from open to
+// half-open when the open window has elapsed.
+func (b *circuitBreaker) allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch b.state {
+	case breakerClosed:
+		return nil
+	case breakerOpen:
+		if time.Since(b.openedAt) < b.openFor {
+			return ErrCircuitOpen
+		}
+		b.setState(breakerHalfOpen)
+		b.probing = true
+		return nil
+	default: // half-open
+		if b.probing {
+			return ErrCircuitOpen
+		}
+		b.probing = true
+		return nil
+	}
+}
+
+// record updates the breaker with the outcome of a call.
+func (b *circuitBreaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	if err == nil {
+		b.failures = 0
+		if b.state != breakerClosed {
+			b.setState(breakerClosed)
+		}
+		return
+	}
+	b.failures++
+	if b.state == breakerHalfOpen || (b.state == breakerClosed && b.failures >= b.threshold) {
+		b.setState(breakerOpen)
+		b.openedAt = time.Now()
+	}
+}
+
+// setState changes the breaker's state, logging the transition.
+// The caller must hold b.mu.
+func (b *circuitBreaker) setState(s breakerState) {
+	slog.Info("model circuit breaker state change",
+		"model", b.name,
+		"from", b.state.String(),
+		"to", s.String())
+	b.state = s
+}
+
+var (
+	modelBreakersMu sync.RWMutex
+	// modelBreakers maps a model's full action name to its circuit breaker.
+	modelBreakers = map[string]*circuitBreaker{}
+)
+
+// SetModelCircuitBreaker installs a circuit breaker for the named model:
+// after threshold consecutive [Model.Generate] failures, calls fail fast
+// with [ErrCircuitOpen] for openFor, then a single probe call is let
+// through to test recovery. The name is the model's full action name,
+// for example "vertexai/gemini-1.0-pro". State transitions are logged.
+func SetModelCircuitBreaker(name string, threshold int, openFor time.Duration) {
+	modelBreakersMu.Lock()
+	defer modelBreakersMu.Unlock()
+	modelBreakers[name] = &circuitBreaker{name: name, threshold: threshold, openFor: openFor}
+}
+
+// ModelCircuitState returns the state of the named model's circuit
+// breaker ("closed", "open" or "half-open"), or "closed" if the model
+// has no breaker. Export it as a metric to see outages at a glance.
+func ModelCircuitState(name string) string {
+	modelBreakersMu.RLock()
+	b := modelBreakers[name]
+	modelBreakersMu.RUnlock()
+	if b == nil {
+		return breakerClosed.String()
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state.String()
+}
+
+// modelBreaker returns the circuit breaker for the named model, or nil.
+func modelBreaker(name string) *circuitBreaker {
+	modelBreakersMu.RLock()
+	defer modelBreakersMu.RUnlock()
+	return modelBreakers[name]
+}
+
+// GenerateWithFallbacks calls [Model.Generate] on each model in turn
+// until one succeeds, skipping models whose circuit breakers are open.
+// Use it to fall back to another provider during an outage. If every
+// model fails, the errors are joined.
+func GenerateWithFallbacks(ctx context.Context, models []*Model, req *GenerateRequest, cb ModelStreamingCallback) (*GenerateResponse, error) {
+	var errs []error
+	for _, m := range models {
+		resp, err := m.Generate(ctx, req, cb)
+		if err == nil {
+			return resp, nil
+		}
+		errs = append(errs, err)
+	}
+	return nil, errors.Join(errs...)
+}
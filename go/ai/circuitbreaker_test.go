@@ -0,0 +1,69 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ai
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	b := &circuitBreaker{name: "test", threshold: 2, openFor: 10 * time.Millisecond}
+	fail := errors.New("provider down")
+
+	// Below the threshold, calls go through.
+	if err := b.allow(); err != nil {
+		t.Fatal(err)
+	}
+	b.record(fail)
+	if err := b.allow(); err != nil {
+		t.Fatal(err)
+	}
+	b.record(fail)
+
+	// At the threshold, the breaker opens and fails fast.
+	if err := b.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("got %v, want ErrCircuitOpen", err)
+	}
+
+	// After the open window, a single probe is allowed.
+	time.Sleep(15 * time.Millisecond)
+	if err := b.allow(); err != nil {
+		t.Fatalf("probe not allowed: %v", err)
+	}
+	if err := b.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("second probe allowed: got %v, want ErrCircuitOpen", err)
+	}
+
+	// A failing probe reopens the breaker.
+	b.record(fail)
+	if err := b.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("got %v, want ErrCircuitOpen", err)
+	}
+
+	// A successful probe closes it.
+	time.Sleep(15 * time.Millisecond)
+	if err := b.allow(); err != nil {
+		t.Fatal(err)
+	}
+	b.record(nil)
+	if err := b.allow(); err != nil {
+		t.Fatal(err)
+	}
+	if got := b.state; got != breakerClosed {
+		t.Errorf("state = %v, want closed", got)
+	}
+}
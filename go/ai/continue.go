@@ -0,0 +1,91 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ai
+
+import (
+	"context"
+	"fmt"
+)
+
+// This file implements recovery from max-token truncation. A response
+// with [FinishReasonLength] is incomplete; re-prompting the model with
+// its own partial output as context usually completes it.
+
+// continueInstruction asks the model to resume a truncated answer.
+const continueInstruction = "Your previous answer was cut off by the output length limit. " +
+	"Continue exactly where you left off, without repeating anything you already wrote " +
+	"and without any preamble."
+
+// ContinueGeneration completes a response that was truncated by the
+// model's output token limit. While the first candidate finished with
+// [FinishReasonLength], it re-prompts the model with the partial output
+// as context and stitches the continuation onto it, looping until the
+// model finishes or maxContinues continuations have been attempted.
+// For JSON output, the stitched result is validated against the
+// request's output schema. Responses that were not truncated are
+// returned unchanged.
+func ContinueGeneration(ctx context.Context, m *Model, resp *GenerateResponse, maxContinues int) (*GenerateResponse, error) {
+	final := resp
+	var content []*Part
+	for i := 0; i < maxContinues; i++ {
+		if len(final.Candidates) == 0 || final.Candidates[0].FinishReason != FinishReasonLength {
+			break
+		}
+		c := final.Candidates[0]
+		content = append(content, c.Message.Content...)
+		creq, err := cloneGenerateRequest(resp.Request)
+		if err != nil {
+			return final, err
+		}
+		creq.Candidates = 1
+		// Continuation pieces are fragments, so they cannot be
+		// validated (or conformed) individually; the stitched whole is
+		// validated below instead.
+		creq.Output = nil
+		creq.Messages = append(creq.Messages,
+			&Message{Role: RoleModel, Content: content},
+			&Message{Role: RoleUser, Content: []*Part{NewTextPart(continueInstruction)}})
+		next, err := m.Generate(ctx, creq, nil)
+		if err != nil {
+			return final, err
+		}
+		final = next
+	}
+	if len(content) == 0 {
+		return final, nil
+	}
+	if len(final.Candidates) == 0 {
+		return final, fmt.Errorf("continuation resulted in no candidates")
+	}
+	if final.Candidates[0].FinishReason == FinishReasonLength {
+		return final, fmt.Errorf("response still truncated after %d continuations", maxContinues)
+	}
+	// Stitch the accumulated partial output onto the final continuation.
+	c := final.Candidates[0]
+	c.Message = &Message{
+		Role:    RoleModel,
+		Content: append(content, c.Message.Content...),
+	}
+	// For structured output, the pieces must form a valid whole.
+	if out := resp.Request.Output; out != nil && out.Format == OutputFormatJSON {
+		vc, err := validCandidate(c, out)
+		if err != nil {
+			return final, fmt.Errorf("stitched continuation does not match the output schema: %w", err)
+		}
+		final.Candidates[0] = vc
+	}
+	final.Request = resp.Request
+	return final, nil
+}
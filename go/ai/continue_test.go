@@ -0,0 +1,64 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ai
+
+import (
+	"context"
+	"testing"
+)
+
+func TestContinueGeneration(t *testing.T) {
+	// The model answers in three truncated pieces.
+	pieces := []string{"one ", "two ", "three"}
+	call := 0
+	model := DefineModel("test", "continueModel", nil,
+		func(ctx context.Context, req *GenerateRequest, cb ModelStreamingCallback) (*GenerateResponse, error) {
+			reason := FinishReasonLength
+			if call == len(pieces)-1 {
+				reason = FinishReasonStop
+			}
+			resp := &GenerateResponse{
+				Request: req,
+				Candidates: []*Candidate{{
+					Message:      &Message{Role: RoleModel, Content: []*Part{NewTextPart(pieces[call])}},
+					FinishReason: reason,
+				}},
+			}
+			call++
+			return resp, nil
+		})
+
+	req := &GenerateRequest{
+		Messages: []*Message{{Content: []*Part{NewTextPart("count")}, Role: RoleUser}},
+	}
+	resp, err := model.Generate(context.Background(), req, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	full, err := ContinueGeneration(context.Background(), model, resp, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, err := full.Text()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "one two three" {
+		t.Errorf("stitched text = %q, want %q", text, "one two three")
+	}
+	if got := full.Candidates[0].FinishReason; got != FinishReasonStop {
+		t.Errorf("FinishReason = %q, want %q", got, FinishReasonStop)
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ai
+
+import (
+	"context"
+
+	"github.com/firebase/genkit/go/internal"
+)
+
+// This file lets callers supply provider credentials per request. A
+// SaaS platform calling a model on behalf of its customers can pass
+// each customer's own API key through the context instead of
+// configuring a plugin instance per tenant.
+
+// credentialsKey carries per-provider API keys in a context.
+var credentialsKey = internal.NewContextKey[map[string]string]()
+
+// WithCredentials returns a context that carries an API key for the
+// named provider, e.g. "googleai". Plugins that support per-request
+// credentials use the key from the context for that request instead of
+// the one the plugin was initialized with. Credentials for other
+// providers already in the context are preserved.
+func WithCredentials(ctx context.Context, provider, apiKey string) context.Context {
+	creds := map[string]string{provider: apiKey}
+	for p, k := range credentialsKey.FromContext(ctx) {
+		if p != provider {
+			creds[p] = k
+		}
+	}
+	return credentialsKey.NewContext(ctx, creds)
+}
+
+// CredentialsFromContext returns the per-request API key for the named
+// provider, if one was attached with [WithCredentials].
+func CredentialsFromContext(ctx context.Context, provider string) (string, bool) {
+	key, ok := credentialsKey.FromContext(ctx)[provider]
+	return key, ok
+}
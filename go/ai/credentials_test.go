@@ -0,0 +1,37 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ai
+
+import (
+	"context"
+	"testing"
+)
+
+func TestWithCredentials(t *testing.T) {
+	ctx := context.Background()
+	if _, ok := CredentialsFromContext(ctx, "googleai"); ok {
+		t.Fatal("credentials in empty context")
+	}
+	ctx = WithCredentials(ctx, "googleai", "key-1")
+	ctx = WithCredentials(ctx, "openai", "key-2")
+	// Overwriting one provider preserves the other.
+	ctx = WithCredentials(ctx, "googleai", "key-3")
+	if key, ok := CredentialsFromContext(ctx, "googleai"); !ok || key != "key-3" {
+		t.Errorf("googleai key = %q, %t; want key-3", key, ok)
+	}
+	if key, ok := CredentialsFromContext(ctx, "openai"); !ok || key != "key-2" {
+		t.Errorf("openai key = %q, %t; want key-2", key, ok)
+	}
+}
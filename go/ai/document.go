@@ -31,11 +31,12 @@ type Document struct {
 // A Part is one part of a [Document]. This may be plain text or it
 // may be a URL (possibly a "data:" URL with embedded data).
 type Part struct {
-	Kind         PartKind      `json:"kind,omitempty"`
-	ContentType  string        `json:"contentType,omitempty"` // valid for kind==blob
-	Text         string        `json:"text,omitempty"`        // valid for kind∈{text,blob}
-	ToolRequest  *ToolRequest  `json:"toolreq,omitempty"`     // valid for kind==partToolRequest
-	ToolResponse *ToolResponse `json:"toolresp,omitempty"`    // valid for kind==partToolResponse
+	Kind         PartKind       `json:"kind,omitempty"`
+	ContentType  string         `json:"contentType,omitempty"` // valid for kind==blob
+	Text         string         `json:"text,omitempty"`        // valid for kind∈{text,blob}
+	ToolRequest  *ToolRequest   `json:"toolreq,omitempty"`     // valid for kind==partToolRequest
+	ToolResponse *ToolResponse  `json:"toolresp,omitempty"`    // valid for kind==partToolResponse
+	Metadata     map[string]any `json:"metadata,omitempty"`    // valid for any kind
 }
 
 type PartKind int8
@@ -46,6 +47,7 @@ const (
 	PartData
 	PartToolRequest
 	PartToolResponse
+	PartReasoning
 )
 
 // NewTextPart returns a Part containing text.
@@ -69,6 +71,13 @@ func NewDataPart(contents string) *Part {
 	return &Part{Kind: PartData, Text: contents}
 }
 
+// NewReasoningPart returns a Part containing the model's reasoning
+// (sometimes called "thinking") that led to its answer.
+// (Only genkit plugins should need to use this function.)
+func NewReasoningPart(text string) *Part {
+	return &Part{Kind: PartReasoning, Text: text}
+}
+
 // NewToolRequestPart returns a Part containing a request from
 // the model to the client to run a Tool.
 // (Only genkit plugins should need to use this function.)
@@ -97,6 +106,11 @@ func (p *Part) IsData() bool {
 	return p.Kind == PartData
 }
 
+// IsReasoning reports whether the [Part] contains model reasoning.
+func (p *Part) IsReasoning() bool {
+	return p.Kind == PartReasoning
+}
+
 // IsToolRequest reports whether the [Part] contains a request to run a tool.
 func (p *Part) IsToolRequest() bool {
 	return p.Kind == PartToolRequest
@@ -115,7 +129,8 @@ func (p *Part) MarshalJSON() ([]byte, error) {
 	switch p.Kind {
 	case PartText:
 		v := textPart{
-			Text: p.Text,
+			Text:     p.Text,
+			Metadata: p.Metadata,
 		}
 		return json.Marshal(v)
 	case PartMedia:
@@ -124,11 +139,13 @@ func (p *Part) MarshalJSON() ([]byte, error) {
 				ContentType: p.ContentType,
 				Url:         p.Text,
 			},
+			Metadata: p.Metadata,
 		}
 		return json.Marshal(v)
 	case PartData:
 		v := dataPart{
-			Data: p.Text,
+			Data:     p.Text,
+			Metadata: p.Metadata,
 		}
 		return json.Marshal(v)
 	case PartToolRequest:
@@ -136,16 +153,29 @@ func (p *Part) MarshalJSON() ([]byte, error) {
 		// between Go and javascript. At the very least the
 		// field name needs to change (here and in UnmarshalJSON).
 		v := struct {
-			ToolReq *ToolRequest `json:"toolreq,omitempty"`
+			ToolReq  *ToolRequest   `json:"toolreq,omitempty"`
+			Metadata map[string]any `json:"metadata,omitempty"`
 		}{
-			ToolReq: p.ToolRequest,
+			ToolReq:  p.ToolRequest,
+			Metadata: p.Metadata,
 		}
 		return json.Marshal(v)
 	case PartToolResponse:
 		v := struct {
-			ToolResp *ToolResponse `json:"toolresp,omitempty"`
+			ToolResp *ToolResponse  `json:"toolresp,omitempty"`
+			Metadata map[string]any `json:"metadata,omitempty"`
 		}{
 			ToolResp: p.ToolResponse,
+			Metadata: p.Metadata,
+		}
+		return json.Marshal(v)
+	case PartReasoning:
+		v := struct {
+			Reasoning string         `json:"reasoning,omitempty"`
+			Metadata  map[string]any `json:"metadata,omitempty"`
+		}{
+			Reasoning: p.Text,
+			Metadata:  p.Metadata,
 		}
 		return json.Marshal(v)
 	default:
@@ -154,11 +184,13 @@ func (p *Part) MarshalJSON() ([]byte, error) {
 }
 
 type partSchema struct {
-	Text     string          `json:"text,omitempty"`
-	Media    *mediaPartMedia `json:"media,omitempty"`
-	Data     string          `json:"data,omitempty"`
-	ToolReq  *ToolRequest    `json:"toolreq,omitempty"`
-	ToolResp *ToolResponse   `json:"toolresp,omitempty"`
+	Text      string          `json:"text,omitempty"`
+	Media     *mediaPartMedia `json:"media,omitempty"`
+	Data      string          `json:"data,omitempty"`
+	ToolReq   *ToolRequest    `json:"toolreq,omitempty"`
+	ToolResp  *ToolResponse   `json:"toolresp,omitempty"`
+	Reasoning string          `json:"reasoning,omitempty"`
+	Metadata  map[string]any  `json:"metadata,omitempty"`
 }
 
 // UnmarshalJSON is called by the JSON unmarshaler to read a Part.
@@ -170,6 +202,7 @@ func (p *Part) UnmarshalJSON(b []byte) error {
 	if err := json.Unmarshal(b, &s); err != nil {
 		return err
 	}
+	p.Metadata = s.Metadata
 
 	switch {
 	case s.Media != nil:
@@ -182,6 +215,9 @@ func (p *Part) UnmarshalJSON(b []byte) error {
 	case s.ToolResp != nil:
 		p.Kind = PartToolResponse
 		p.ToolResponse = s.ToolResp
+	case s.Reasoning != "":
+		p.Kind = PartReasoning
+		p.Text = s.Reasoning
 	default:
 		p.Kind = PartText
 		p.Text = s.Text
@@ -108,3 +108,41 @@ func TestDocumentJSON(t *testing.T) {
 		t.Errorf("mismatch (-want, +got)\n%s", diff)
 	}
 }
+
+func TestPartMetadataJSON(t *testing.T) {
+	d := Document{
+		Content: []*Part{
+			&Part{
+				Kind:     PartText,
+				Text:     "hi",
+				Metadata: map[string]any{"sourceDocID": "doc-7"},
+			},
+			&Part{
+				Kind:        PartMedia,
+				ContentType: "text/plain",
+				Text:        "data:,bye",
+				Metadata:    map[string]any{"uiElementID": "img-3"},
+			},
+			&Part{
+				Kind: PartToolRequest,
+				ToolRequest: &ToolRequest{
+					Name:  "tool1",
+					Input: map[string]any{"arg1": "foo"},
+				},
+				Metadata: map[string]any{"origin": "planner"},
+			},
+		},
+	}
+
+	b, err := json.Marshal(&d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var d2 Document
+	if err := json.Unmarshal(b, &d2); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(d, d2); diff != "" {
+		t.Errorf("mismatch (-want, +got)\n%s", diff)
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ai
+
+// This file provides accessors for structured finish details, so
+// callers can see why a generation was blocked or truncated without
+// digging through provider-specific Custom data.
+
+// Blocked reports whether the candidate was blocked by the provider's
+// safety filters.
+func (c *Candidate) Blocked() bool {
+	return c.FinishReason == FinishReasonBlocked
+}
+
+// Truncated reports whether the candidate was cut off by the model's
+// maximum output token limit.
+func (c *Candidate) Truncated() bool {
+	return c.FinishReason == FinishReasonLength
+}
+
+// BlockedReasons returns the safety filter categories that blocked
+// candidates of the response, across all candidates. It is empty if
+// nothing was blocked or the provider reported no detail.
+func (r *GenerateResponse) BlockedReasons() []BlockedReason {
+	var reasons []BlockedReason
+	for _, c := range r.Candidates {
+		if c.FinishDetails != nil {
+			reasons = append(reasons, c.FinishDetails.BlockedReasons...)
+		}
+	}
+	return reasons
+}
+
+// Blocked reports whether every candidate of the response was blocked
+// by the provider's safety filters.
+func (r *GenerateResponse) Blocked() bool {
+	if len(r.Candidates) == 0 {
+		return false
+	}
+	for _, c := range r.Candidates {
+		if !c.Blocked() {
+			return false
+		}
+	}
+	return true
+}
@@ -20,12 +20,44 @@ package ai
 // request. It contains a single generated message along with additional
 // metadata about its generation. A generation request may result in multiple Candidates.
 type Candidate struct {
-	Custom        any              `json:"custom,omitempty"`
-	FinishMessage string           `json:"finishMessage,omitempty"`
-	FinishReason  FinishReason     `json:"finishReason,omitempty"`
-	Index         int              `json:"index,omitempty"`
-	Message       *Message         `json:"message,omitempty"`
-	Usage         *GenerationUsage `json:"usage,omitempty"`
+	Custom        any          `json:"custom,omitempty"`
+	FinishMessage string       `json:"finishMessage,omitempty"`
+	FinishReason  FinishReason `json:"finishReason,omitempty"`
+	// FinishDetails holds structured detail about why generation
+	// stopped, beyond the FinishReason constant.
+	FinishDetails *FinishDetails `json:"finishDetails,omitempty"`
+	Index         int            `json:"index,omitempty"`
+	// Logprobs holds the per-token log probabilities of this candidate's
+	// message, for providers that report them.
+	Logprobs []Logprob        `json:"logprobs,omitempty"`
+	Message  *Message         `json:"message,omitempty"`
+	Usage    *GenerationUsage `json:"usage,omitempty"`
+}
+
+// A Logprob is the log probability a model assigned to one generated token.
+type Logprob struct {
+	Logprob float64 `json:"logprob,omitempty"`
+	Token   string  `json:"token,omitempty"`
+}
+
+// FinishDetails carries structured detail about why a candidate's
+// generation stopped, normalized across providers.
+type FinishDetails struct {
+	// BlockedReasons lists what triggered the provider's safety filters,
+	// when FinishReason is "blocked".
+	BlockedReasons []BlockedReason `json:"blockedReasons,omitempty"`
+}
+
+// A BlockedReason describes one safety filter category that contributed
+// to a generation being blocked.
+type BlockedReason struct {
+	// Category is the provider's safety category, e.g. "HarmCategoryHarassment".
+	Category string `json:"category,omitempty"`
+	// Score is the provider's probability or severity score for the
+	// category, if it reports one.
+	Score float64 `json:"score,omitempty"`
+	// Blocked reports whether this category alone blocked the content.
+	Blocked bool `json:"blocked,omitempty"`
 }
 
 // FinishReason is the reason why a model stopped generating tokens.
@@ -101,12 +133,15 @@ type GenerateResponseChunk struct {
 
 // GenerationCommonConfig holds configuration for generation.
 type GenerationCommonConfig struct {
-	MaxOutputTokens int      `json:"maxOutputTokens,omitempty"`
-	StopSequences   []string `json:"stopSequences,omitempty"`
-	Temperature     float64  `json:"temperature,omitempty"`
-	TopK            int      `json:"topK,omitempty"`
-	TopP            float64  `json:"topP,omitempty"`
-	Version         string   `json:"version,omitempty"`
+	FrequencyPenalty float64  `json:"frequencyPenalty,omitempty"`
+	MaxOutputTokens  int      `json:"maxOutputTokens,omitempty"`
+	PresencePenalty  float64  `json:"presencePenalty,omitempty"`
+	Seed             int      `json:"seed,omitempty"`
+	StopSequences    []string `json:"stopSequences,omitempty"`
+	Temperature      float64  `json:"temperature,omitempty"`
+	TopK             int      `json:"topK,omitempty"`
+	TopP             float64  `json:"topP,omitempty"`
+	Version          string   `json:"version,omitempty"`
 }
 
 // GenerationUsage provides information about the generation process.
@@ -46,6 +46,10 @@ type ModelCapabilities struct {
 type ModelMetadata struct {
 	Label    string
 	Supports ModelCapabilities
+	// ConfigSchema is a JSON schema describing the config the model accepts
+	// in [GenerateRequest.Config]. If set, [Model.Generate] validates the
+	// request's config against it before invoking the model.
+	ConfigSchema map[string]any
 }
 
 // DefineModel registers the given generate function as an action, and returns a
@@ -63,6 +67,10 @@ func DefineModel(provider, name string, metadata *ModelMetadata, generate func(c
 			"tools":      metadata.Supports.Tools,
 		}
 		metadataMap["supports"] = supports
+		if metadata.ConfigSchema != nil {
+			metadataMap["customOptions"] = metadata.ConfigSchema
+			registerConfigSchema(provider+"/"+name, metadata.ConfigSchema)
+		}
 	}
 	return (*Model)(core.DefineStreamingAction(provider, name, atype.Model, map[string]any{
 		"model": metadataMap,
@@ -82,11 +90,31 @@ func (m *Model) Generate(ctx context.Context, req *GenerateRequest, cb ModelStre
 	}
 
 	a := (*core.Action[*GenerateRequest, *GenerateResponse, *GenerateResponseChunk])(m)
+	if err := validateConfig(a.Name(), req); err != nil {
+		return nil, err
+	}
+	if l := modelLimiter(a.Name()); l != nil {
+		if err := l.Acquire(ctx); err != nil {
+			return nil, err
+		}
+		defer l.Release()
+	}
+	breaker := modelBreaker(a.Name())
+	var turnUsage []*GenerationUsage
 	for {
+		if breaker != nil {
+			if err := breaker.allow(); err != nil {
+				return nil, err
+			}
+		}
 		resp, err := a.Run(ctx, req, cb)
+		if breaker != nil {
+			breaker.record(err)
+		}
 		if err != nil {
 			return nil, err
 		}
+		turnUsage = append(turnUsage, resp.Usage)
 
 		candidates, err := validCandidates(ctx, resp)
 		if err != nil {
@@ -99,6 +127,11 @@ func (m *Model) Generate(ctx context.Context, req *GenerateRequest, cb ModelStre
 			return nil, err
 		}
 		if newReq == nil {
+			// The tool loop may have run the model several times; report the
+			// usage of all turns, not just the last one.
+			if len(turnUsage) > 1 {
+				resp.Usage = aggregateUsage(turnUsage)
+			}
 			return resp, nil
 		}
 
@@ -200,7 +233,13 @@ func handleToolRequest(ctx context.Context, req *GenerateRequest, resp *Generate
 	toolReq := part.ToolRequest
 	output, err := RunTool(ctx, toolReq.Name, toolReq.Input)
 	if err != nil {
-		return nil, err
+		// Bad arguments go back to the model as the tool's output so
+		// it can correct them on the next turn.
+		var tie *ToolInputError
+		if !errors.As(err, &tie) {
+			return nil, err
+		}
+		output = map[string]any{"error": tie.Error()}
 	}
 
 	toolResp := &Message{
@@ -237,16 +276,31 @@ func (c *GenerateResponseChunk) Text() (string, error) {
 	if len(c.Content) == 0 {
 		return "", errors.New("response chunk has no content")
 	}
-	if len(c.Content) == 1 {
+	if len(c.Content) == 1 && !c.Content[0].IsReasoning() {
 		return c.Content[0].Text, nil
 	}
 	var sb strings.Builder
 	for _, p := range c.Content {
+		if p.IsReasoning() {
+			continue
+		}
 		sb.WriteString(p.Text)
 	}
 	return sb.String(), nil
 }
 
+// Reasoning returns the model's reasoning ("thinking") from the
+// [GenerateResponseChunk], if the model reported any.
+func (c *GenerateResponseChunk) Reasoning() string {
+	var sb strings.Builder
+	for _, p := range c.Content {
+		if p.IsReasoning() {
+			sb.WriteString(p.Text)
+		}
+	}
+	return sb.String()
+}
+
 // Text returns the contents of a [Candidate] as a string. It
 // returns an error if the candidate has no message.
 func (c *Candidate) Text() (string, error) {
@@ -257,12 +311,60 @@ func (c *Candidate) Text() (string, error) {
 	if len(msg.Content) == 0 {
 		return "", errors.New("candidate message has no content")
 	}
-	if len(msg.Content) == 1 {
+	if len(msg.Content) == 1 && !msg.Content[0].IsReasoning() {
 		return msg.Content[0].Text, nil
 	}
 	var sb strings.Builder
 	for _, p := range msg.Content {
+		if p.IsReasoning() {
+			continue
+		}
 		sb.WriteString(p.Text)
 	}
 	return sb.String(), nil
 }
+
+// Texts returns the contents of every candidate in a [GenerateResponse]
+// as strings, in candidate order. It is useful for ranking and
+// self-consistency techniques that sample several candidates and vote.
+// It returns an error if there are no candidates or if any candidate
+// has no message.
+func (gr *GenerateResponse) Texts() ([]string, error) {
+	if len(gr.Candidates) == 0 {
+		return nil, errors.New("no candidates returned")
+	}
+	texts := make([]string, len(gr.Candidates))
+	for i, c := range gr.Candidates {
+		text, err := c.Text()
+		if err != nil {
+			return nil, fmt.Errorf("candidate %d: %w", i, err)
+		}
+		texts[i] = text
+	}
+	return texts, nil
+}
+
+// Reasoning returns the model's reasoning ("thinking") from the first
+// candidate in a [GenerateResponse]. It returns the empty string if the
+// model reported no reasoning.
+func (gr *GenerateResponse) Reasoning() string {
+	if len(gr.Candidates) == 0 {
+		return ""
+	}
+	return gr.Candidates[0].Reasoning()
+}
+
+// Reasoning returns the model's reasoning ("thinking") from a [Candidate].
+// It returns the empty string if the model reported no reasoning.
+func (c *Candidate) Reasoning() string {
+	if c.Message == nil {
+		return ""
+	}
+	var sb strings.Builder
+	for _, p := range c.Message.Content {
+		if p.IsReasoning() {
+			sb.WriteString(p.Text)
+		}
+	}
+	return sb.String()
+}
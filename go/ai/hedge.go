@@ -0,0 +1,178 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/firebase/genkit/go/core/tracing"
+)
+
+// This file implements request hedging. Tail latency for interactive
+// flows is dominated by provider stragglers: a request that would
+// normally stream its first token in under a second occasionally takes
+// ten. Hedging fires a duplicate request when the first is slow and
+// takes whichever answers first.
+
+// HedgeConfig configures [GenerateHedged].
+type HedgeConfig struct {
+	// Delay is how long to wait for the first streamed chunk (or, for
+	// non-streaming calls, for completion) before firing a duplicate
+	// request.
+	Delay time.Duration
+	// MaxExtra is the maximum number of duplicate requests.
+	MaxExtra int
+	// Models, if non-empty, supplies the models for the duplicate
+	// requests in order. If empty, duplicates go to the same model.
+	Models []*Model
+}
+
+// GenerateHedged is like [Model.Generate], but if the model has not
+// produced a first chunk within cfg.Delay it fires a duplicate request,
+// up to cfg.MaxExtra times. The first attempt to respond wins and the
+// others are canceled; once an attempt has streamed a chunk to cb, it
+// has won and later attempts are ignored. The number of attempts and
+// the winning attempt are recorded in the current span's metadata.
+func GenerateHedged(ctx context.Context, m *Model, req *GenerateRequest, cfg *HedgeConfig, cb ModelStreamingCallback) (*GenerateResponse, error) {
+	if cfg == nil || cfg.MaxExtra <= 0 {
+		return m.Generate(ctx, req, cb)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		attempt int
+		resp    *GenerateResponse
+		err     error
+	}
+	results := make(chan result, cfg.MaxExtra+1)
+	// winner holds 1+the attempt that claimed the stream, or 0.
+	var winner atomic.Int64
+	// firstChunk is closed when any attempt streams its first chunk.
+	firstChunk := make(chan struct{})
+	var firstChunkOnce sync.Once
+
+	launch := func(attempt int, model *Model) {
+		// Each attempt gets its own copy of the request: Generate
+		// appends output-conformance instructions to the messages.
+		areq, err := cloneGenerateRequest(req)
+		if err != nil {
+			results <- result{attempt, nil, err}
+			return
+		}
+		acb := cb
+		if cb != nil {
+			acb = func(ctx context.Context, c *GenerateResponseChunk) error {
+				// The first attempt to stream a chunk claims the
+				// client's callback; losers are canceled.
+				if !winner.CompareAndSwap(0, int64(attempt+1)) && winner.Load() != int64(attempt+1) {
+					return context.Canceled
+				}
+				firstChunkOnce.Do(func() { close(firstChunk) })
+				return cb(ctx, c)
+			}
+		}
+		go func() {
+			resp, err := model.Generate(ctx, areq, acb)
+			results <- result{attempt, resp, err}
+		}()
+	}
+
+	hedgeModel := func(attempt int) *Model {
+		if attempt-1 < len(cfg.Models) {
+			return cfg.Models[attempt-1]
+		}
+		return m
+	}
+
+	launch(0, m)
+	attempts := 1
+	timer := time.NewTimer(cfg.Delay)
+	defer timer.Stop()
+
+	var errs []error
+	// hedging is cleared once an attempt claims the stream: from then
+	// on, launching more duplicates could only waste provider calls,
+	// because no other attempt may deliver to the client.
+	hedging := true
+	for {
+		select {
+		case <-timer.C:
+			if hedging && attempts <= cfg.MaxExtra {
+				launch(attempts, hedgeModel(attempts))
+				attempts++
+				timer.Reset(cfg.Delay)
+			}
+		case <-firstChunk:
+			// An attempt has claimed the stream; stop hedging and wait
+			// for its result.
+			firstChunk = nil
+			hedging = false
+			timer.Stop()
+		case r := <-results:
+			w := winner.Load()
+			if r.err == nil {
+				// For streaming calls, only the claiming attempt may win.
+				if cb == nil || w == 0 || w == int64(r.attempt+1) {
+					tracing.SetCustomMetadataAttr(ctx, "hedgeAttempts", strconv.Itoa(attempts))
+					tracing.SetCustomMetadataAttr(ctx, "hedgeWinner", strconv.Itoa(r.attempt))
+					return r.resp, nil
+				}
+				continue
+			}
+			errs = append(errs, r.err)
+			if w == int64(r.attempt+1) {
+				// The attempt that claimed the client's stream failed
+				// mid-stream. No other attempt may deliver to the
+				// client, so report the failure rather than waiting on
+				// results that cannot win.
+				tracing.SetCustomMetadataAttr(ctx, "hedgeAttempts", strconv.Itoa(attempts))
+				return nil, errors.Join(errs...)
+			}
+			if len(errs) > cfg.MaxExtra {
+				// Every attempt has failed.
+				tracing.SetCustomMetadataAttr(ctx, "hedgeAttempts", strconv.Itoa(attempts))
+				return nil, errors.Join(errs...)
+			}
+			if hedging && len(errs) == attempts && attempts <= cfg.MaxExtra {
+				// Every in-flight attempt has failed; hedge right away
+				// rather than waiting out the delay.
+				launch(attempts, hedgeModel(attempts))
+				attempts++
+				timer.Reset(cfg.Delay)
+			}
+		}
+	}
+}
+
+// cloneGenerateRequest deep-copies a request via JSON so that
+// concurrent attempts do not share messages.
+func cloneGenerateRequest(req *GenerateRequest) (*GenerateRequest, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	clone := &GenerateRequest{}
+	if err := json.Unmarshal(data, clone); err != nil {
+		return nil, err
+	}
+	return clone, nil
+}
@@ -0,0 +1,163 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ai
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func hedgeTextResponse(req *GenerateRequest, text string) *GenerateResponse {
+	return &GenerateResponse{
+		Request: req,
+		Candidates: []*Candidate{{
+			Message: &Message{Content: []*Part{NewTextPart(text)}, Role: RoleModel},
+		}},
+	}
+}
+
+func TestGenerateHedged(t *testing.T) {
+	// The slow model stalls until it is canceled.
+	slow := DefineModel("test", "hedgeSlow", nil,
+		func(ctx context.Context, req *GenerateRequest, cb ModelStreamingCallback) (*GenerateResponse, error) {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(time.Minute):
+				return hedgeTextResponse(req, "slow"), nil
+			}
+		})
+	fast := DefineModel("test", "hedgeFast", nil,
+		func(ctx context.Context, req *GenerateRequest, cb ModelStreamingCallback) (*GenerateResponse, error) {
+			return hedgeTextResponse(req, "fast"), nil
+		})
+
+	req := &GenerateRequest{
+		Messages: []*Message{{Content: []*Part{NewTextPart("hi")}, Role: RoleUser}},
+	}
+	cfg := &HedgeConfig{
+		Delay:    5 * time.Millisecond,
+		MaxExtra: 1,
+		Models:   []*Model{fast},
+	}
+	resp, err := GenerateHedged(context.Background(), slow, req, cfg, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, err := resp.Text()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "fast" {
+		t.Errorf("got %q, want the hedged response", text)
+	}
+}
+
+func TestGenerateHedgedStreaming(t *testing.T) {
+	req := &GenerateRequest{
+		Messages: []*Message{{Content: []*Part{NewTextPart("hi")}, Role: RoleUser}},
+	}
+
+	t.Run("claimed stream failure is reported", func(t *testing.T) {
+		hedgeStarted := make(chan struct{})
+		claimed := make(chan struct{})
+		// The primary streams a chunk, claiming the client's callback,
+		// then fails after the hedge has reported its discarded success.
+		primary := DefineModel("test", "hedgeStreamFail", nil,
+			func(ctx context.Context, req *GenerateRequest, cb ModelStreamingCallback) (*GenerateResponse, error) {
+				<-hedgeStarted
+				if err := cb(ctx, &GenerateResponseChunk{Content: []*Part{NewTextPart("partial")}}); err != nil {
+					return nil, err
+				}
+				close(claimed)
+				time.Sleep(50 * time.Millisecond)
+				return nil, errors.New("provider reset the stream")
+			})
+		loser := DefineModel("test", "hedgeStreamLoser", nil,
+			func(ctx context.Context, req *GenerateRequest, cb ModelStreamingCallback) (*GenerateResponse, error) {
+				close(hedgeStarted)
+				<-claimed
+				return hedgeTextResponse(req, "loser"), nil
+			})
+		cfg := &HedgeConfig{
+			Delay:    time.Millisecond,
+			MaxExtra: 1,
+			Models:   []*Model{loser},
+		}
+		var chunks atomic.Int32
+		errCh := make(chan error, 1)
+		go func() {
+			_, err := GenerateHedged(context.Background(), primary, req, cfg,
+				func(ctx context.Context, c *GenerateResponseChunk) error {
+					chunks.Add(1)
+					return nil
+				})
+			errCh <- err
+		}()
+		select {
+		case err := <-errCh:
+			if err == nil {
+				t.Fatal("want the claiming attempt's error, got nil")
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("GenerateHedged hung after the claiming attempt failed")
+		}
+		if got := chunks.Load(); got != 1 {
+			t.Errorf("streamed %d chunks to the client, want 1", got)
+		}
+	})
+
+	t.Run("no hedges after a claim", func(t *testing.T) {
+		var hedges atomic.Int32
+		hedge := DefineModel("test", "hedgeStreamExtra", nil,
+			func(ctx context.Context, req *GenerateRequest, cb ModelStreamingCallback) (*GenerateResponse, error) {
+				hedges.Add(1)
+				return hedgeTextResponse(req, "hedge"), nil
+			})
+		// The primary claims the stream immediately, then takes several
+		// hedge delays to finish.
+		primary := DefineModel("test", "hedgeStreamWinner", nil,
+			func(ctx context.Context, req *GenerateRequest, cb ModelStreamingCallback) (*GenerateResponse, error) {
+				if err := cb(ctx, &GenerateResponseChunk{Content: []*Part{NewTextPart("win")}}); err != nil {
+					return nil, err
+				}
+				time.Sleep(150 * time.Millisecond)
+				return hedgeTextResponse(req, "winner"), nil
+			})
+		cfg := &HedgeConfig{
+			Delay:    50 * time.Millisecond,
+			MaxExtra: 3,
+			Models:   []*Model{hedge, hedge, hedge},
+		}
+		resp, err := GenerateHedged(context.Background(), primary, req, cfg,
+			func(ctx context.Context, c *GenerateResponseChunk) error { return nil })
+		if err != nil {
+			t.Fatal(err)
+		}
+		text, err := resp.Text()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if text != "winner" {
+			t.Errorf("got %q, want the claiming attempt's response", text)
+		}
+		if got := hedges.Load(); got != 0 {
+			t.Errorf("launched %d hedges after the stream was claimed, want 0", got)
+		}
+	})
+}
@@ -0,0 +1,84 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ai
+
+// A HistoryWindow truncates conversation history so that long chat
+// sessions don't grow a [GenerateRequest] without bound. The oldest
+// messages are dropped first; the final message, which carries the
+// current request, is never dropped.
+type HistoryWindow struct {
+	// MaxMessages is the maximum number of messages to keep.
+	// Zero means no limit on the number of messages.
+	MaxMessages int
+	// MaxCharacters is the maximum total number of characters of message
+	// text to keep, as a rough proxy for tokens.
+	// Zero means no limit on characters.
+	MaxCharacters int
+	// KeepSystem prevents system messages from being dropped.
+	// System messages still count against the limits.
+	KeepSystem bool
+}
+
+// Apply returns the messages remaining after applying the window.
+// It does not modify msgs.
+func (w HistoryWindow) Apply(msgs []*Message) []*Message {
+	if len(msgs) == 0 {
+		return msgs
+	}
+	keep := make([]bool, len(msgs))
+	count := 0
+	chars := 0
+	// The last message and, if KeepSystem is set, system messages are
+	// always kept; count them against the limits first.
+	for i, m := range msgs {
+		if i == len(msgs)-1 || (w.KeepSystem && m.Role == RoleSystem) {
+			keep[i] = true
+			count++
+			chars += messageChars(m)
+		}
+	}
+	// Then walk from newest to oldest, keeping messages while the limits allow.
+	for i := len(msgs) - 1; i >= 0; i-- {
+		if keep[i] {
+			continue
+		}
+		c := messageChars(msgs[i])
+		if w.MaxMessages > 0 && count+1 > w.MaxMessages {
+			continue
+		}
+		if w.MaxCharacters > 0 && chars+c > w.MaxCharacters {
+			continue
+		}
+		keep[i] = true
+		count++
+		chars += c
+	}
+	ret := make([]*Message, 0, count)
+	for i, m := range msgs {
+		if keep[i] {
+			ret = append(ret, m)
+		}
+	}
+	return ret
+}
+
+// messageChars returns the total number of characters of text in a message.
+func messageChars(m *Message) int {
+	n := 0
+	for _, p := range m.Content {
+		n += len(p.Text)
+	}
+	return n
+}
@@ -0,0 +1,71 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ai
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestHistoryWindow(t *testing.T) {
+	sys := &Message{Role: RoleSystem, Content: []*Part{NewTextPart("be nice")}}
+	turn := func(role Role, text string) *Message {
+		return &Message{Role: role, Content: []*Part{NewTextPart(text)}}
+	}
+	msgs := []*Message{
+		sys,
+		turn(RoleUser, "one"),
+		turn(RoleModel, "two"),
+		turn(RoleUser, "three"),
+		turn(RoleModel, "four"),
+		turn(RoleUser, "five"),
+	}
+
+	tests := []struct {
+		name   string
+		window HistoryWindow
+		want   []*Message
+	}{
+		{
+			name:   "maxMessages drops oldest",
+			window: HistoryWindow{MaxMessages: 3},
+			want:   msgs[3:],
+		},
+		{
+			name:   "keepSystem",
+			window: HistoryWindow{MaxMessages: 3, KeepSystem: true},
+			want:   []*Message{sys, msgs[4], msgs[5]},
+		},
+		{
+			name:   "maxCharacters",
+			window: HistoryWindow{MaxCharacters: 9},
+			want:   msgs[4:],
+		},
+		{
+			name:   "no limits",
+			window: HistoryWindow{},
+			want:   msgs,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := test.window.Apply(msgs)
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("mismatch (-want, +got):\n%s", diff)
+			}
+		})
+	}
+}
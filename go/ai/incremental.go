@@ -0,0 +1,94 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ai
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+)
+
+// This file implements incremental indexing. Re-embedding an entire
+// corpus on every deploy is slow and expensive; SyncIndex hashes each
+// document and only sends new or changed documents to the indexer,
+// using a manifest the caller persists between runs.
+
+// An IndexSummary reports what one SyncIndex run did.
+type IndexSummary struct {
+	// Indexed is the number of new or changed documents sent to the
+	// indexer.
+	Indexed int `json:"indexed"`
+	// Unchanged is the number of documents skipped because their
+	// content hash matched the manifest.
+	Unchanged int `json:"unchanged"`
+	// Deleted is the number of documents that were in the manifest
+	// but not in this run's source.
+	Deleted int `json:"deleted"`
+}
+
+// SyncIndex incrementally indexes docs, a map from stable document key
+// (e.g. file path) to document. It compares each document's content
+// hash with manifest, the map returned by the previous run (nil on the
+// first run), indexes only new or changed documents, and calls remove
+// for each key that has disappeared from the source, so the backing
+// store can delete it. remove may be nil if the store does not support
+// deletion. It returns the new manifest, which the caller persists for
+// the next run, and a summary.
+func SyncIndex(ctx context.Context, ix *Indexer, docs map[string]*Document, manifest map[string]string, remove func(ctx context.Context, key string) error) (map[string]string, *IndexSummary, error) {
+	next := make(map[string]string, len(docs))
+	summary := &IndexSummary{}
+	var changed []*Document
+	for key, doc := range docs {
+		hash, err := documentHash(doc)
+		if err != nil {
+			return nil, nil, fmt.Errorf("ai.SyncIndex: hashing %q: %w", key, err)
+		}
+		next[key] = hash
+		if manifest[key] == hash {
+			summary.Unchanged++
+			continue
+		}
+		changed = append(changed, doc)
+	}
+	if len(changed) > 0 {
+		if err := ix.Index(ctx, &IndexerRequest{Documents: changed}); err != nil {
+			return nil, nil, err
+		}
+		summary.Indexed = len(changed)
+	}
+	for key := range manifest {
+		if _, ok := docs[key]; ok {
+			continue
+		}
+		if remove != nil {
+			if err := remove(ctx, key); err != nil {
+				return nil, nil, fmt.Errorf("ai.SyncIndex: removing %q: %w", key, err)
+			}
+		}
+		summary.Deleted++
+	}
+	return next, summary, nil
+}
+
+// documentHash returns a stable hash of a document's content and
+// metadata.
+func documentHash(doc *Document) (string, error) {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(data)), nil
+}
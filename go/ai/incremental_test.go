@@ -0,0 +1,75 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ai
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSyncIndex(t *testing.T) {
+	var indexed []*Document
+	ix := DefineIndexer("test", "incremental", func(ctx context.Context, req *IndexerRequest) error {
+		indexed = append(indexed, req.Documents...)
+		return nil
+	})
+	ctx := context.Background()
+
+	docs := map[string]*Document{
+		"a.txt": DocumentFromText("alpha", nil),
+		"b.txt": DocumentFromText("beta", nil),
+	}
+	manifest, summary, err := SyncIndex(ctx, ix, docs, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Indexed != 2 || summary.Unchanged != 0 || summary.Deleted != 0 {
+		t.Errorf("first run summary = %+v", summary)
+	}
+	if len(indexed) != 2 {
+		t.Fatalf("indexed %d documents, want 2", len(indexed))
+	}
+
+	// Change one document, remove another, add a third.
+	indexed = nil
+	docs = map[string]*Document{
+		"a.txt": DocumentFromText("alpha v2", nil),
+		"c.txt": DocumentFromText("gamma", nil),
+	}
+	var removed []string
+	manifest, summary, err = SyncIndex(ctx, ix, docs, manifest, func(_ context.Context, key string) error {
+		removed = append(removed, key)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Indexed != 2 || summary.Unchanged != 0 || summary.Deleted != 1 {
+		t.Errorf("second run summary = %+v", summary)
+	}
+	if len(removed) != 1 || removed[0] != "b.txt" {
+		t.Errorf("removed = %v, want [b.txt]", removed)
+	}
+
+	// A no-op run indexes nothing.
+	indexed = nil
+	_, summary, err = SyncIndex(ctx, ix, docs, manifest, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if summary.Indexed != 0 || summary.Unchanged != 2 || len(indexed) != 0 {
+		t.Errorf("no-op run summary = %+v, indexed %d", summary, len(indexed))
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ingest
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/firebase/genkit/go/ai"
+)
+
+// LoadDOCX loads a DOCX file as a single document.
+//
+// A DOCX file is a zip archive; the text lives in word/document.xml as
+// w:t elements grouped into w:p paragraphs, so no external dependency
+// is needed to extract it.
+func LoadDOCX(path string) ([]*ai.Document, error) {
+	zr, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("ingest: opening DOCX: %w", err)
+	}
+	defer zr.Close()
+	for _, f := range zr.File {
+		if f.Name != "word/document.xml" {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer rc.Close()
+		text, err := docxToText(rc)
+		if err != nil {
+			return nil, err
+		}
+		return []*ai.Document{ai.DocumentFromText(text, sourceMetadata(path))}, nil
+	}
+	return nil, errors.New("ingest: DOCX file has no word/document.xml")
+}
+
+// docxToText extracts the text of a DOCX document.xml stream,
+// separating paragraphs with newlines.
+func docxToText(r io.Reader) (string, error) {
+	dec := xml.NewDecoder(r)
+	var sb strings.Builder
+	inText := false
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("ingest: parsing DOCX XML: %w", err)
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if t.Name.Local == "t" {
+				inText = true
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				sb.WriteByte('\n')
+			}
+		case xml.CharData:
+			if inText {
+				sb.Write(t)
+			}
+		}
+	}
+	return strings.TrimSpace(sb.String()), nil
+}
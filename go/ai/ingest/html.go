@@ -0,0 +1,90 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ingest
+
+import (
+	"io"
+	"os"
+	"strings"
+
+	"github.com/firebase/genkit/go/ai"
+	"golang.org/x/net/html"
+)
+
+// LoadHTML loads an HTML file as a single document containing the page's
+// visible text. Script, style and head content is discarded. If the page
+// has a title, it is recorded in the document's metadata under "title".
+func LoadHTML(path string) ([]*ai.Document, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	text, title, err := htmlToText(f)
+	if err != nil {
+		return nil, err
+	}
+	md := sourceMetadata(path)
+	if title != "" {
+		md["title"] = title
+	}
+	return []*ai.Document{ai.DocumentFromText(text, md)}, nil
+}
+
+// htmlToText extracts the visible text and the title from HTML.
+func htmlToText(r io.Reader) (text, title string, err error) {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return "", "", err
+	}
+	var sb strings.Builder
+	var visit func(*html.Node)
+	visit = func(n *html.Node) {
+		switch {
+		case n.Type == html.ElementNode && (n.Data == "script" || n.Data == "style" || n.Data == "head"):
+			// Still look for the title inside head.
+			for c := n.FirstChild; c != nil; c = c.NextSibling {
+				if c.Type == html.ElementNode && c.Data == "title" && c.FirstChild != nil {
+					title = strings.TrimSpace(c.FirstChild.Data)
+				}
+			}
+			return
+		case n.Type == html.TextNode:
+			if s := strings.TrimSpace(n.Data); s != "" {
+				if sb.Len() > 0 {
+					sb.WriteByte(' ')
+				}
+				sb.WriteString(s)
+			}
+		case n.Type == html.ElementNode && isHTMLBlock(n.Data):
+			if sb.Len() > 0 {
+				sb.WriteByte('\n')
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			visit(c)
+		}
+	}
+	visit(doc)
+	return sb.String(), title, nil
+}
+
+func isHTMLBlock(tag string) bool {
+	switch tag {
+	case "p", "div", "br", "h1", "h2", "h3", "h4", "h5", "h6", "li", "tr", "article", "section":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,129 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package ingest converts common document formats — PDF, DOCX, HTML,
+// Markdown and plain text — into [ai.Document] values suitable for
+// embedding and indexing.
+//
+// Each returned document carries a "source" metadata key with the path it
+// was loaded from. Formats with internal structure add more: PDF loaders
+// produce one document per page with a "page" key, and the Markdown loader
+// produces one document per section with a "heading" key.
+package ingest
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/firebase/genkit/go/ai"
+)
+
+// LoadFile loads the file at path, choosing the loader from the file
+// extension. It supports .pdf, .docx, .html, .htm, .md and .txt files.
+func LoadFile(path string) ([]*ai.Document, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".pdf":
+		return LoadPDF(path)
+	case ".docx":
+		return LoadDOCX(path)
+	case ".html", ".htm":
+		return LoadHTML(path)
+	case ".md":
+		return LoadMarkdown(path)
+	case ".txt":
+		return LoadText(path)
+	default:
+		return nil, fmt.Errorf("ingest: unsupported file type %q", filepath.Ext(path))
+	}
+}
+
+// LoadDir loads every supported file under dir, recursively.
+// Unsupported files are skipped.
+func LoadDir(dir string) ([]*ai.Document, error) {
+	var docs []*ai.Document
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".pdf", ".docx", ".html", ".htm", ".md", ".txt":
+		default:
+			return nil
+		}
+		ds, err := LoadFile(path)
+		if err != nil {
+			return fmt.Errorf("ingest: loading %s: %w", path, err)
+		}
+		docs = append(docs, ds...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+// LoadText loads a plain text file as a single document.
+func LoadText(path string) ([]*ai.Document, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return []*ai.Document{ai.DocumentFromText(string(data), sourceMetadata(path))}, nil
+}
+
+// LoadMarkdown loads a Markdown file, producing one document per section.
+// A section starts at each heading; its text includes the heading line.
+// Each document's metadata records the heading text under "heading".
+// Text before the first heading becomes a document without a heading.
+func LoadMarkdown(path string) ([]*ai.Document, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var docs []*ai.Document
+	var section []string
+	heading := ""
+	flush := func() {
+		text := strings.TrimSpace(strings.Join(section, "\n"))
+		if text == "" {
+			return
+		}
+		md := sourceMetadata(path)
+		if heading != "" {
+			md["heading"] = heading
+		}
+		docs = append(docs, ai.DocumentFromText(text, md))
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.HasPrefix(line, "#") {
+			flush()
+			section = nil
+			heading = strings.TrimSpace(strings.TrimLeft(line, "# "))
+		}
+		section = append(section, line)
+	}
+	flush()
+	return docs, nil
+}
+
+func sourceMetadata(path string) map[string]any {
+	return map[string]any{"source": path}
+}
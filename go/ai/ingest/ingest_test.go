@@ -0,0 +1,135 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ingest
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadMarkdown(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "doc.md")
+	content := "intro text\n\n# First\n\nfirst section\n\n## Second\n\nsecond section\n"
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	docs, err := LoadMarkdown(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 3 {
+		t.Fatalf("got %d documents, want 3", len(docs))
+	}
+	if docs[0].Metadata["heading"] != nil {
+		t.Errorf("got heading %v for preamble, want none", docs[0].Metadata["heading"])
+	}
+	if got := docs[1].Metadata["heading"]; got != "First" {
+		t.Errorf("got heading %v, want First", got)
+	}
+	if got := docs[2].Metadata["heading"]; got != "Second" {
+		t.Errorf("got heading %v, want Second", got)
+	}
+	if !strings.Contains(docs[2].Content[0].Text, "second section") {
+		t.Errorf("section text missing: %q", docs[2].Content[0].Text)
+	}
+}
+
+func TestLoadHTML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "page.html")
+	content := `<html><head><title>The Title</title><style>p {}</style></head>
+<body><p>Hello</p><script>var x;</script><p>world</p></body></html>`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	docs, err := LoadHTML(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 1 {
+		t.Fatalf("got %d documents, want 1", len(docs))
+	}
+	text := docs[0].Content[0].Text
+	if !strings.Contains(text, "Hello") || !strings.Contains(text, "world") {
+		t.Errorf("text missing content: %q", text)
+	}
+	if strings.Contains(text, "var x") || strings.Contains(text, "p {}") {
+		t.Errorf("text contains script or style: %q", text)
+	}
+	if got := docs[0].Metadata["title"]; got != "The Title" {
+		t.Errorf("got title %v, want The Title", got)
+	}
+}
+
+func TestLoadDOCX(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "doc.docx")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	w, err := zw.Create("word/document.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	docXML := `<?xml version="1.0"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+<w:body><w:p><w:r><w:t>Hello</w:t></w:r><w:r><w:t> world</w:t></w:r></w:p>
+<w:p><w:r><w:t>Second paragraph</w:t></w:r></w:p></w:body></w:document>`
+	if _, err := w.Write([]byte(docXML)); err != nil {
+		t.Fatal(err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	docs, err := LoadDOCX(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 1 {
+		t.Fatalf("got %d documents, want 1", len(docs))
+	}
+	text := docs[0].Content[0].Text
+	want := "Hello world\nSecond paragraph"
+	if text != want {
+		t.Errorf("got %q, want %q", text, want)
+	}
+}
+
+func TestLoadDir(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("text a"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "b.md"), []byte("# B\n\ntext b"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "skip.bin"), []byte{0}, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	docs, err := LoadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 2 {
+		t.Fatalf("got %d documents, want 2", len(docs))
+	}
+}
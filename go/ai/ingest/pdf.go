@@ -0,0 +1,51 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ingest
+
+import (
+	"strings"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/ledongthuc/pdf"
+)
+
+// LoadPDF loads a PDF file, producing one document per page.
+// Each document's metadata records the 1-based page number under "page".
+// Pages with no extractable text are skipped.
+func LoadPDF(path string) ([]*ai.Document, error) {
+	f, r, err := pdf.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var docs []*ai.Document
+	for i := 1; i <= r.NumPage(); i++ {
+		page := r.Page(i)
+		if page.V.IsNull() {
+			continue
+		}
+		text, err := page.GetPlainText(nil)
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+		md := sourceMetadata(path)
+		md["page"] = i
+		docs = append(docs, ai.DocumentFromText(text, md))
+	}
+	return docs, nil
+}
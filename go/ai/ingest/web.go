@@ -0,0 +1,268 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ingest
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"golang.org/x/net/html"
+)
+
+// A WebLoader fetches web pages and converts them to documents.
+// It is polite by default: it honors robots.txt and waits Delay between
+// fetches. The zero value is ready to use.
+type WebLoader struct {
+	// The HTTP client to use. If nil, http.DefaultClient.
+	Client *http.Client
+	// The minimum time between two fetches. If zero, one second.
+	Delay time.Duration
+	// The User-Agent header to send. If empty, "genkit-webloader".
+	UserAgent string
+	// If true, do not fetch or honor robots.txt.
+	IgnoreRobots bool
+
+	mu       sync.Mutex
+	lastTime time.Time
+	// Disallowed path prefixes for the wildcard user agent, per host.
+	robots map[string][]string
+}
+
+// LoadURL fetches a page and returns it as a single document containing the
+// page's visible text. The document's metadata records the page's canonical
+// URL (from a rel="canonical" link, falling back to the fetched URL) under
+// "url", so retrieval results can cite their source.
+// It returns an error if robots.txt disallows fetching the page.
+func (l *WebLoader) LoadURL(ctx context.Context, pageURL string) ([]*ai.Document, error) {
+	body, err := l.fetch(ctx, pageURL)
+	if err != nil {
+		return nil, err
+	}
+	text, title, err := htmlToText(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	canonical := canonicalURL(bytes.NewReader(body))
+	if canonical == "" {
+		canonical = pageURL
+	}
+	md := map[string]any{"url": canonical}
+	if title != "" {
+		md["title"] = title
+	}
+	return []*ai.Document{ai.DocumentFromText(text, md)}, nil
+}
+
+// LoadSitemap fetches a sitemap and then every page it lists, returning one
+// document per page in the manner of [WebLoader.LoadURL].
+// Pages disallowed by robots.txt are skipped rather than reported as errors.
+func (l *WebLoader) LoadSitemap(ctx context.Context, sitemapURL string) ([]*ai.Document, error) {
+	body, err := l.fetch(ctx, sitemapURL)
+	if err != nil {
+		return nil, err
+	}
+	var sitemap struct {
+		URLs []struct {
+			Loc string `xml:"loc"`
+		} `xml:"url"`
+	}
+	if err := xml.Unmarshal(body, &sitemap); err != nil {
+		return nil, fmt.Errorf("ingest: parsing sitemap: %w", err)
+	}
+	var docs []*ai.Document
+	for _, u := range sitemap.URLs {
+		loc := strings.TrimSpace(u.Loc)
+		if loc == "" {
+			continue
+		}
+		ds, err := l.LoadURL(ctx, loc)
+		if err != nil {
+			if _, ok := err.(*robotsDisallowedError); ok {
+				continue
+			}
+			return nil, err
+		}
+		docs = append(docs, ds...)
+	}
+	return docs, nil
+}
+
+type robotsDisallowedError struct {
+	url string
+}
+
+func (e *robotsDisallowedError) Error() string {
+	return fmt.Sprintf("ingest: robots.txt disallows fetching %s", e.url)
+}
+
+// fetch retrieves a URL, enforcing robots.txt and the fetch delay.
+func (l *WebLoader) fetch(ctx context.Context, fetchURL string) ([]byte, error) {
+	u, err := url.Parse(fetchURL)
+	if err != nil {
+		return nil, err
+	}
+	if !l.IgnoreRobots {
+		allowed, err := l.robotsAllowed(ctx, u)
+		if err != nil {
+			return nil, err
+		}
+		if !allowed {
+			return nil, &robotsDisallowedError{fetchURL}
+		}
+	}
+	l.waitTurn()
+	return l.get(ctx, fetchURL)
+}
+
+// waitTurn sleeps as needed so that fetches are at least Delay apart.
+func (l *WebLoader) waitTurn() {
+	delay := l.Delay
+	if delay == 0 {
+		delay = time.Second
+	}
+	l.mu.Lock()
+	wait := time.Until(l.lastTime.Add(delay))
+	now := time.Now()
+	if wait > 0 {
+		now = now.Add(wait)
+	}
+	l.lastTime = now
+	l.mu.Unlock()
+	if wait > 0 {
+		time.Sleep(wait)
+	}
+}
+
+// robotsAllowed reports whether robots.txt permits fetching u.
+// The robots.txt of each host is fetched once and cached.
+func (l *WebLoader) robotsAllowed(ctx context.Context, u *url.URL) (bool, error) {
+	l.mu.Lock()
+	disallowed, ok := l.robots[u.Host]
+	l.mu.Unlock()
+	if !ok {
+		body, err := l.get(ctx, u.Scheme+"://"+u.Host+"/robots.txt")
+		if err != nil {
+			// No reachable robots.txt means no restrictions.
+			body = nil
+		}
+		disallowed = parseRobots(body)
+		l.mu.Lock()
+		if l.robots == nil {
+			l.robots = map[string][]string{}
+		}
+		l.robots[u.Host] = disallowed
+		l.mu.Unlock()
+	}
+	for _, prefix := range disallowed {
+		if strings.HasPrefix(u.Path, prefix) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// parseRobots extracts the disallowed path prefixes that apply to the
+// wildcard user agent.
+func parseRobots(body []byte) []string {
+	var disallowed []string
+	applies := false
+	for _, line := range strings.Split(string(body), "\n") {
+		if i := strings.IndexByte(line, '#'); i >= 0 {
+			line = line[:i]
+		}
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		key = strings.TrimSpace(strings.ToLower(key))
+		value = strings.TrimSpace(value)
+		switch key {
+		case "user-agent":
+			applies = value == "*"
+		case "disallow":
+			if applies && value != "" {
+				disallowed = append(disallowed, value)
+			}
+		}
+	}
+	return disallowed
+}
+
+func (l *WebLoader) get(ctx context.Context, fetchURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", fetchURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	ua := l.UserAgent
+	if ua == "" {
+		ua = "genkit-webloader"
+	}
+	req.Header.Set("User-Agent", ua)
+	client := l.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ingest: fetching %s: %s", fetchURL, res.Status)
+	}
+	return io.ReadAll(res.Body)
+}
+
+// canonicalURL returns the href of a rel="canonical" link in the HTML,
+// or the empty string.
+func canonicalURL(r io.Reader) string {
+	doc, err := html.Parse(r)
+	if err != nil {
+		return ""
+	}
+	var canonical string
+	var visit func(*html.Node)
+	visit = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "link" {
+			rel, href := "", ""
+			for _, a := range n.Attr {
+				switch a.Key {
+				case "rel":
+					rel = a.Val
+				case "href":
+					href = a.Val
+				}
+			}
+			if rel == "canonical" {
+				canonical = href
+				return
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			visit(c)
+		}
+	}
+	visit(doc)
+	return canonical
+}
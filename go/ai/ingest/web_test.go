@@ -0,0 +1,73 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ingest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWebLoader(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			fmt.Fprint(w, "User-agent: *\nDisallow: /private\n")
+		case "/sitemap.xml":
+			fmt.Fprintf(w, `<?xml version="1.0"?>
+<urlset><url><loc>%s/a</loc></url><url><loc>%s/private/b</loc></url></urlset>`, srv.URL, srv.URL)
+		case "/a":
+			fmt.Fprintf(w, `<html><head><title>A</title><link rel="canonical" href="https://example.com/a"></head><body><p>page a</p></body></html>`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	l := &WebLoader{Delay: 1}
+	ctx := context.Background()
+
+	docs, err := l.LoadURL(ctx, srv.URL+"/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 1 {
+		t.Fatalf("got %d documents, want 1", len(docs))
+	}
+	if !strings.Contains(docs[0].Content[0].Text, "page a") {
+		t.Errorf("text missing content: %q", docs[0].Content[0].Text)
+	}
+	if got := docs[0].Metadata["url"]; got != "https://example.com/a" {
+		t.Errorf("got url %v, want canonical URL", got)
+	}
+
+	// Disallowed paths are refused.
+	if _, err := l.LoadURL(ctx, srv.URL+"/private/b"); err == nil {
+		t.Error("got nil, want robots.txt error")
+	}
+
+	// The sitemap loader skips disallowed pages.
+	docs, err = l.LoadSitemap(ctx, srv.URL+"/sitemap.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 1 {
+		t.Fatalf("got %d documents, want 1", len(docs))
+	}
+}
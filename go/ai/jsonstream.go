@@ -0,0 +1,349 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ai
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// An ArrayStreamer incrementally parses the text of a JSON array as it is
+// streamed in chunks, and reports each completed element exactly once.
+//
+// Models that produce structured output stream the serialized value a few
+// tokens at a time, so a client that wants the elements of an array output
+// would otherwise have to re-parse the growing partial text and filter out
+// elements it has already seen. Feed each streamed chunk to [ArrayStreamer.Add]
+// instead, and unmarshal the returned raw messages.
+//
+// ArrayStreamer also accepts newline-delimited JSON (JSONL): if the first
+// non-space character is not '[', each top-level value is treated as an
+// element.
+//
+// The zero value is ready to use.
+type ArrayStreamer struct {
+	mode     arrayStreamerMode
+	elem     []byte // the incomplete element, so far
+	depth    int    // bracket depth within elem
+	inString bool
+	escaped  bool
+	done     bool // saw the end of the array
+}
+
+type arrayStreamerMode int
+
+const (
+	modeUnknown arrayStreamerMode = iota
+	modeArray
+	modeJSONL
+)
+
+// Add consumes the next chunk of streamed text and returns the elements that
+// it completed, in order. It returns an error if the text is not a JSON array
+// or JSONL sequence.
+func (s *ArrayStreamer) Add(text string) ([]json.RawMessage, error) {
+	var elems []json.RawMessage
+	for i := 0; i < len(text); i++ {
+		b := text[i]
+		if s.done {
+			if !isJSONSpace(b) {
+				return elems, fmt.Errorf("unexpected %q after end of JSON array", b)
+			}
+			continue
+		}
+		if s.mode == modeUnknown {
+			if isJSONSpace(b) {
+				continue
+			}
+			if b == '[' {
+				s.mode = modeArray
+				continue
+			}
+			s.mode = modeJSONL
+		}
+		if len(s.elem) == 0 {
+			// Between elements.
+			if isJSONSpace(b) || b == ',' {
+				continue
+			}
+			if s.mode == modeArray && b == ']' {
+				s.done = true
+				continue
+			}
+		}
+		if s.inString {
+			s.elem = append(s.elem, b)
+			switch {
+			case s.escaped:
+				s.escaped = false
+			case b == '\\':
+				s.escaped = true
+			case b == '"':
+				s.inString = false
+				if s.depth == 0 {
+					elems = append(elems, s.take())
+				}
+			}
+			continue
+		}
+		switch b {
+		case '"':
+			s.inString = true
+			s.elem = append(s.elem, b)
+		case '{', '[':
+			s.depth++
+			s.elem = append(s.elem, b)
+		case '}', ']':
+			if s.depth == 0 {
+				if s.mode == modeArray && b == ']' && s.primitive() {
+					// The array's closing bracket also ends a primitive element.
+					elems = append(elems, s.take())
+					s.done = true
+					continue
+				}
+				return elems, fmt.Errorf("unexpected %q in JSON array element", b)
+			}
+			s.depth--
+			s.elem = append(s.elem, b)
+			if s.depth == 0 {
+				elems = append(elems, s.take())
+			}
+		case ',', '\n':
+			if s.depth == 0 {
+				// Ends a primitive element such as a number or "true".
+				if s.primitive() {
+					elems = append(elems, s.take())
+				}
+				continue
+			}
+			s.elem = append(s.elem, b)
+		default:
+			if s.depth == 0 && isJSONSpace(b) {
+				if s.primitive() {
+					elems = append(elems, s.take())
+				}
+				continue
+			}
+			s.elem = append(s.elem, b)
+		}
+	}
+	return elems, nil
+}
+
+// Done reports whether the streamed array has been closed.
+// It is always false in JSONL mode.
+func (s *ArrayStreamer) Done() bool { return s.done }
+
+// primitive reports whether the incomplete element is a non-empty primitive
+// (a number, or one of true, false and null).
+func (s *ArrayStreamer) primitive() bool {
+	return len(s.elem) > 0 && s.elem[0] != '{' && s.elem[0] != '[' && s.elem[0] != '"'
+}
+
+// take returns the completed element and resets the element buffer.
+func (s *ArrayStreamer) take() json.RawMessage {
+	elem := json.RawMessage(s.elem)
+	s.elem = nil
+	return elem
+}
+
+func isJSONSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r'
+}
+
+// An ObjectField is one completed top-level field of a streamed JSON
+// object, as reported by [ObjectStreamer.Add].
+type ObjectField struct {
+	Key   string
+	Value json.RawMessage
+}
+
+// An ObjectStreamer incrementally parses the text of a JSON object as
+// it is streamed in chunks, and reports each completed top-level field
+// exactly once. It is the object counterpart of [ArrayStreamer]: a
+// client that wants the fields of a structured output as they complete
+// would otherwise have to re-parse the growing partial text on every
+// chunk, which is quadratic in the output length. The streamer keeps
+// its scan state across chunks, so each byte is examined once.
+//
+// The zero value is ready to use.
+type ObjectStreamer struct {
+	state    objState
+	key      []byte // the raw key string of the current field, with quotes
+	value    []byte // the incomplete value, so far
+	depth    int    // bracket depth within value
+	inString bool
+	escaped  bool
+	done     bool // saw the end of the object
+}
+
+type objState int
+
+const (
+	objStart     objState = iota // before the opening brace
+	objSeekKey                   // between fields
+	objInKey                     // inside the key string
+	objSeekColon                 // between key and ':'
+	objValue                     // inside the value
+)
+
+// Add consumes the next chunk of streamed text and returns the fields
+// that it completed, in order. It returns an error if the text is not
+// a JSON object.
+func (s *ObjectStreamer) Add(text string) ([]ObjectField, error) {
+	var fields []ObjectField
+	for i := 0; i < len(text); i++ {
+		b := text[i]
+		if s.done {
+			if !isJSONSpace(b) {
+				return fields, fmt.Errorf("unexpected %q after end of JSON object", b)
+			}
+			continue
+		}
+		switch s.state {
+		case objStart:
+			if isJSONSpace(b) {
+				continue
+			}
+			if b != '{' {
+				return fields, fmt.Errorf("unexpected %q at start of JSON object", b)
+			}
+			s.state = objSeekKey
+		case objSeekKey:
+			switch {
+			case isJSONSpace(b) || b == ',':
+			case b == '"':
+				s.key = append(s.key[:0], b)
+				s.state = objInKey
+			case b == '}':
+				s.done = true
+			default:
+				return fields, fmt.Errorf("unexpected %q before JSON object key", b)
+			}
+		case objInKey:
+			s.key = append(s.key, b)
+			switch {
+			case s.escaped:
+				s.escaped = false
+			case b == '\\':
+				s.escaped = true
+			case b == '"':
+				s.state = objSeekColon
+			}
+		case objSeekColon:
+			switch {
+			case isJSONSpace(b):
+			case b == ':':
+				s.state = objValue
+			default:
+				return fields, fmt.Errorf("unexpected %q after JSON object key", b)
+			}
+		case objValue:
+			if len(s.value) == 0 && isJSONSpace(b) {
+				continue
+			}
+			if s.inString {
+				s.value = append(s.value, b)
+				switch {
+				case s.escaped:
+					s.escaped = false
+				case b == '\\':
+					s.escaped = true
+				case b == '"':
+					s.inString = false
+					if s.depth == 0 {
+						if err := s.emit(&fields); err != nil {
+							return fields, err
+						}
+					}
+				}
+				continue
+			}
+			switch b {
+			case '"':
+				s.inString = true
+				s.value = append(s.value, b)
+			case '{', '[':
+				s.depth++
+				s.value = append(s.value, b)
+			case '}', ']':
+				if s.depth == 0 {
+					if b == '}' && s.valuePrimitive() {
+						// The object's closing brace also ends a
+						// primitive value.
+						if err := s.emit(&fields); err != nil {
+							return fields, err
+						}
+						s.done = true
+						continue
+					}
+					return fields, fmt.Errorf("unexpected %q in JSON object value", b)
+				}
+				s.depth--
+				s.value = append(s.value, b)
+				if s.depth == 0 {
+					if err := s.emit(&fields); err != nil {
+						return fields, err
+					}
+				}
+			case ',':
+				if s.depth == 0 {
+					// Ends a primitive value such as a number or "true".
+					if !s.valuePrimitive() {
+						return fields, fmt.Errorf("unexpected %q in JSON object value", b)
+					}
+					if err := s.emit(&fields); err != nil {
+						return fields, err
+					}
+					continue
+				}
+				s.value = append(s.value, b)
+			default:
+				if s.depth == 0 && isJSONSpace(b) {
+					if s.valuePrimitive() {
+						if err := s.emit(&fields); err != nil {
+							return fields, err
+						}
+					}
+					continue
+				}
+				s.value = append(s.value, b)
+			}
+		}
+	}
+	return fields, nil
+}
+
+// Done reports whether the streamed object has been closed.
+func (s *ObjectStreamer) Done() bool { return s.done }
+
+// valuePrimitive reports whether the incomplete value is a non-empty
+// primitive (a number, or one of true, false and null).
+func (s *ObjectStreamer) valuePrimitive() bool {
+	return len(s.value) > 0 && s.value[0] != '{' && s.value[0] != '[' && s.value[0] != '"'
+}
+
+// emit appends the completed field and resets for the next one.
+func (s *ObjectStreamer) emit(fields *[]ObjectField) error {
+	var key string
+	if err := json.Unmarshal(s.key, &key); err != nil {
+		return fmt.Errorf("invalid JSON object key %s: %w", s.key, err)
+	}
+	*fields = append(*fields, ObjectField{Key: key, Value: json.RawMessage(s.value)})
+	s.key = nil
+	s.value = nil
+	s.state = objSeekKey
+	return nil
+}
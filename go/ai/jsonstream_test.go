@@ -0,0 +1,207 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ai
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestArrayStreamer(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		chunks []string
+		want   []string
+		done   bool
+	}{
+		{
+			name:   "objects split across chunks",
+			chunks: []string{`[{"a":`, `1}, {"a"`, `:2}`, `]`},
+			want:   []string{`{"a":1}`, `{"a":2}`},
+			done:   true,
+		},
+		{
+			name:   "strings with escapes",
+			chunks: []string{`["a\"b", "c`, `d"]`},
+			want:   []string{`"a\"b"`, `"cd"`},
+			done:   true,
+		},
+		{
+			name:   "numbers",
+			chunks: []string{`[1, 22,`, ` 3]`},
+			want:   []string{`1`, `22`, `3`},
+			done:   true,
+		},
+		{
+			name:   "nested arrays",
+			chunks: []string{`[[1,2],[3,`, `4]]`},
+			want:   []string{`[1,2]`, `[3,4]`},
+			done:   true,
+		},
+		{
+			name:   "incomplete trailing element",
+			chunks: []string{`[{"a":1},{"a":`},
+			want:   []string{`{"a":1}`},
+		},
+		{
+			name:   "jsonl",
+			chunks: []string{`{"a":1}` + "\n" + `{"a"`, `:2}` + "\n"},
+			want:   []string{`{"a":1}`, `{"a":2}`},
+		},
+		{
+			name:   "commas inside strings",
+			chunks: []string{`["a,b", {"c":"],"}]`},
+			want:   []string{`"a,b"`, `{"c":"],"}`},
+			done:   true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var s ArrayStreamer
+			var got []string
+			for _, chunk := range test.chunks {
+				elems, err := s.Add(chunk)
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, e := range elems {
+					got = append(got, string(e))
+				}
+			}
+			if !slices.Equal(got, test.want) {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+			if s.Done() != test.done {
+				t.Errorf("Done() = %t, want %t", s.Done(), test.done)
+			}
+		})
+	}
+}
+
+func TestArrayStreamerBadInput(t *testing.T) {
+	var s ArrayStreamer
+	if _, err := s.Add(`[}`); err == nil {
+		t.Error("got nil, want error")
+	}
+}
+
+func TestObjectStreamer(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		chunks []string
+		want   []ObjectField
+		done   bool
+	}{
+		{
+			name:   "fields split across chunks",
+			chunks: []string{`{"title": "a `, `story", "rating"`, `: 5}`},
+			want: []ObjectField{
+				{"title", []byte(`"a story"`)},
+				{"rating", []byte(`5`)},
+			},
+			done: true,
+		},
+		{
+			name:   "nested values complete as a unit",
+			chunks: []string{`{"steps": [{"n":1},`, `{"n":2}], "ok": true`, `}`},
+			want: []ObjectField{
+				{"steps", []byte(`[{"n":1},{"n":2}]`)},
+				{"ok", []byte(`true`)},
+			},
+			done: true,
+		},
+		{
+			name:   "escaped key and value",
+			chunks: []string{`{"a\"b": "c\`, `"d"}`},
+			want:   []ObjectField{{`a"b`, []byte(`"c\"d"`)}},
+			done:   true,
+		},
+		{
+			name:   "incomplete trailing field",
+			chunks: []string{`{"done": false, "partial": "so far`},
+			want:   []ObjectField{{"done", []byte(`false`)}},
+		},
+		{
+			name:   "empty object",
+			chunks: []string{` {`, `}`},
+			want:   nil,
+			done:   true,
+		},
+		{
+			name:   "space-delimited primitive",
+			chunks: []string{`{"n": 7 , "m": 8 }`},
+			want: []ObjectField{
+				{"n", []byte(`7`)},
+				{"m", []byte(`8`)},
+			},
+			done: true,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			var s ObjectStreamer
+			var got []ObjectField
+			for _, chunk := range test.chunks {
+				fields, err := s.Add(chunk)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got = append(got, fields...)
+			}
+			if len(got) != len(test.want) {
+				t.Fatalf("got %d fields %v, want %d", len(got), got, len(test.want))
+			}
+			for i, f := range got {
+				if f.Key != test.want[i].Key || string(f.Value) != string(test.want[i].Value) {
+					t.Errorf("field %d = {%q %s}, want {%q %s}", i, f.Key, f.Value, test.want[i].Key, test.want[i].Value)
+				}
+			}
+			if s.Done() != test.done {
+				t.Errorf("Done() = %t, want %t", s.Done(), test.done)
+			}
+		})
+	}
+}
+
+func TestObjectStreamerSplitEverywhere(t *testing.T) {
+	// Splitting the input at any byte must not change the result.
+	const input = `{"title": "a \"story\"", "steps": [{"n": 1}], "rating": 4.5, "ok": true}`
+	for i := 1; i < len(input); i++ {
+		var s ObjectStreamer
+		var got []ObjectField
+		for _, chunk := range []string{input[:i], input[i:]} {
+			fields, err := s.Add(chunk)
+			if err != nil {
+				t.Fatalf("split at %d: %v", i, err)
+			}
+			got = append(got, fields...)
+		}
+		if len(got) != 4 || !s.Done() {
+			t.Fatalf("split at %d: got %d fields, done %t", i, len(got), s.Done())
+		}
+	}
+}
+
+func TestObjectStreamerErrors(t *testing.T) {
+	for _, text := range []string{
+		`["not", "an", "object"]`,
+		`{"a" 1}`,
+		`{1: 2}`,
+		`{} trailing`,
+	} {
+		var s ObjectStreamer
+		if _, err := s.Add(text); err == nil {
+			t.Errorf("Add(%q) succeeded, want error", text)
+		}
+	}
+}
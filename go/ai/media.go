@@ -0,0 +1,168 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ai
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// MediaContent is a generated image, audio or video extracted from a
+// response, with any inline "data:" URI already decoded.
+type MediaContent struct {
+	// The media type, like "image/png".
+	ContentType string
+	// The decoded bytes, if the part carried inline data.
+	Data []byte
+	// The location of the media, if the part referenced it by URL.
+	URI string
+}
+
+// Media returns the media parts of the first candidate's message as
+// [MediaContent], so callers don't have to scan [Message.Content] and parse
+// data URIs by hand. It returns an error if a media part is malformed.
+func (gr *GenerateResponse) Media() ([]MediaContent, error) {
+	if len(gr.Candidates) == 0 {
+		return nil, nil
+	}
+	return gr.Candidates[0].Media()
+}
+
+// Media returns the media parts of the [Candidate]'s message as [MediaContent].
+func (c *Candidate) Media() ([]MediaContent, error) {
+	if c.Message == nil {
+		return nil, nil
+	}
+	var mcs []MediaContent
+	for _, p := range c.Message.Content {
+		if !p.IsMedia() {
+			continue
+		}
+		mc, err := mediaContentFromPart(p)
+		if err != nil {
+			return nil, err
+		}
+		mcs = append(mcs, mc)
+	}
+	return mcs, nil
+}
+
+// mediaContentFromPart converts a media [Part], whose Text holds either a
+// URL or a "data:" URI, into a [MediaContent].
+func mediaContentFromPart(p *Part) (MediaContent, error) {
+	mc := MediaContent{ContentType: p.ContentType}
+	if !strings.HasPrefix(p.Text, "data:") {
+		mc.URI = p.Text
+		return mc, nil
+	}
+	prefix, data, found := strings.Cut(p.Text, ",")
+	if !found {
+		return MediaContent{}, fmt.Errorf("malformed data URI in media part")
+	}
+	mediatype := strings.TrimPrefix(prefix, "data:")
+	mediatype, isBase64 := strings.CutSuffix(mediatype, ";base64")
+	if mc.ContentType == "" {
+		mc.ContentType = mediatype
+	}
+	if isBase64 {
+		decoded, err := base64.StdEncoding.DecodeString(data)
+		if err != nil {
+			return MediaContent{}, fmt.Errorf("malformed base64 data in media part: %w", err)
+		}
+		mc.Data = decoded
+		return mc, nil
+	}
+	unescaped, err := url.PathUnescape(data)
+	if err != nil {
+		return MediaContent{}, fmt.Errorf("malformed data URI in media part: %w", err)
+	}
+	mc.Data = []byte(unescaped)
+	return mc, nil
+}
+
+// A MediaSink stores generated media somewhere out of band — a Cloud
+// Storage bucket, an S3 bucket, a local directory — and returns a URL for
+// it. Implementations must be safe for concurrent use.
+type MediaSink interface {
+	// Store writes the media data and returns a URL that resolves to it.
+	Store(ctx context.Context, contentType string, data []byte) (url string, err error)
+}
+
+// SinkMedia replaces every inline media part in the response with a
+// reference to the same media stored in sink. Use it after generating
+// images, audio or video so that multi-megabyte base64 payloads don't
+// linger in memory, traces or streamed responses; clients receive storage
+// URLs instead.
+func SinkMedia(ctx context.Context, resp *GenerateResponse, sink MediaSink) error {
+	for _, c := range resp.Candidates {
+		if c.Message == nil {
+			continue
+		}
+		for _, p := range c.Message.Content {
+			if !p.IsMedia() || !strings.HasPrefix(p.Text, "data:") {
+				continue
+			}
+			mc, err := mediaContentFromPart(p)
+			if err != nil {
+				return err
+			}
+			url, err := sink.Store(ctx, mc.ContentType, mc.Data)
+			if err != nil {
+				return fmt.Errorf("failed to store media: %w", err)
+			}
+			p.ContentType = mc.ContentType
+			p.Text = url
+		}
+	}
+	return nil
+}
+
+// A DirMediaSink is a [MediaSink] that writes media files to a local
+// directory, naming each file after the SHA-256 hash of its contents.
+type DirMediaSink struct {
+	// The directory to write to. It must exist.
+	Dir string
+}
+
+// Store implements [MediaSink].
+// The returned URL is a file: URL.
+func (s DirMediaSink) Store(ctx context.Context, contentType string, data []byte) (string, error) {
+	name := fmt.Sprintf("%02x", sha256.Sum256(data))
+	if exts, _ := mime.ExtensionsByType(contentType); len(exts) > 0 {
+		name += exts[0]
+	}
+	path := filepath.Join(s.Dir, name)
+	if err := os.WriteFile(path, data, 0o666); err != nil {
+		return "", err
+	}
+	return "file://" + path, nil
+}
+
+// WriteFile writes the media's inline data to a file.
+// It returns an error if the media was referenced by URI rather than
+// delivered inline.
+func (m MediaContent) WriteFile(path string) error {
+	if m.Data == nil {
+		return fmt.Errorf("media has no inline data (URI %q)", m.URI)
+	}
+	return os.WriteFile(path, m.Data, 0o666)
+}
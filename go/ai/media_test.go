@@ -0,0 +1,92 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ai
+
+import (
+	"context"
+	"encoding/base64"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestResponseMedia(t *testing.T) {
+	png := []byte{0x89, 'P', 'N', 'G'}
+	resp := &GenerateResponse{
+		Candidates: []*Candidate{{
+			Message: &Message{
+				Content: []*Part{
+					NewTextPart("here is your image"),
+					NewMediaPart("image/png", "data:image/png;base64,"+base64.StdEncoding.EncodeToString(png)),
+					NewMediaPart("video/mp4", "https://example.com/clip.mp4"),
+				},
+			},
+		}},
+	}
+	media, err := resp.Media()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(media) != 2 {
+		t.Fatalf("got %d media parts, want 2", len(media))
+	}
+	if media[0].ContentType != "image/png" || string(media[0].Data) != string(png) {
+		t.Errorf("got %+v, want decoded PNG data", media[0])
+	}
+	if media[1].URI != "https://example.com/clip.mp4" || media[1].Data != nil {
+		t.Errorf("got %+v, want URI-only media", media[1])
+	}
+}
+
+func TestSinkMedia(t *testing.T) {
+	png := []byte{0x89, 'P', 'N', 'G'}
+	resp := &GenerateResponse{
+		Candidates: []*Candidate{{
+			Message: &Message{
+				Content: []*Part{
+					NewMediaPart("image/png", "data:image/png;base64,"+base64.StdEncoding.EncodeToString(png)),
+				},
+			},
+		}},
+	}
+	dir := t.TempDir()
+	if err := SinkMedia(context.Background(), resp, DirMediaSink{Dir: dir}); err != nil {
+		t.Fatal(err)
+	}
+	p := resp.Candidates[0].Message.Content[0]
+	if !p.IsMedia() || !strings.HasPrefix(p.Text, "file://") {
+		t.Fatalf("got part %+v, want file URL", p)
+	}
+	data, err := os.ReadFile(strings.TrimPrefix(p.Text, "file://"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != string(png) {
+		t.Errorf("stored %q, want %q", data, png)
+	}
+}
+
+func TestResponseMediaMalformed(t *testing.T) {
+	resp := &GenerateResponse{
+		Candidates: []*Candidate{{
+			Message: &Message{
+				Content: []*Part{NewMediaPart("image/png", "data:image/png;base64,!!!")},
+			},
+		}},
+	}
+	if _, err := resp.Media(); err == nil {
+		t.Error("got nil, want error")
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ai
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+)
+
+// MergePatch returns the JSON merge patch (RFC 7386) that transforms the JSON
+// value prev into next. Applying the returned patch to prev with
+// [ApplyMergePatch] yields next.
+//
+// A server streaming a progressively completed struct can send the patch
+// between consecutive snapshots instead of re-sending the whole value, so
+// clients rendering structured output over SSE only receive the fields that
+// changed. If nothing changed, the patch is "{}".
+func MergePatch(prev, next json.RawMessage) (json.RawMessage, error) {
+	var pv, nv any
+	if err := json.Unmarshal(prev, &pv); err != nil {
+		return nil, fmt.Errorf("invalid previous value: %w", err)
+	}
+	if err := json.Unmarshal(next, &nv); err != nil {
+		return nil, fmt.Errorf("invalid next value: %w", err)
+	}
+	return json.Marshal(mergePatchValue(pv, nv))
+}
+
+func mergePatchValue(prev, next any) any {
+	pm, pok := prev.(map[string]any)
+	nm, nok := next.(map[string]any)
+	if !pok || !nok {
+		// Merge patch can only describe object diffs; anything else is
+		// replaced wholesale.
+		return next
+	}
+	patch := map[string]any{}
+	for k, nv := range nm {
+		pv, ok := pm[k]
+		if !ok {
+			patch[k] = nv
+			continue
+		}
+		if reflect.DeepEqual(pv, nv) {
+			continue
+		}
+		patch[k] = mergePatchValue(pv, nv)
+	}
+	for k := range pm {
+		if _, ok := nm[k]; !ok {
+			// A null value deletes the key.
+			patch[k] = nil
+		}
+	}
+	return patch
+}
+
+// ApplyMergePatch applies a JSON merge patch (RFC 7386), as produced by
+// [MergePatch], to the JSON value target and returns the result.
+func ApplyMergePatch(target, patch json.RawMessage) (json.RawMessage, error) {
+	var tv, pv any
+	if err := json.Unmarshal(target, &tv); err != nil {
+		return nil, fmt.Errorf("invalid target value: %w", err)
+	}
+	if err := json.Unmarshal(patch, &pv); err != nil {
+		return nil, fmt.Errorf("invalid patch: %w", err)
+	}
+	return json.Marshal(applyMergePatchValue(tv, pv))
+}
+
+func applyMergePatchValue(target, patch any) any {
+	pm, ok := patch.(map[string]any)
+	if !ok {
+		return patch
+	}
+	tm, ok := target.(map[string]any)
+	if !ok {
+		tm = map[string]any{}
+	}
+	for k, pv := range pm {
+		if pv == nil {
+			delete(tm, k)
+			continue
+		}
+		tm[k] = applyMergePatchValue(tm[k], pv)
+	}
+	return tm
+}
@@ -0,0 +1,65 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ai
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestMergePatch(t *testing.T) {
+	for _, test := range []struct {
+		name, prev, next, want string
+	}{
+		{"no change", `{"a":1}`, `{"a":1}`, `{}`},
+		{"changed field", `{"a":1,"b":"x"}`, `{"a":2,"b":"x"}`, `{"a":2}`},
+		{"added field", `{"a":1}`, `{"a":1,"b":"x"}`, `{"b":"x"}`},
+		{"removed field", `{"a":1,"b":"x"}`, `{"a":1}`, `{"b":null}`},
+		{"nested change", `{"a":{"b":1,"c":2}}`, `{"a":{"b":1,"c":3}}`, `{"a":{"c":3}}`},
+		{"array replaced", `{"a":[1]}`, `{"a":[1,2]}`, `{"a":[1,2]}`},
+		{"non-object", `1`, `2`, `2`},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			patch, err := MergePatch(json.RawMessage(test.prev), json.RawMessage(test.next))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !jsonEqual(t, patch, json.RawMessage(test.want)) {
+				t.Errorf("MergePatch = %s, want %s", patch, test.want)
+			}
+			// Applying the patch to prev must produce next.
+			got, err := ApplyMergePatch(json.RawMessage(test.prev), patch)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !jsonEqual(t, got, json.RawMessage(test.next)) {
+				t.Errorf("ApplyMergePatch = %s, want %s", got, test.next)
+			}
+		})
+	}
+}
+
+func jsonEqual(t *testing.T, a, b json.RawMessage) bool {
+	t.Helper()
+	var av, bv any
+	if err := json.Unmarshal(a, &av); err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(b, &bv); err != nil {
+		t.Fatal(err)
+	}
+	return reflect.DeepEqual(av, bv)
+}
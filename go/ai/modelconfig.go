@@ -0,0 +1,69 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ai
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/firebase/genkit/go/core"
+)
+
+// This file implements validation of [GenerateRequest.Config] against the
+// config schema a model declared in its [ModelMetadata]. Passing the wrong
+// config struct otherwise fails deep inside the plugin with a cryptic error.
+
+var (
+	configSchemasMu sync.RWMutex
+	// configSchemas maps a model's full action name to its declared config schema.
+	configSchemas = map[string]map[string]any{}
+)
+
+// registerConfigSchema records the config schema a model declared via
+// [ModelMetadata.ConfigSchema] so that [Model.Generate] can validate
+// requests before they reach the plugin.
+func registerConfigSchema(name string, schema map[string]any) {
+	configSchemasMu.Lock()
+	defer configSchemasMu.Unlock()
+	configSchemas[name] = schema
+}
+
+// validateConfig checks the request's Config against the config schema the
+// named model declared, if any. It returns an actionable error naming the
+// model and the failing fields rather than letting the plugin fail later.
+func validateConfig(name string, req *GenerateRequest) error {
+	if req.Config == nil {
+		return nil
+	}
+	configSchemasMu.RLock()
+	schema := configSchemas[name]
+	configSchemasMu.RUnlock()
+	if schema == nil {
+		return nil
+	}
+	configBytes, err := json.Marshal(req.Config)
+	if err != nil {
+		return fmt.Errorf("config for model %q cannot be marshaled to JSON: %w", name, err)
+	}
+	schemaBytes, err := json.Marshal(schema)
+	if err != nil {
+		return fmt.Errorf("config schema for model %q is not valid: %w", name, err)
+	}
+	if err := core.ValidateRaw(configBytes, schemaBytes); err != nil {
+		return fmt.Errorf("config does not match the schema declared by model %q; pass the config type that model documents: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ai
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestValidateConfig(t *testing.T) {
+	model := DefineModel("test", "configModel", &ModelMetadata{
+		ConfigSchema: map[string]any{
+			"type":                 "object",
+			"additionalProperties": false,
+			"properties": map[string]any{
+				"temperature": map[string]any{"type": "number"},
+			},
+		},
+	}, func(ctx context.Context, req *GenerateRequest, cb ModelStreamingCallback) (*GenerateResponse, error) {
+		return &GenerateResponse{
+			Request: req,
+			Candidates: []*Candidate{{
+				Message: &Message{Content: []*Part{NewTextPart("ok")}, Role: RoleModel},
+			}},
+		}, nil
+	})
+
+	t.Run("valid config", func(t *testing.T) {
+		req := &GenerateRequest{
+			Messages: []*Message{{Content: []*Part{NewTextPart("hi")}, Role: RoleUser}},
+			Config:   map[string]any{"temperature": 0.5},
+		}
+		if _, err := model.Generate(context.Background(), req, nil); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("wrong config", func(t *testing.T) {
+		req := &GenerateRequest{
+			Messages: []*Message{{Content: []*Part{NewTextPart("hi")}, Role: RoleUser}},
+			Config:   map[string]any{"sampleCount": 4},
+		}
+		_, err := model.Generate(context.Background(), req, nil)
+		if err == nil {
+			t.Fatal("expected config validation error")
+		}
+		if !strings.Contains(err.Error(), "test/configModel") {
+			t.Errorf("error %q does not name the model", err)
+		}
+	})
+
+	t.Run("no schema declared", func(t *testing.T) {
+		if err := validateConfig("test/unknownModel", &GenerateRequest{Config: map[string]any{"x": 1}}); err != nil {
+			t.Fatal(err)
+		}
+	})
+}
@@ -0,0 +1,62 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ai
+
+import (
+	"sync"
+
+	"github.com/firebase/genkit/go/core"
+)
+
+// This file implements per-model concurrency limits, so a spike of
+// requests queues briefly instead of opening hundreds of simultaneous
+// provider streams.
+
+var (
+	modelLimitersMu sync.RWMutex
+	// modelLimiters maps a model's full action name to its limiter.
+	modelLimiters = map[string]*core.ConcurrencyLimiter{}
+)
+
+// SetModelConcurrencyLimit bounds the number of concurrent
+// [Model.Generate] calls to the named model: n calls run at once, up to
+// maxQueue more wait, and calls beyond that fail with
+// [core.ErrQueueFull]. The name is the model's full action name, for
+// example "vertexai/gemini-1.0-pro".
+func SetModelConcurrencyLimit(name string, n, maxQueue int) {
+	modelLimitersMu.Lock()
+	defer modelLimitersMu.Unlock()
+	modelLimiters[name] = core.NewConcurrencyLimiter(n, maxQueue)
+}
+
+// ModelQueueDepth returns the number of [Model.Generate] calls to the
+// named model currently waiting for a slot, or zero if the model has no
+// concurrency limit.
+func ModelQueueDepth(name string) int {
+	modelLimitersMu.RLock()
+	l := modelLimiters[name]
+	modelLimitersMu.RUnlock()
+	if l == nil {
+		return 0
+	}
+	return l.QueueDepth()
+}
+
+// modelLimiter returns the limiter for the named model, or nil.
+func modelLimiter(name string) *core.ConcurrencyLimiter {
+	modelLimitersMu.RLock()
+	defer modelLimitersMu.RUnlock()
+	return modelLimiters[name]
+}
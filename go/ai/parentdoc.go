@@ -0,0 +1,150 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ai
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// This file implements small-to-big retrieval: index small chunks for
+// precise matching, but hand the model their parent documents (or a
+// window of surrounding chunks) for context. Chunks are annotated with
+// their parent's ID at indexing time with [ChunkWithParent]; at query
+// time [RetrieveParents] and [RetrieveWindow] resolve the annotations
+// through a caller-supplied fetch function, so any document store can
+// serve as the parent store.
+
+// Metadata keys written by ChunkWithParent.
+const (
+	parentMetadataKey     = "parent"
+	chunkIndexMetadataKey = "chunkIndex"
+)
+
+// ChunkWithParent annotates a chunk with the ID of its parent document
+// and its position within the parent, and returns the chunk. Index the
+// annotated chunks; retrieve with [RetrieveParents] or
+// [RetrieveWindow].
+func ChunkWithParent(chunk *Document, parentID string, index int) *Document {
+	if chunk.Metadata == nil {
+		chunk.Metadata = map[string]any{}
+	}
+	chunk.Metadata[parentMetadataKey] = parentID
+	chunk.Metadata[chunkIndexMetadataKey] = index
+	return chunk
+}
+
+// RetrieveParents runs the retriever, then replaces each retrieved
+// chunk with its parent document, fetched by ID through fetch. Parents
+// are deduplicated and returned in the order their first chunk was
+// retrieved. Documents without parent metadata are passed through
+// unchanged.
+func RetrieveParents(ctx context.Context, r *Retriever, req *RetrieverRequest, fetch func(ctx context.Context, parentID string) (*Document, error)) (*RetrieverResponse, error) {
+	resp, err := r.Retrieve(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	var out []*Document
+	for _, d := range resp.Documents {
+		id, ok := d.Metadata[parentMetadataKey].(string)
+		if !ok {
+			out = append(out, d)
+			continue
+		}
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		parent, err := fetch(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("ai.RetrieveParents: fetching parent %q: %w", id, err)
+		}
+		if parent != nil {
+			out = append(out, parent)
+		}
+	}
+	return &RetrieverResponse{Documents: out}, nil
+}
+
+// RetrieveWindow runs the retriever, then expands each retrieved chunk
+// into a window of the window chunks on either side of it within the
+// same parent, fetched by (parentID, index) through fetch. fetch
+// returns nil for indices past either end of the parent. Overlapping
+// windows within one parent are merged: each parent yields a single
+// document whose text is the retrieved and neighboring chunks joined in
+// order, with the parent ID in its metadata. Documents without parent
+// metadata are passed through unchanged.
+func RetrieveWindow(ctx context.Context, r *Retriever, req *RetrieverRequest, window int, fetch func(ctx context.Context, parentID string, index int) (*Document, error)) (*RetrieverResponse, error) {
+	resp, err := r.Retrieve(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	// Gather the wanted chunk indices per parent, preserving the order
+	// in which parents first appear.
+	var parentOrder []string
+	wanted := map[string]map[int]bool{}
+	var out []*Document
+	for _, d := range resp.Documents {
+		id, ok := d.Metadata[parentMetadataKey].(string)
+		index, okIndex := chunkIndex(d)
+		if !ok || !okIndex {
+			out = append(out, d)
+			continue
+		}
+		if wanted[id] == nil {
+			parentOrder = append(parentOrder, id)
+			wanted[id] = map[int]bool{}
+		}
+		for i := index - window; i <= index+window; i++ {
+			if i >= 0 {
+				wanted[id][i] = true
+			}
+		}
+	}
+	for _, id := range parentOrder {
+		indices := make([]int, 0, len(wanted[id]))
+		for i := range wanted[id] {
+			indices = append(indices, i)
+		}
+		sort.Ints(indices)
+		var texts []string
+		for _, i := range indices {
+			chunk, err := fetch(ctx, id, i)
+			if err != nil {
+				return nil, fmt.Errorf("ai.RetrieveWindow: fetching chunk %d of %q: %w", i, id, err)
+			}
+			if chunk != nil {
+				texts = append(texts, strings.TrimSpace(docText(chunk)))
+			}
+		}
+		out = append(out, DocumentFromText(strings.Join(texts, "\n"), map[string]any{parentMetadataKey: id}))
+	}
+	return &RetrieverResponse{Documents: out}, nil
+}
+
+// chunkIndex reads the chunk index metadata written by ChunkWithParent,
+// tolerating the float64 it becomes after a JSON round trip.
+func chunkIndex(d *Document) (int, bool) {
+	switch v := d.Metadata[chunkIndexMetadataKey].(type) {
+	case int:
+		return v, true
+	case float64:
+		return int(v), true
+	}
+	return 0, false
+}
@@ -0,0 +1,101 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ai
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRetrieveParents(t *testing.T) {
+	r := DefineRetriever("test", "parents", func(ctx context.Context, req *RetrieverRequest) (*RetrieverResponse, error) {
+		return &RetrieverResponse{Documents: []*Document{
+			ChunkWithParent(DocumentFromText("chunk one", nil), "doc1", 0),
+			ChunkWithParent(DocumentFromText("chunk two", nil), "doc1", 1),
+			ChunkWithParent(DocumentFromText("chunk three", nil), "doc2", 0),
+		}}, nil
+	})
+	parents := map[string]*Document{
+		"doc1": DocumentFromText("full document one", nil),
+		"doc2": DocumentFromText("full document two", nil),
+	}
+	resp, err := RetrieveParents(context.Background(), r, &RetrieverRequest{Document: DocumentFromText("q", nil)},
+		func(_ context.Context, id string) (*Document, error) {
+			return parents[id], nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Documents) != 2 {
+		t.Fatalf("got %d documents %v, want 2", len(resp.Documents), docTexts(resp.Documents))
+	}
+	if docText(resp.Documents[0]) != docText(parents["doc1"]) {
+		t.Errorf("first = %q, want parent of doc1", docText(resp.Documents[0]))
+	}
+}
+
+func TestRetrieveWindow(t *testing.T) {
+	chunks := map[string][]string{
+		"doc1": {"zero", "one", "two", "three", "four"},
+	}
+	r := DefineRetriever("test", "window", func(ctx context.Context, req *RetrieverRequest) (*RetrieverResponse, error) {
+		return &RetrieverResponse{Documents: []*Document{
+			ChunkWithParent(DocumentFromText("one", nil), "doc1", 1),
+			ChunkWithParent(DocumentFromText("three", nil), "doc1", 3),
+		}}, nil
+	})
+	resp, err := RetrieveWindow(context.Background(), r, &RetrieverRequest{Document: DocumentFromText("q", nil)}, 1,
+		func(_ context.Context, id string, i int) (*Document, error) {
+			cs := chunks[id]
+			if i < 0 || i >= len(cs) {
+				return nil, nil
+			}
+			return DocumentFromText(cs[i], nil), nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Documents) != 1 {
+		t.Fatalf("got %d documents, want 1 merged window", len(resp.Documents))
+	}
+	want := "zero\none\ntwo\nthree\nfour"
+	if got := docText(resp.Documents[0]); got != want+" " {
+		t.Errorf("window text = %q, want %q", got, want)
+	}
+	if resp.Documents[0].Metadata["parent"] != "doc1" {
+		t.Errorf("parent metadata = %v, want doc1", resp.Documents[0].Metadata["parent"])
+	}
+}
+
+func TestRetrieveWindowMissingEdges(t *testing.T) {
+	r := DefineRetriever("test", "windowEdges", func(ctx context.Context, req *RetrieverRequest) (*RetrieverResponse, error) {
+		return &RetrieverResponse{Documents: []*Document{
+			ChunkWithParent(DocumentFromText("only", nil), "doc1", 0),
+		}}, nil
+	})
+	resp, err := RetrieveWindow(context.Background(), r, &RetrieverRequest{Document: DocumentFromText("q", nil)}, 2,
+		func(_ context.Context, id string, i int) (*Document, error) {
+			if i != 0 {
+				return nil, nil
+			}
+			return DocumentFromText("only", nil), nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Documents) != 1 || docText(resp.Documents[0]) != "only " {
+		t.Errorf("got %v, want [only]", docTexts(resp.Documents))
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ai
+
+import "context"
+
+// This file implements partial response recovery. When a provider
+// stream dies after producing substantial content, Generate discards
+// everything and returns only the error; for long generations the
+// partial output is often still worth keeping or resuming from.
+
+// GeneratePartial is like [Model.Generate] for streaming calls, except
+// that if the stream fails after producing content, the accumulated
+// partial message is returned alongside the error instead of being
+// discarded. The partial response has a single candidate with
+// [FinishReasonOther] and the error as its finish message; on success
+// the response is the complete one, exactly as from Generate.
+func (m *Model) GeneratePartial(ctx context.Context, req *GenerateRequest, cb ModelStreamingCallback) (*GenerateResponse, error) {
+	var content []*Part
+	wcb := func(ctx context.Context, c *GenerateResponseChunk) error {
+		content = append(content, c.Content...)
+		if cb != nil {
+			return cb(ctx, c)
+		}
+		return nil
+	}
+	resp, err := m.Generate(ctx, req, wcb)
+	if err == nil {
+		return resp, nil
+	}
+	if len(content) == 0 {
+		return nil, err
+	}
+	partial := &GenerateResponse{
+		Request: req,
+		Candidates: []*Candidate{{
+			Message:       &Message{Role: RoleModel, Content: content},
+			FinishReason:  FinishReasonOther,
+			FinishMessage: err.Error(),
+		}},
+	}
+	return partial, err
+}
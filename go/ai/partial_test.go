@@ -0,0 +1,57 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ai
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestGeneratePartial(t *testing.T) {
+	streamErr := errors.New("stream died")
+	model := DefineModel("test", "partialModel", nil,
+		func(ctx context.Context, req *GenerateRequest, cb ModelStreamingCallback) (*GenerateResponse, error) {
+			// Stream two chunks, then fail.
+			for _, text := range []string{"first ", "second"} {
+				if err := cb(ctx, &GenerateResponseChunk{Content: []*Part{NewTextPart(text)}}); err != nil {
+					return nil, err
+				}
+			}
+			return nil, streamErr
+		})
+
+	req := &GenerateRequest{
+		Messages: []*Message{{Content: []*Part{NewTextPart("hi")}, Role: RoleUser}},
+	}
+	resp, err := model.GeneratePartial(context.Background(), req,
+		func(ctx context.Context, c *GenerateResponseChunk) error { return nil })
+	if !errors.Is(err, streamErr) {
+		t.Fatalf("got err %v, want the stream error", err)
+	}
+	if resp == nil {
+		t.Fatal("no partial response")
+	}
+	text, terr := resp.Text()
+	if terr != nil {
+		t.Fatal(terr)
+	}
+	if text != "first second" {
+		t.Errorf("partial text = %q, want %q", text, "first second")
+	}
+	if got := resp.Candidates[0].FinishReason; got != FinishReasonOther {
+		t.Errorf("FinishReason = %q, want %q", got, FinishReasonOther)
+	}
+}
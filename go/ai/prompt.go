@@ -16,6 +16,7 @@ package ai
 
 import (
 	"context"
+	"fmt"
 	"maps"
 
 	"github.com/firebase/genkit/go/core"
@@ -47,6 +48,17 @@ func LookupPrompt(provider, name string) *Prompt {
 	return (*Prompt)(core.LookupActionFor[any, *GenerateRequest, struct{}](atype.Prompt, provider, name))
 }
 
+// MustLookupPrompt is like [LookupPrompt] but panics if the prompt was
+// not defined, naming the missing prompt. It avoids the nil checks that
+// otherwise follow every lookup when the prompt is known to exist.
+func MustLookupPrompt(provider, name string) *Prompt {
+	p := LookupPrompt(provider, name)
+	if p == nil {
+		panic(fmt.Sprintf("ai.MustLookupPrompt: no prompt named %q for provider %q", name, provider))
+	}
+	return p
+}
+
 // Render renders the [Prompt] with some input data.
 func (p *Prompt) Render(ctx context.Context, input any) (*GenerateRequest, error) {
 	return (*core.Action[any, *GenerateRequest, struct{}])(p).Run(ctx, input, nil)
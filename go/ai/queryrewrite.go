@@ -0,0 +1,158 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ai
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// This file implements query rewriting for retrieval. A single query
+// often misses relevant documents that a paraphrase or a hypothetical
+// answer would find; RetrieveWithRewrites retrieves for the original
+// query and each rewrite, then merges the result lists with reciprocal
+// rank fusion. Each retrieval and each model call runs as its own
+// action, so the stages appear as separate spans in traces.
+
+// A QueryRewriter produces alternative query documents for a query.
+// The original query is always retrieved for as well; return only the
+// additions.
+type QueryRewriter func(ctx context.Context, query *Document) ([]*Document, error)
+
+// MultiQueryRewriter returns a rewriter that asks the model for n
+// paraphrases of the query.
+func MultiQueryRewriter(m *Model, n int) QueryRewriter {
+	return func(ctx context.Context, query *Document) ([]*Document, error) {
+		prompt := fmt.Sprintf(
+			"Write %d alternative phrasings of the following search query, one per line, with no numbering or commentary.\n\nQuery: %s",
+			n, strings.TrimSpace(docText(query)))
+		lines, err := generateLines(ctx, m, prompt)
+		if err != nil {
+			return nil, err
+		}
+		if len(lines) > n {
+			lines = lines[:n]
+		}
+		var out []*Document
+		for _, l := range lines {
+			out = append(out, DocumentFromText(l, nil))
+		}
+		return out, nil
+	}
+}
+
+// HyDERewriter returns a rewriter that asks the model to write a short
+// hypothetical passage answering the query and uses that passage as an
+// additional query (hypothetical document embeddings).
+func HyDERewriter(m *Model) QueryRewriter {
+	return func(ctx context.Context, query *Document) ([]*Document, error) {
+		prompt := fmt.Sprintf(
+			"Write a short passage that would answer the following question. Write only the passage.\n\nQuestion: %s",
+			strings.TrimSpace(docText(query)))
+		resp, err := m.Generate(ctx, &GenerateRequest{Messages: []*Message{NewUserTextMessage(prompt)}}, nil)
+		if err != nil {
+			return nil, err
+		}
+		text, err := resp.Text()
+		if err != nil {
+			return nil, err
+		}
+		return []*Document{DocumentFromText(strings.TrimSpace(text), nil)}, nil
+	}
+}
+
+// rrfK is the standard smoothing constant for reciprocal rank fusion.
+const rrfK = 60
+
+// RetrieveWithRewrites retrieves for the request's query and for each
+// rewrite the rewriters produce, then merges the result lists with
+// reciprocal rank fusion: each document scores sum(1/(60+rank)) over
+// the lists it appears in, and the merged list is ordered by that
+// score. Duplicate documents (by content) are merged. Each document's
+// fused score is stored in its "score" metadata.
+func RetrieveWithRewrites(ctx context.Context, r *Retriever, req *RetrieverRequest, rewriters ...QueryRewriter) (*RetrieverResponse, error) {
+	queries := []*Document{req.Document}
+	for _, rw := range rewriters {
+		more, err := rw(ctx, req.Document)
+		if err != nil {
+			return nil, fmt.Errorf("ai.RetrieveWithRewrites: %w", err)
+		}
+		queries = append(queries, more...)
+	}
+	type fused struct {
+		doc   *Document
+		score float64
+		order int
+	}
+	byHash := map[string]*fused{}
+	var all []*fused
+	for _, q := range queries {
+		resp, err := r.Retrieve(ctx, &RetrieverRequest{Document: q, Options: req.Options})
+		if err != nil {
+			return nil, err
+		}
+		for rank, d := range resp.Documents {
+			hash, err := documentHash(&Document{Content: d.Content})
+			if err != nil {
+				return nil, err
+			}
+			f := byHash[hash]
+			if f == nil {
+				f = &fused{doc: d, order: len(all)}
+				byHash[hash] = f
+				all = append(all, f)
+			}
+			f.score += 1 / float64(rrfK+rank+1)
+		}
+	}
+	sort.SliceStable(all, func(i, j int) bool {
+		if all[i].score != all[j].score {
+			return all[i].score > all[j].score
+		}
+		return all[i].order < all[j].order
+	})
+	var out []*Document
+	for _, f := range all {
+		md := map[string]any{}
+		for k, v := range f.doc.Metadata {
+			md[k] = v
+		}
+		md["score"] = f.score
+		out = append(out, &Document{Content: f.doc.Content, Metadata: md})
+	}
+	return &RetrieverResponse{Documents: out}, nil
+}
+
+// generateLines runs a prompt and splits the response into non-empty
+// trimmed lines.
+func generateLines(ctx context.Context, m *Model, prompt string) ([]string, error) {
+	resp, err := m.Generate(ctx, &GenerateRequest{Messages: []*Message{NewUserTextMessage(prompt)}}, nil)
+	if err != nil {
+		return nil, err
+	}
+	text, err := resp.Text()
+	if err != nil {
+		return nil, err
+	}
+	var lines []string
+	for _, l := range strings.Split(text, "\n") {
+		if l = strings.TrimSpace(l); l != "" {
+			lines = append(lines, l)
+		}
+	}
+	return lines, nil
+}
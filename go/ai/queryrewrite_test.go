@@ -0,0 +1,73 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ai
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestRetrieveWithRewrites(t *testing.T) {
+	// The corpus routes queries to fixed result lists so the fusion is
+	// predictable: "shared" appears in both lists, at rank 2 and rank 1.
+	corpus := map[string][]string{
+		"original": {"only original", "shared"},
+		"rewrite":  {"shared", "only rewrite"},
+	}
+	r := DefineRetriever("test", "rewrites", func(ctx context.Context, req *RetrieverRequest) (*RetrieverResponse, error) {
+		var docs []*Document
+		for _, text := range corpus[strings.TrimSpace(docText(req.Document))] {
+			docs = append(docs, DocumentFromText(text, nil))
+		}
+		return &RetrieverResponse{Documents: docs}, nil
+	})
+	rewriter := QueryRewriter(func(ctx context.Context, query *Document) ([]*Document, error) {
+		return []*Document{DocumentFromText("rewrite", nil)}, nil
+	})
+	resp, err := RetrieveWithRewrites(context.Background(), r,
+		&RetrieverRequest{Document: DocumentFromText("original", nil)}, rewriter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Documents) != 3 {
+		t.Fatalf("got %d documents %v, want 3", len(resp.Documents), docTexts(resp.Documents))
+	}
+	// The document found by both queries fuses to the top.
+	if got := strings.TrimSpace(docText(resp.Documents[0])); got != "shared" {
+		t.Errorf("first = %q, want shared", got)
+	}
+	if _, ok := resp.Documents[0].Metadata["score"].(float64); !ok {
+		t.Error("fused document has no score metadata")
+	}
+}
+
+func TestMultiQueryRewriter(t *testing.T) {
+	m := DefineModel("test", "multiQuery", nil, func(ctx context.Context, req *GenerateRequest, _ ModelStreamingCallback) (*GenerateResponse, error) {
+		return &GenerateResponse{
+			Request: req,
+			Candidates: []*Candidate{{
+				Message: &Message{Content: []*Part{NewTextPart("first rewrite\n\nsecond rewrite\nthird rewrite")}, Role: RoleModel},
+			}},
+		}, nil
+	})
+	docs, err := MultiQueryRewriter(m, 2)(context.Background(), DocumentFromText("q", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 2 || strings.TrimSpace(docText(docs[0])) != "first rewrite" {
+		t.Errorf("got %v, want the first two rewrites", docTexts(docs))
+	}
+}
@@ -14,6 +14,13 @@
 
 package ai
 
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/invopop/jsonschema"
+)
+
 // NewGenerateRequest create a new GenerateRequest with provided config and
 // messages. Use NewUserTextGenerateRequest if you have a simple text-only user message.
 func NewGenerateRequest(config any, messages ...*Message) *GenerateRequest {
@@ -23,6 +30,51 @@ func NewGenerateRequest(config any, messages ...*Message) *GenerateRequest {
 	}
 }
 
+// NewJSONOutput returns a [GenerateRequestOutput] requesting JSON output
+// that conforms to the given raw JSON schema.
+//
+// Use this when the schema is not known at compile time — for example when
+// it is stored in a database or supplied by a customer — so no Go struct
+// can be declared for it. The model's output is validated against the
+// schema; candidates that do not conform are discarded.
+func NewJSONOutput(schema map[string]any) *GenerateRequestOutput {
+	return &GenerateRequestOutput{
+		Format: OutputFormatJSON,
+		Schema: schema,
+	}
+}
+
+// NewJSONOutputFor returns a [GenerateRequestOutput] requesting JSON output
+// that conforms to the schema inferred from the type T.
+//
+// The inference honors jsonschema struct tags, including constraint and
+// documentation keywords such as pattern, minimum, maximum, minItems and
+// example, so they are both sent to the model as part of the request and
+// enforced when the model's output is validated. For example:
+//
+//	type Flight struct {
+//		// Must look like "AA100".
+//		Number string `jsonschema:"pattern=^[A-Z]{2}[0-9]{1,4}$"`
+//		Seats  int    `jsonschema:"minimum=1,maximum=850"`
+//	}
+func NewJSONOutputFor[T any]() (*GenerateRequestOutput, error) {
+	var x T
+	r := jsonschema.Reflector{
+		DoNotReference: true,
+	}
+	s := r.Reflect(x)
+	s.Version = ""
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal inferred schema: %w", err)
+	}
+	var m map[string]any
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal inferred schema: %w", err)
+	}
+	return NewJSONOutput(m), nil
+}
+
 // NewUserMessage creates a new Message with role "user" and provided parts.
 // Use NewUserTextMessage if you have a text-only message.
 func NewUserMessage(parts ...*Part) *Message {
@@ -0,0 +1,59 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ai
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewJSONOutputFor(t *testing.T) {
+	type flight struct {
+		Number string `json:"number" jsonschema:"pattern=^[A-Z]{2}[0-9]{1\\,4}$"`
+		Seats  int    `json:"seats" jsonschema:"minimum=1,maximum=850"`
+	}
+	output, err := NewJSONOutputFor[flight]()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if output.Format != OutputFormatJSON {
+		t.Errorf("got format %q, want %q", output.Format, OutputFormatJSON)
+	}
+
+	newCandidate := func(json string) *Candidate {
+		return &Candidate{
+			Message: &Message{
+				Content: []*Part{NewTextPart(json)},
+			},
+		}
+	}
+
+	if _, err := validCandidate(newCandidate(`{"number": "AA100", "seats": 180}`), output); err != nil {
+		t.Errorf("conforming output rejected: %v", err)
+	}
+
+	// Constraints from struct tags must be enforced on the output.
+	for _, bad := range []string{
+		`{"number": "not a flight number", "seats": 180}`,
+		`{"number": "AA100", "seats": 0}`,
+		`{"number": "AA100", "seats": 9000}`,
+	} {
+		if _, err := validCandidate(newCandidate(bad), output); err == nil {
+			t.Errorf("non-conforming output %s accepted", bad)
+		} else if !strings.Contains(err.Error(), "did not match expected schema") {
+			t.Errorf("unhelpful validation error: %v", err)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ai
+
+import (
+	"context"
+	"strings"
+)
+
+// This file implements post-retrieval processors: transformations
+// applied to retrieved documents uniformly, regardless of which
+// retriever backend produced them, so deduplication and diversification
+// are not reimplemented per plugin.
+
+// A RetrievalProcessor transforms retrieved documents. query is the
+// retrieval query document.
+type RetrievalProcessor func(query *Document, docs []*Document) []*Document
+
+// RetrieveWithProcessors runs the retriever and applies the processors
+// to its documents in order.
+func (r *Retriever) RetrieveWithProcessors(ctx context.Context, req *RetrieverRequest, procs ...RetrievalProcessor) (*RetrieverResponse, error) {
+	resp, err := r.Retrieve(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	docs := resp.Documents
+	for _, p := range procs {
+		docs = p(req.Document, docs)
+	}
+	return &RetrieverResponse{Documents: docs}, nil
+}
+
+// Dedupe returns a processor that drops documents whose content is
+// identical to an earlier document's.
+func Dedupe() RetrievalProcessor {
+	return func(_ *Document, docs []*Document) []*Document {
+		seen := map[string]bool{}
+		var out []*Document
+		for _, d := range docs {
+			hash, err := documentHash(&Document{Content: d.Content})
+			if err != nil || !seen[hash] {
+				seen[hash] = true
+				out = append(out, d)
+			}
+		}
+		return out
+	}
+}
+
+// ScoreThreshold returns a processor that drops documents whose "score"
+// metadata is below min. Documents without a numeric score are kept.
+func ScoreThreshold(min float64) RetrievalProcessor {
+	return func(_ *Document, docs []*Document) []*Document {
+		var out []*Document
+		for _, d := range docs {
+			if score, ok := d.Metadata["score"].(float64); ok && score < min {
+				continue
+			}
+			out = append(out, d)
+		}
+		return out
+	}
+}
+
+// MMR returns a processor that reorders documents by maximal marginal
+// relevance: each position picks the document that best balances
+// relevance to the query against similarity to the documents already
+// picked. lambda in [0, 1] weights relevance; lower values favor
+// diversity. Similarity is lexical (token overlap), so MMR works on any
+// backend without access to embeddings.
+func MMR(lambda float64) RetrievalProcessor {
+	return func(query *Document, docs []*Document) []*Document {
+		if len(docs) < 2 {
+			return docs
+		}
+		queryTokens := tokenSet(docText(query))
+		tokens := make([]map[string]bool, len(docs))
+		rel := make([]float64, len(docs))
+		for i, d := range docs {
+			tokens[i] = tokenSet(docText(d))
+			rel[i] = overlap(queryTokens, tokens[i])
+		}
+		picked := make([]bool, len(docs))
+		out := make([]*Document, 0, len(docs))
+		var pickedTokens []map[string]bool
+		for len(out) < len(docs) {
+			best, bestScore := -1, 0.0
+			for i := range docs {
+				if picked[i] {
+					continue
+				}
+				maxSim := 0.0
+				for _, pt := range pickedTokens {
+					if s := overlap(pt, tokens[i]); s > maxSim {
+						maxSim = s
+					}
+				}
+				score := lambda*rel[i] - (1-lambda)*maxSim
+				if best == -1 || score > bestScore {
+					best, bestScore = i, score
+				}
+			}
+			picked[best] = true
+			pickedTokens = append(pickedTokens, tokens[best])
+			out = append(out, docs[best])
+		}
+		return out
+	}
+}
+
+// docText concatenates the text parts of a document.
+func docText(d *Document) string {
+	if d == nil {
+		return ""
+	}
+	var b strings.Builder
+	for _, p := range d.Content {
+		if p.IsText() {
+			b.WriteString(p.Text)
+			b.WriteString(" ")
+		}
+	}
+	return b.String()
+}
+
+// tokenSet returns the set of lower-cased words in s.
+func tokenSet(s string) map[string]bool {
+	set := map[string]bool{}
+	for _, w := range strings.Fields(strings.ToLower(s)) {
+		set[strings.Trim(w, ".,;:!?\"'()")] = true
+	}
+	return set
+}
+
+// overlap is the Jaccard similarity of two token sets.
+func overlap(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	common := 0
+	for t := range a {
+		if b[t] {
+			common++
+		}
+	}
+	return float64(common) / float64(len(a)+len(b)-common)
+}
@@ -0,0 +1,79 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ai
+
+import (
+	"context"
+	"testing"
+)
+
+func docTexts(docs []*Document) []string {
+	var out []string
+	for _, d := range docs {
+		out = append(out, docText(d))
+	}
+	return out
+}
+
+func TestRetrieveWithProcessors(t *testing.T) {
+	r := DefineRetriever("test", "procs", func(ctx context.Context, req *RetrieverRequest) (*RetrieverResponse, error) {
+		return &RetrieverResponse{Documents: []*Document{
+			DocumentFromText("go is fun", map[string]any{"score": 0.9}),
+			DocumentFromText("go is fun", map[string]any{"score": 0.9}),
+			DocumentFromText("cats are fluffy", map[string]any{"score": 0.2}),
+		}}, nil
+	})
+	resp, err := r.RetrieveWithProcessors(context.Background(),
+		&RetrieverRequest{Document: DocumentFromText("go", nil)},
+		Dedupe(), ScoreThreshold(0.5))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Documents) != 1 {
+		t.Fatalf("got %d documents %v, want 1", len(resp.Documents), docTexts(resp.Documents))
+	}
+}
+
+func TestScoreThresholdKeepsUnscored(t *testing.T) {
+	docs := []*Document{
+		DocumentFromText("no score", nil),
+		DocumentFromText("low score", map[string]any{"score": 0.1}),
+	}
+	got := ScoreThreshold(0.5)(nil, docs)
+	if len(got) != 1 || docText(got[0]) != docText(docs[0]) {
+		t.Errorf("got %v, want just the unscored document", docTexts(got))
+	}
+}
+
+func TestMMR(t *testing.T) {
+	query := DocumentFromText("go concurrency", nil)
+	docs := []*Document{
+		DocumentFromText("go concurrency with goroutines", nil),
+		DocumentFromText("go concurrency with goroutines and more", nil),
+		DocumentFromText("concurrency in java threads", nil),
+	}
+	got := MMR(0.5)(query, docs)
+	if len(got) != 3 {
+		t.Fatalf("got %d documents, want 3", len(got))
+	}
+	// The most relevant document comes first; the near-duplicate is
+	// penalized, so the java document ranks second.
+	if docText(got[0]) != docText(docs[0]) {
+		t.Errorf("first = %q, want %q", docText(got[0]), docText(docs[0]))
+	}
+	if docText(got[1]) != docText(docs[2]) {
+		t.Errorf("second = %q, want %q", docText(got[1]), docText(docs[2]))
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/firebase/genkit/go/core"
+	"github.com/firebase/genkit/go/core/tracing"
+)
+
+// This file implements model routing. A router is registered as a
+// model, so existing code can point at it by name; each request is
+// forwarded to the first route that applies — by prompt length, by
+// what the request needs (vision, tools), or by asking a cheap
+// classifier model — falling back to a default model when no route
+// matches.
+
+// A ModelRoute is one routing decision. Select returns the model to
+// use for the request, or nil if the route does not apply.
+type ModelRoute struct {
+	// Name identifies the route in traces.
+	Name string
+	// Select returns the model to forward to, or nil to try the next
+	// route.
+	Select func(ctx context.Context, req *GenerateRequest) (*Model, error)
+}
+
+// DefineModelRouter registers a model that forwards each request to
+// the first route whose Select returns a model, or to fallback when
+// none does. The chosen route and model are recorded in the trace.
+func DefineModelRouter(provider, name string, routes []ModelRoute, fallback *Model) *Model {
+	return DefineModel(provider, name, nil, func(ctx context.Context, req *GenerateRequest, cb ModelStreamingCallback) (*GenerateResponse, error) {
+		for _, route := range routes {
+			m, err := route.Select(ctx, req)
+			if err != nil {
+				return nil, fmt.Errorf("ai: model router %q: route %q: %w", name, route.Name, err)
+			}
+			if m != nil {
+				tracing.SetCustomMetadataAttr(ctx, "route", route.Name)
+				tracing.SetCustomMetadataAttr(ctx, "routeModel", modelName(m))
+				return m.Generate(ctx, req, cb)
+			}
+		}
+		if fallback == nil {
+			return nil, fmt.Errorf("ai: model router %q: no route matched and no fallback model", name)
+		}
+		tracing.SetCustomMetadataAttr(ctx, "route", "fallback")
+		tracing.SetCustomMetadataAttr(ctx, "routeModel", modelName(fallback))
+		return fallback.Generate(ctx, req, cb)
+	})
+}
+
+// RouteLongPrompts routes requests whose total message text is at
+// least minChars characters to m, e.g. to a model with a larger
+// context window.
+func RouteLongPrompts(minChars int, m *Model) ModelRoute {
+	return ModelRoute{
+		Name: fmt.Sprintf("longPrompts(%d)", minChars),
+		Select: func(_ context.Context, req *GenerateRequest) (*Model, error) {
+			if promptLength(req) >= minChars {
+				return m, nil
+			}
+			return nil, nil
+		},
+	}
+}
+
+// RouteMedia routes requests containing media parts to m, a model with
+// vision support.
+func RouteMedia(m *Model) ModelRoute {
+	return ModelRoute{
+		Name: "media",
+		Select: func(_ context.Context, req *GenerateRequest) (*Model, error) {
+			for _, msg := range req.Messages {
+				for _, p := range msg.Content {
+					if p.IsMedia() {
+						return m, nil
+					}
+				}
+			}
+			return nil, nil
+		},
+	}
+}
+
+// RouteTools routes requests that declare tools to m, a model with
+// tool-use support.
+func RouteTools(m *Model) ModelRoute {
+	return ModelRoute{
+		Name: "tools",
+		Select: func(_ context.Context, req *GenerateRequest) (*Model, error) {
+			if len(req.Tools) > 0 {
+				return m, nil
+			}
+			return nil, nil
+		},
+	}
+}
+
+// RouteByClassifier routes by asking classifier, a cheap model, to
+// label the request with one of the keys of routes. If the classifier
+// answers with a known label, the request goes to that label's model;
+// otherwise the route does not apply.
+func RouteByClassifier(classifier *Model, routes map[string]*Model) ModelRoute {
+	var labels []string
+	for label := range routes {
+		labels = append(labels, label)
+	}
+	return ModelRoute{
+		Name: "classifier",
+		Select: func(ctx context.Context, req *GenerateRequest) (*Model, error) {
+			prompt := fmt.Sprintf(
+				"Classify the following request as exactly one of: %s. Answer with the label only.\n\nRequest: %s",
+				strings.Join(labels, ", "), promptText(req))
+			resp, err := classifier.Generate(ctx, &GenerateRequest{
+				Messages: []*Message{NewUserTextMessage(prompt)},
+			}, nil)
+			if err != nil {
+				return nil, err
+			}
+			text, err := resp.Text()
+			if err != nil {
+				return nil, err
+			}
+			label := strings.ToLower(strings.TrimSpace(text))
+			return routes[label], nil
+		},
+	}
+}
+
+// modelName returns a model's registered name.
+func modelName(m *Model) string {
+	return (*core.Action[*GenerateRequest, *GenerateResponse, *GenerateResponseChunk])(m).Name()
+}
+
+// promptText concatenates the text of all messages in a request.
+func promptText(req *GenerateRequest) string {
+	var b strings.Builder
+	for _, msg := range req.Messages {
+		for _, p := range msg.Content {
+			if p.IsText() {
+				b.WriteString(p.Text)
+				b.WriteString("\n")
+			}
+		}
+	}
+	return b.String()
+}
+
+// promptLength is the total text length of a request's messages.
+func promptLength(req *GenerateRequest) int {
+	n := 0
+	for _, msg := range req.Messages {
+		for _, p := range msg.Content {
+			if p.IsText() {
+				n += len(p.Text)
+			}
+		}
+	}
+	return n
+}
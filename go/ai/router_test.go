@@ -0,0 +1,118 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ai
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// echoModel returns a model whose responses identify it by name.
+func echoModel(name string) *Model {
+	return DefineModel("test", name, nil, func(ctx context.Context, req *GenerateRequest, _ ModelStreamingCallback) (*GenerateResponse, error) {
+		return &GenerateResponse{
+			Request: req,
+			Candidates: []*Candidate{{
+				Message: &Message{Role: RoleModel, Content: []*Part{NewTextPart(name)}},
+			}},
+		}, nil
+	})
+}
+
+func routedTo(t *testing.T, router *Model, req *GenerateRequest) string {
+	t.Helper()
+	resp, err := router.Generate(context.Background(), req, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, err := resp.Text()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return text
+}
+
+func TestModelRouter(t *testing.T) {
+	big := echoModel("routerBig")
+	vision := echoModel("routerVision")
+	small := echoModel("routerSmall")
+	router := DefineModelRouter("test", "router", []ModelRoute{
+		RouteMedia(vision),
+		RouteLongPrompts(100, big),
+	}, small)
+
+	if got := routedTo(t, router, &GenerateRequest{
+		Messages: []*Message{NewUserTextMessage("short question")},
+	}); got != "routerSmall" {
+		t.Errorf("short prompt routed to %q, want routerSmall", got)
+	}
+	if got := routedTo(t, router, &GenerateRequest{
+		Messages: []*Message{NewUserTextMessage(strings.Repeat("long ", 30))},
+	}); got != "routerBig" {
+		t.Errorf("long prompt routed to %q, want routerBig", got)
+	}
+	if got := routedTo(t, router, &GenerateRequest{
+		Messages: []*Message{{Role: RoleUser, Content: []*Part{
+			NewTextPart("what is this?"),
+			NewMediaPart("image/png", "data:image/png;base64,aGk="),
+		}}},
+	}); got != "routerVision" {
+		t.Errorf("media prompt routed to %q, want routerVision", got)
+	}
+}
+
+func TestRouteByClassifier(t *testing.T) {
+	classifier := DefineModel("test", "routerClassifier", nil, func(ctx context.Context, req *GenerateRequest, _ ModelStreamingCallback) (*GenerateResponse, error) {
+		// Classify on the request text after the label list in the prompt.
+		_, request, _ := strings.Cut(promptText(req), "Request:")
+		label := "chat"
+		if strings.Contains(request, "code") {
+			label = "code"
+		}
+		return &GenerateResponse{
+			Request: req,
+			Candidates: []*Candidate{{
+				Message: &Message{Role: RoleModel, Content: []*Part{NewTextPart(" " + label + "\n")}},
+			}},
+		}, nil
+	})
+	coder := echoModel("routerCoder")
+	chatter := echoModel("routerChatter")
+	router := DefineModelRouter("test", "classifierRouter", []ModelRoute{
+		RouteByClassifier(classifier, map[string]*Model{"code": coder, "chat": chatter}),
+	}, nil)
+
+	if got := routedTo(t, router, &GenerateRequest{
+		Messages: []*Message{NewUserTextMessage("write some code for me")},
+	}); got != "routerCoder" {
+		t.Errorf("routed to %q, want routerCoder", got)
+	}
+	if got := routedTo(t, router, &GenerateRequest{
+		Messages: []*Message{NewUserTextMessage("hello there")},
+	}); got != "routerChatter" {
+		t.Errorf("routed to %q, want routerChatter", got)
+	}
+}
+
+func TestModelRouterNoFallback(t *testing.T) {
+	router := DefineModelRouter("test", "noFallbackRouter", nil, nil)
+	_, err := router.Generate(context.Background(), &GenerateRequest{
+		Messages: []*Message{NewUserTextMessage("hi")},
+	}, nil)
+	if err == nil {
+		t.Error("expected an error with no routes and no fallback")
+	}
+}
@@ -0,0 +1,200 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/google/uuid"
+)
+
+// This file implements long-term memory for sessions: durable facts
+// ("the user's name is Earl", "prefers metric units") extracted from
+// conversation turns by a model and injected into later prompts.
+
+// A Memory is one durable fact extracted from a conversation.
+type Memory struct {
+	ID        string    `json:"id"`
+	SessionID string    `json:"sessionId"`
+	Text      string    `json:"text"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// A MemoryStore persists memories and finds ones relevant to a query.
+// Implementations may be backed by a vector store or a key-value store.
+type MemoryStore interface {
+	// Add stores memories.
+	Add(ctx context.Context, memories []*Memory) error
+	// Relevant returns up to limit memories relevant to the query,
+	// most relevant first.
+	Relevant(ctx context.Context, query string, limit int) ([]*Memory, error)
+}
+
+// An Extractor uses a model to pull durable facts out of conversation
+// turns and store them as memories. Run it after each completed turn.
+type Extractor struct {
+	Model *ai.Model
+	Store MemoryStore
+}
+
+const extractInstructions = `Extract durable facts about the user from the conversation below: ` +
+	`stable preferences, personal details, and long-lived context worth remembering in later conversations. ` +
+	`Ignore transient details of the current task. Reply with a JSON array of short declarative sentences, ` +
+	`or an empty array if there is nothing worth remembering.`
+
+// ExtractTurn asks the model for durable facts in the given messages
+// and adds them to the store. It returns the extracted memories.
+func (e *Extractor) ExtractTurn(ctx context.Context, sessionID string, msgs []*ai.Message) ([]*Memory, error) {
+	var sb strings.Builder
+	sb.WriteString(extractInstructions)
+	sb.WriteString("\n\n")
+	for _, m := range msgs {
+		for _, p := range m.Content {
+			if p.IsText() {
+				fmt.Fprintf(&sb, "%s: %s\n", m.Role, p.Text)
+			}
+		}
+	}
+	req := &ai.GenerateRequest{
+		Messages: []*ai.Message{{Role: ai.RoleUser, Content: []*ai.Part{ai.NewTextPart(sb.String())}}},
+		Output: &ai.GenerateRequestOutput{
+			Format: ai.OutputFormatJSON,
+			Schema: map[string]any{
+				"type":  "array",
+				"items": map[string]any{"type": "string"},
+			},
+		},
+	}
+	resp, err := e.Model.Generate(ctx, req, nil)
+	if err != nil {
+		return nil, fmt.Errorf("session: extracting memories: %w", err)
+	}
+	text, err := resp.Text()
+	if err != nil {
+		return nil, err
+	}
+	var facts []string
+	if err := json.Unmarshal([]byte(text), &facts); err != nil {
+		return nil, fmt.Errorf("session: parsing extracted memories: %w", err)
+	}
+	now := time.Now().UTC()
+	var memories []*Memory
+	for _, fact := range facts {
+		memories = append(memories, &Memory{
+			ID:        uuid.NewString(),
+			SessionID: sessionID,
+			Text:      fact,
+			CreatedAt: now,
+		})
+	}
+	if len(memories) > 0 {
+		if err := e.Store.Add(ctx, memories); err != nil {
+			return nil, err
+		}
+	}
+	return memories, nil
+}
+
+// InjectMemories prepends a system message holding the memories most
+// relevant to the last user message in the request, so the model can use
+// what it learned in earlier conversations.
+func InjectMemories(ctx context.Context, store MemoryStore, req *ai.GenerateRequest, limit int) error {
+	query := ""
+	for i := len(req.Messages) - 1; i >= 0; i-- {
+		if req.Messages[i].Role == ai.RoleUser {
+			for _, p := range req.Messages[i].Content {
+				query += p.Text
+			}
+			break
+		}
+	}
+	memories, err := store.Relevant(ctx, query, limit)
+	if err != nil {
+		return err
+	}
+	if len(memories) == 0 {
+		return nil
+	}
+	var sb strings.Builder
+	sb.WriteString("Things you remember about the user from earlier conversations:\n")
+	for _, m := range memories {
+		sb.WriteString("- ")
+		sb.WriteString(m.Text)
+		sb.WriteString("\n")
+	}
+	msg := &ai.Message{Role: ai.RoleSystem, Content: []*ai.Part{ai.NewTextPart(sb.String())}}
+	req.Messages = append([]*ai.Message{msg}, req.Messages...)
+	return nil
+}
+
+// A KVMemoryStore is a [MemoryStore] that keeps memories in memory and
+// ranks them by the number of query words they share with the memory
+// text. It is suitable for tests and small applications; production use
+// should prefer a vector-backed implementation.
+type KVMemoryStore struct {
+	mu       sync.Mutex
+	memories []*Memory
+}
+
+// NewKVMemoryStore returns an empty [KVMemoryStore].
+func NewKVMemoryStore() *KVMemoryStore {
+	return &KVMemoryStore{}
+}
+
+// Add implements [MemoryStore].
+func (s *KVMemoryStore) Add(ctx context.Context, memories []*Memory) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.memories = append(s.memories, memories...)
+	return nil
+}
+
+// Relevant implements [MemoryStore].
+func (s *KVMemoryStore) Relevant(ctx context.Context, query string, limit int) ([]*Memory, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	words := strings.Fields(strings.ToLower(query))
+	type scored struct {
+		m     *Memory
+		score int
+	}
+	var all []scored
+	for _, m := range s.memories {
+		text := strings.ToLower(m.Text)
+		score := 0
+		for _, w := range words {
+			if strings.Contains(text, w) {
+				score++
+			}
+		}
+		all = append(all, scored{m, score})
+	}
+	sort.SliceStable(all, func(i, j int) bool { return all[i].score > all[j].score })
+	var ret []*Memory
+	for _, sc := range all {
+		if limit > 0 && len(ret) >= limit {
+			break
+		}
+		ret = append(ret, sc.m)
+	}
+	return ret, nil
+}
@@ -0,0 +1,65 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+)
+
+func TestMemoryExtraction(t *testing.T) {
+	ctx := context.Background()
+	model := ai.DefineModel("test", "memoryModel", nil,
+		func(ctx context.Context, req *ai.GenerateRequest, cb ai.ModelStreamingCallback) (*ai.GenerateResponse, error) {
+			return &ai.GenerateResponse{
+				Request: req,
+				Candidates: []*ai.Candidate{{
+					Message: &ai.Message{
+						Role:    ai.RoleModel,
+						Content: []*ai.Part{ai.NewTextPart(`["The user's name is Earl.", "The user prefers metric units."]`)},
+					},
+				}},
+			}, nil
+		})
+	store := NewKVMemoryStore()
+	e := &Extractor{Model: model, Store: store}
+
+	memories, err := e.ExtractTurn(ctx, "sess1", []*ai.Message{
+		msg(ai.RoleUser, "I'm Earl, how many kilometers is a marathon?"),
+		msg(ai.RoleModel, "About 42.2 kilometers."),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(memories) != 2 {
+		t.Fatalf("extracted %d memories, want 2", len(memories))
+	}
+
+	req := &ai.GenerateRequest{
+		Messages: []*ai.Message{msg(ai.RoleUser, "what units does the user prefer?")},
+	}
+	if err := InjectMemories(ctx, store, req, 1); err != nil {
+		t.Fatal(err)
+	}
+	if req.Messages[0].Role != ai.RoleSystem {
+		t.Fatal("expected an injected system message")
+	}
+	if text := req.Messages[0].Content[0].Text; !strings.Contains(text, "metric") {
+		t.Errorf("injected message %q does not contain the relevant memory", text)
+	}
+}
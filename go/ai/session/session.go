@@ -0,0 +1,188 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package session stores multi-turn conversation state.
+//
+// A [Session] holds one or more named threads of [ai.Message] history
+// along with arbitrary state, persisted through a [Store]. Threads let a
+// chat UI keep exploratory branches of one conversation: [Session.Fork]
+// starts a new thread that shares a prefix of an existing thread's
+// history, which is how "regenerate from here" is built.
+package session
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/google/uuid"
+)
+
+// DefaultThread is the name of the thread used when no thread is specified.
+const DefaultThread = "main"
+
+// Data is the persisted form of a session.
+type Data struct {
+	// ID identifies the session in its [Store].
+	ID string `json:"id"`
+	// Revision counts successful saves of the session. A [Store] rejects
+	// a save whose revision does not match the stored one with
+	// [ErrRevisionConflict], so concurrent writers cannot clobber each
+	// other; see [Update] for the retry pattern.
+	Revision int64 `json:"revision,omitempty"`
+	// Threads holds the message history of each named thread.
+	Threads map[string][]*ai.Message `json:"threads,omitempty"`
+	// State holds arbitrary application state for the session.
+	State map[string]any `json:"state,omitempty"`
+	// Metadata holds arbitrary metadata about the session, such as
+	// a display title.
+	Metadata map[string]any `json:"metadata,omitempty"`
+}
+
+// A Session is a loaded session. It is not safe for concurrent use.
+type Session struct {
+	store Store
+	data  *Data
+}
+
+// New creates a new session with a random ID and saves it in the store.
+func New(ctx context.Context, store Store) (*Session, error) {
+	s := &Session{
+		store: store,
+		data: &Data{
+			ID:      uuid.NewString(),
+			Threads: map[string][]*ai.Message{},
+		},
+	}
+	if err := store.Save(ctx, s.data); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Load loads an existing session from the store.
+func Load(ctx context.Context, store Store, id string) (*Session, error) {
+	data, err := store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if data.Threads == nil {
+		data.Threads = map[string][]*ai.Message{}
+	}
+	return &Session{store: store, data: data}, nil
+}
+
+// ID returns the session's ID.
+func (s *Session) ID() string { return s.data.ID }
+
+// Messages returns the message history of the named thread.
+// It returns nil if the thread does not exist.
+func (s *Session) Messages(thread string) []*ai.Message {
+	return s.data.Threads[thread]
+}
+
+// UpdateMessages replaces the message history of the named thread
+// and saves the session.
+func (s *Session) UpdateMessages(ctx context.Context, thread string, msgs []*ai.Message) error {
+	s.data.Threads[thread] = msgs
+	return s.store.Save(ctx, s.data)
+}
+
+// State returns the session's state map.
+func (s *Session) State() map[string]any { return s.data.State }
+
+// UpdateState replaces the session's state and saves the session.
+func (s *Session) UpdateState(ctx context.Context, state map[string]any) error {
+	s.data.State = state
+	return s.store.Save(ctx, s.data)
+}
+
+// Threads returns the names of the session's threads, sorted.
+func (s *Session) Threads() []string {
+	names := make([]string, 0, len(s.data.Threads))
+	for name := range s.data.Threads {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Fork creates a new thread whose history is the first fromIndex
+// messages of the named thread, and saves the session. Use it to
+// regenerate a conversation from an earlier point without losing the
+// original thread.
+func (s *Session) Fork(ctx context.Context, thread, newThread string, fromIndex int) error {
+	src, ok := s.data.Threads[thread]
+	if !ok {
+		return fmt.Errorf("session: no thread named %q", thread)
+	}
+	if _, ok := s.data.Threads[newThread]; ok {
+		return fmt.Errorf("session: thread %q already exists", newThread)
+	}
+	if fromIndex < 0 || fromIndex > len(src) {
+		return fmt.Errorf("session: fork index %d out of range for thread %q with %d messages", fromIndex, thread, len(src))
+	}
+	fork := make([]*ai.Message, fromIndex)
+	copy(fork, src[:fromIndex])
+	s.data.Threads[newThread] = fork
+	return s.store.Save(ctx, s.data)
+}
+
+// A ThreadDiff describes how two threads of a session relate.
+type ThreadDiff struct {
+	// CommonPrefix is the number of leading messages the threads share.
+	CommonPrefix int
+	// OnlyA and OnlyB are the messages after the common prefix in each thread.
+	OnlyA []*ai.Message
+	OnlyB []*ai.Message
+}
+
+// DiffThreads reports where two threads diverge. Messages are compared
+// by role and concatenated text.
+func (s *Session) DiffThreads(a, b string) (*ThreadDiff, error) {
+	ta, ok := s.data.Threads[a]
+	if !ok {
+		return nil, fmt.Errorf("session: no thread named %q", a)
+	}
+	tb, ok := s.data.Threads[b]
+	if !ok {
+		return nil, fmt.Errorf("session: no thread named %q", b)
+	}
+	n := 0
+	for n < len(ta) && n < len(tb) && sameMessage(ta[n], tb[n]) {
+		n++
+	}
+	return &ThreadDiff{
+		CommonPrefix: n,
+		OnlyA:        ta[n:],
+		OnlyB:        tb[n:],
+	}, nil
+}
+
+// sameMessage reports whether two messages have the same role and text.
+func sameMessage(a, b *ai.Message) bool {
+	if a == b {
+		return true
+	}
+	if a == nil || b == nil || a.Role != b.Role || len(a.Content) != len(b.Content) {
+		return false
+	}
+	for i := range a.Content {
+		if a.Content[i].Kind != b.Content[i].Kind || a.Content[i].Text != b.Content[i].Text {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,80 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"context"
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/google/go-cmp/cmp"
+)
+
+func msg(role ai.Role, text string) *ai.Message {
+	return &ai.Message{Role: role, Content: []*ai.Part{ai.NewTextPart(text)}}
+}
+
+func TestFork(t *testing.T) {
+	ctx := context.Background()
+	store := NewInMemoryStore()
+	s, err := New(ctx, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	history := []*ai.Message{
+		msg(ai.RoleUser, "q1"),
+		msg(ai.RoleModel, "a1"),
+		msg(ai.RoleUser, "q2"),
+		msg(ai.RoleModel, "a2"),
+	}
+	if err := s.UpdateMessages(ctx, DefaultThread, history); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Fork(ctx, DefaultThread, "retry", 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.UpdateMessages(ctx, "retry", append(s.Messages("retry"), msg(ai.RoleUser, "q2b"))); err != nil {
+		t.Fatal(err)
+	}
+
+	if diff := cmp.Diff([]string{"main", "retry"}, s.Threads()); diff != "" {
+		t.Errorf("threads mismatch (-want, +got):\n%s", diff)
+	}
+
+	d, err := s.DiffThreads(DefaultThread, "retry")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.CommonPrefix != 2 || len(d.OnlyA) != 2 || len(d.OnlyB) != 1 {
+		t.Errorf("diff = %d common, %d onlyA, %d onlyB; want 2, 2, 1",
+			d.CommonPrefix, len(d.OnlyA), len(d.OnlyB))
+	}
+
+	// The fork must persist: reload from the store.
+	s2, err := Load(ctx, store, s.ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(s2.Messages("retry")); got != 3 {
+		t.Errorf("reloaded retry thread has %d messages, want 3", got)
+	}
+
+	if err := s.Fork(ctx, DefaultThread, "retry", 1); err == nil {
+		t.Error("expected an error forking to an existing thread name")
+	}
+	if err := s.Fork(ctx, DefaultThread, "oops", 99); err == nil {
+		t.Error("expected an error for an out-of-range fork index")
+	}
+}
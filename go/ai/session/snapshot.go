@@ -0,0 +1,144 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/google/uuid"
+)
+
+// A Snapshot is a copy of a session's data at a point in time.
+// Snapshots enable time-travel debugging of conversations: take one
+// before a risky operation, then restore the session from it.
+type Snapshot struct {
+	// ID identifies the snapshot in its [SnapshotStore].
+	ID string `json:"id"`
+	// SessionID is the ID of the session the snapshot was taken from.
+	SessionID string `json:"sessionId"`
+	// CreatedAt is when the snapshot was taken.
+	CreatedAt time.Time `json:"createdAt"`
+	// Data is the session data at the time of the snapshot.
+	Data *Data `json:"data"`
+}
+
+// A SnapshotStore persists session snapshots.
+type SnapshotStore interface {
+	// Create stores a snapshot of the given session data and returns it.
+	Create(ctx context.Context, data *Data) (*Snapshot, error)
+	// List returns the snapshots of a session, oldest first.
+	List(ctx context.Context, sessionID string) ([]*Snapshot, error)
+	// Get returns the snapshot with the given ID.
+	Get(ctx context.Context, id string) (*Snapshot, error)
+}
+
+// Snapshot stores a snapshot of the session's current data.
+func (s *Session) Snapshot(ctx context.Context, snaps SnapshotStore) (*Snapshot, error) {
+	return snaps.Create(ctx, s.data)
+}
+
+// RestoreFromSnapshot replaces the stored session with the contents of
+// the identified snapshot and returns the restored session. The
+// session's revision keeps counting forward; only its threads, state,
+// and metadata are rewound.
+func RestoreFromSnapshot(ctx context.Context, store Store, snaps SnapshotStore, snapshotID string) (*Session, error) {
+	snap, err := snaps.Get(ctx, snapshotID)
+	if err != nil {
+		return nil, err
+	}
+	s, err := Load(ctx, store, snap.Data.ID)
+	if err != nil {
+		return nil, err
+	}
+	s.data.Threads = snap.Data.Threads
+	if s.data.Threads == nil {
+		s.data.Threads = map[string][]*ai.Message{}
+	}
+	s.data.State = snap.Data.State
+	s.data.Metadata = snap.Data.Metadata
+	if err := s.store.Save(ctx, s.data); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// An InMemorySnapshotStore is a [SnapshotStore] that keeps snapshots in
+// memory. It is suitable for tests and development.
+type InMemorySnapshotStore struct {
+	mu        sync.Mutex
+	snapshots map[string][]byte
+}
+
+// NewInMemorySnapshotStore returns an empty [InMemorySnapshotStore].
+func NewInMemorySnapshotStore() *InMemorySnapshotStore {
+	return &InMemorySnapshotStore{snapshots: map[string][]byte{}}
+}
+
+// Create implements [SnapshotStore].
+func (s *InMemorySnapshotStore) Create(ctx context.Context, data *Data) (*Snapshot, error) {
+	snap := &Snapshot{
+		ID:        uuid.NewString(),
+		SessionID: data.ID,
+		CreatedAt: time.Now().UTC(),
+		Data:      data,
+	}
+	b, err := json.Marshal(snap)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.snapshots[snap.ID] = b
+	return snap, nil
+}
+
+// List implements [SnapshotStore].
+func (s *InMemorySnapshotStore) List(ctx context.Context, sessionID string) ([]*Snapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var snaps []*Snapshot
+	for _, b := range s.snapshots {
+		var snap Snapshot
+		if err := json.Unmarshal(b, &snap); err != nil {
+			return nil, err
+		}
+		if snap.SessionID == sessionID {
+			snaps = append(snaps, &snap)
+		}
+	}
+	sort.Slice(snaps, func(i, j int) bool { return snaps[i].CreatedAt.Before(snaps[j].CreatedAt) })
+	return snaps, nil
+}
+
+// Get implements [SnapshotStore].
+func (s *InMemorySnapshotStore) Get(ctx context.Context, id string) (*Snapshot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.snapshots[id]
+	if !ok {
+		return nil, fmt.Errorf("session: no snapshot with ID %q", id)
+	}
+	var snap Snapshot
+	if err := json.Unmarshal(b, &snap); err != nil {
+		return nil, err
+	}
+	return &snap, nil
+}
@@ -0,0 +1,64 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"context"
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+)
+
+func TestSnapshotRestore(t *testing.T) {
+	ctx := context.Background()
+	store := NewInMemoryStore()
+	snaps := NewInMemorySnapshotStore()
+
+	s, err := New(ctx, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.UpdateMessages(ctx, DefaultThread, []*ai.Message{msg(ai.RoleUser, "q1")}); err != nil {
+		t.Fatal(err)
+	}
+	snap, err := s.Snapshot(ctx, snaps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	more := append(s.Messages(DefaultThread), msg(ai.RoleModel, "bad answer"))
+	if err := s.UpdateMessages(ctx, DefaultThread, more); err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := snaps.List(ctx, s.ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].ID != snap.ID {
+		t.Fatalf("List = %v, want the one snapshot", list)
+	}
+
+	restored, err := RestoreFromSnapshot(ctx, store, snaps, snap.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(restored.Messages(DefaultThread)); got != 1 {
+		t.Errorf("restored thread has %d messages, want 1", got)
+	}
+	// The restored session must still be saveable (no revision conflict).
+	if err := restored.UpdateState(ctx, map[string]any{"ok": true}); err != nil {
+		t.Errorf("saving restored session: %v", err)
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrRevisionConflict is returned by [Store.Save] when the session was
+// modified by another writer since it was read. Reload the session and
+// retry the change, or use [Update] which does so automatically.
+var ErrRevisionConflict = errors.New("session: revision conflict")
+
+// A Store persists sessions. Implementations are expected to be safe
+// for concurrent use.
+type Store interface {
+	// Get returns the session with the given ID.
+	Get(ctx context.Context, id string) (*Data, error)
+	// Save stores the session, replacing any existing session with the
+	// same ID. If data.Revision does not match the stored session's
+	// revision, Save returns [ErrRevisionConflict] and stores nothing.
+	// On success Save increments data.Revision.
+	Save(ctx context.Context, data *Data) error
+}
+
+// Update loads the session, applies fn to its data, and saves it,
+// retrying the whole cycle up to attempts times if another writer saved
+// the session in between. It is the safe way to modify a session that
+// several concurrent requests may be updating.
+func Update(ctx context.Context, store Store, id string, attempts int, fn func(data *Data) error) error {
+	var err error
+	for i := 0; i < attempts; i++ {
+		var data *Data
+		data, err = store.Get(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err = fn(data); err != nil {
+			return err
+		}
+		if err = store.Save(ctx, data); !errors.Is(err, ErrRevisionConflict) {
+			return err
+		}
+	}
+	return fmt.Errorf("session: updating session %q failed after %d attempts: %w", id, attempts, err)
+}
+
+// An InMemoryStore is a [Store] that keeps sessions in memory.
+// It is suitable for tests and development.
+type InMemoryStore struct {
+	mu       sync.Mutex
+	sessions map[string][]byte
+}
+
+// NewInMemoryStore returns an empty [InMemoryStore].
+func NewInMemoryStore() *InMemoryStore {
+	return &InMemoryStore{sessions: map[string][]byte{}}
+}
+
+// Get implements [Store].
+func (s *InMemoryStore) Get(ctx context.Context, id string) (*Data, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.sessions[id]
+	if !ok {
+		return nil, fmt.Errorf("session: no session with ID %q", id)
+	}
+	var data Data
+	if err := json.Unmarshal(b, &data); err != nil {
+		return nil, err
+	}
+	return &data, nil
+}
+
+// Save implements [Store].
+func (s *InMemoryStore) Save(ctx context.Context, data *Data) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if b, ok := s.sessions[data.ID]; ok {
+		var stored Data
+		if err := json.Unmarshal(b, &stored); err != nil {
+			return err
+		}
+		if stored.Revision != data.Revision {
+			return fmt.Errorf("%w: session %q is at revision %d, not %d",
+				ErrRevisionConflict, data.ID, stored.Revision, data.Revision)
+		}
+	}
+	data.Revision++
+	b, err := json.Marshal(data)
+	if err != nil {
+		data.Revision--
+		return err
+	}
+	s.sessions[data.ID] = b
+	return nil
+}
@@ -0,0 +1,83 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestRevisionConflict(t *testing.T) {
+	ctx := context.Background()
+	store := NewInMemoryStore()
+	s, err := New(ctx, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Two copies of the same session data: the second save must conflict.
+	a, err := store.Get(ctx, s.ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := store.Get(ctx, s.ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Save(ctx, a); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Save(ctx, b); !errors.Is(err, ErrRevisionConflict) {
+		t.Errorf("got %v, want ErrRevisionConflict", err)
+	}
+}
+
+func TestUpdateRetries(t *testing.T) {
+	ctx := context.Background()
+	store := NewInMemoryStore()
+	s, err := New(ctx, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Many concurrent counters; every increment must land.
+	const writers = 10
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := Update(ctx, store, s.ID(), writers+1, func(data *Data) error {
+				n, _ := data.State["count"].(float64)
+				data.State = map[string]any{"count": n + 1}
+				return nil
+			})
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	data, err := store.Get(ctx, s.ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := data.State["count"].(float64); got != writers {
+		t.Errorf("count = %v, want %d", got, writers)
+	}
+}
@@ -0,0 +1,129 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/firebase/genkit/go/ai"
+)
+
+// Metadata keys under which [Summarizer] stores its results.
+const (
+	MetadataTitle   = "title"
+	MetadataSummary = "summary"
+)
+
+// A Summarizer maintains a short conversation title and a rolling
+// summary in a session's metadata, so chat list UIs can display
+// sessions without implementing summarization themselves.
+type Summarizer struct {
+	Model *ai.Model
+}
+
+const summarizeInstructions = `Given the summary so far and the latest conversation turns below, ` +
+	`reply with JSON holding a "title" (at most six words naming the conversation topic) and a ` +
+	`"summary" (at most three sentences covering the whole conversation so far).`
+
+// UpdateTurn regenerates the session's title and rolling summary from
+// the latest turns of the named thread and saves them in the session
+// metadata. Call it after each completed turn, typically from a
+// goroutine via [Summarizer.UpdateTurnAsync].
+func (s *Summarizer) UpdateTurn(ctx context.Context, sess *Session, thread string) error {
+	var sb strings.Builder
+	sb.WriteString(summarizeInstructions)
+	sb.WriteString("\n\nSummary so far: ")
+	if prev, ok := sess.data.Metadata[MetadataSummary].(string); ok && prev != "" {
+		sb.WriteString(prev)
+	} else {
+		sb.WriteString("(none)")
+	}
+	sb.WriteString("\n\n")
+	msgs := sess.Messages(thread)
+	const maxTurns = 6
+	if len(msgs) > maxTurns {
+		msgs = msgs[len(msgs)-maxTurns:]
+	}
+	for _, m := range msgs {
+		for _, p := range m.Content {
+			if p.IsText() {
+				fmt.Fprintf(&sb, "%s: %s\n", m.Role, p.Text)
+			}
+		}
+	}
+	req := &ai.GenerateRequest{
+		Messages: []*ai.Message{{Role: ai.RoleUser, Content: []*ai.Part{ai.NewTextPart(sb.String())}}},
+		Output: &ai.GenerateRequestOutput{
+			Format: ai.OutputFormatJSON,
+			Schema: map[string]any{
+				"type":     "object",
+				"required": []string{"title", "summary"},
+				"properties": map[string]any{
+					"title":   map[string]any{"type": "string"},
+					"summary": map[string]any{"type": "string"},
+				},
+			},
+		},
+	}
+	resp, err := s.Model.Generate(ctx, req, nil)
+	if err != nil {
+		return fmt.Errorf("session: summarizing: %w", err)
+	}
+	text, err := resp.Text()
+	if err != nil {
+		return err
+	}
+	var out struct {
+		Title   string `json:"title"`
+		Summary string `json:"summary"`
+	}
+	if err := json.Unmarshal([]byte(text), &out); err != nil {
+		return fmt.Errorf("session: parsing summary: %w", err)
+	}
+	if sess.data.Metadata == nil {
+		sess.data.Metadata = map[string]any{}
+	}
+	sess.data.Metadata[MetadataTitle] = out.Title
+	sess.data.Metadata[MetadataSummary] = out.Summary
+	return sess.store.Save(ctx, sess.data)
+}
+
+// UpdateTurnAsync runs [Summarizer.UpdateTurn] in a goroutine, logging
+// any error, so summarization doesn't delay the response to the user.
+func (s *Summarizer) UpdateTurnAsync(ctx context.Context, sess *Session, thread string) {
+	go func() {
+		if err := s.UpdateTurn(ctx, sess, thread); err != nil {
+			slog.Error("session: updating summary", "session", sess.ID(), "err", err)
+		}
+	}()
+}
+
+// Title returns the generated title from the session metadata,
+// or the empty string if there is none yet.
+func (s *Session) Title() string {
+	title, _ := s.data.Metadata[MetadataTitle].(string)
+	return title
+}
+
+// Summary returns the rolling summary from the session metadata,
+// or the empty string if there is none yet.
+func (s *Session) Summary() string {
+	summary, _ := s.data.Metadata[MetadataSummary].(string)
+	return summary
+}
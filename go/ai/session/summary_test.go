@@ -0,0 +1,66 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"context"
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+)
+
+func TestSummarizer(t *testing.T) {
+	ctx := context.Background()
+	model := ai.DefineModel("test", "summaryModel", nil,
+		func(ctx context.Context, req *ai.GenerateRequest, cb ai.ModelStreamingCallback) (*ai.GenerateResponse, error) {
+			return &ai.GenerateResponse{
+				Request: req,
+				Candidates: []*ai.Candidate{{
+					Message: &ai.Message{
+						Role:    ai.RoleModel,
+						Content: []*ai.Part{ai.NewTextPart(`{"title": "Marathon distances", "summary": "The user asked about marathon length."}`)},
+					},
+				}},
+			}, nil
+		})
+	store := NewInMemoryStore()
+	s, err := New(ctx, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.UpdateMessages(ctx, DefaultThread, []*ai.Message{
+		msg(ai.RoleUser, "how long is a marathon?"),
+		msg(ai.RoleModel, "42.2 km"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	sum := &Summarizer{Model: model}
+	if err := sum.UpdateTurn(ctx, s, DefaultThread); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := s.Title(), "Marathon distances"; got != want {
+		t.Errorf("Title = %q, want %q", got, want)
+	}
+
+	// The summary must survive a reload.
+	s2, err := Load(ctx, store, s.ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s2.Summary() == "" {
+		t.Error("reloaded session has no summary")
+	}
+}
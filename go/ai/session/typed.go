@@ -0,0 +1,112 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/firebase/genkit/go/core"
+	"github.com/invopop/jsonschema"
+)
+
+// A TypedSession is a [Session] whose state has a known Go type instead
+// of an untyped map. State writes are validated against the JSON schema
+// inferred from the type, so a tool writing a malformed state fails at
+// the write rather than corrupting the session.
+type TypedSession[State any] struct {
+	*Session
+	schema []byte
+}
+
+// A StateMigration converts a stored state map from an older shape to
+// the current one. It runs when a session is loaded, before the state is
+// validated against the current type.
+type StateMigration func(state map[string]any) (map[string]any, error)
+
+// NewTyped creates a new session with a typed state and saves it in the store.
+func NewTyped[State any](ctx context.Context, store Store) (*TypedSession[State], error) {
+	s, err := New(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+	return newTypedSession[State](s)
+}
+
+// LoadTyped loads an existing session with a typed state. If migrate is
+// non-nil it is applied to the stored state first, and the migrated
+// state is saved back if it changed the session.
+func LoadTyped[State any](ctx context.Context, store Store, id string, migrate StateMigration) (*TypedSession[State], error) {
+	s, err := Load(ctx, store, id)
+	if err != nil {
+		return nil, err
+	}
+	if migrate != nil && s.data.State != nil {
+		migrated, err := migrate(s.data.State)
+		if err != nil {
+			return nil, fmt.Errorf("session: migrating state of session %q: %w", id, err)
+		}
+		if err := s.UpdateState(ctx, migrated); err != nil {
+			return nil, err
+		}
+	}
+	return newTypedSession[State](s)
+}
+
+func newTypedSession[State any](s *Session) (*TypedSession[State], error) {
+	r := jsonschema.Reflector{DoNotReference: true}
+	var state State
+	schema := r.Reflect(&state)
+	schema.Version = ""
+	schemaBytes, err := json.Marshal(schema)
+	if err != nil {
+		return nil, err
+	}
+	return &TypedSession[State]{Session: s, schema: schemaBytes}, nil
+}
+
+// TypedState returns the session's state as a State value.
+func (ts *TypedSession[State]) TypedState() (State, error) {
+	var state State
+	if ts.data.State == nil {
+		return state, nil
+	}
+	b, err := json.Marshal(ts.data.State)
+	if err != nil {
+		return state, err
+	}
+	if err := json.Unmarshal(b, &state); err != nil {
+		return state, fmt.Errorf("session: state of session %q does not match type: %w", ts.ID(), err)
+	}
+	return state, nil
+}
+
+// UpdateTypedState validates the state against the type's schema,
+// replaces the session's state with it, and saves the session.
+func (ts *TypedSession[State]) UpdateTypedState(ctx context.Context, state State) error {
+	b, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	if err := core.ValidateRaw(b, ts.schema); err != nil {
+		return fmt.Errorf("session: invalid state for session %q: %w", ts.ID(), err)
+	}
+	var m map[string]any
+	if err := json.Unmarshal(b, &m); err != nil {
+		return err
+	}
+	return ts.UpdateState(ctx, m)
+}
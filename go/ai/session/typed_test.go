@@ -0,0 +1,75 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package session
+
+import (
+	"context"
+	"testing"
+)
+
+type testState struct {
+	UserName string `json:"userName,omitempty"`
+	Visits   int    `json:"visits,omitempty"`
+}
+
+func TestTypedSession(t *testing.T) {
+	ctx := context.Background()
+	store := NewInMemoryStore()
+	ts, err := NewTyped[testState](ctx, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ts.UpdateTypedState(ctx, testState{UserName: "Earl", Visits: 3}); err != nil {
+		t.Fatal(err)
+	}
+	state, err := ts.TypedState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.UserName != "Earl" || state.Visits != 3 {
+		t.Errorf("state = %+v, want Earl with 3 visits", state)
+	}
+}
+
+func TestTypedSessionMigration(t *testing.T) {
+	ctx := context.Background()
+	store := NewInMemoryStore()
+	s, err := New(ctx, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Simulate a session written with an older state shape.
+	if err := s.UpdateState(ctx, map[string]any{"name": "Earl"}); err != nil {
+		t.Fatal(err)
+	}
+
+	ts, err := LoadTyped[testState](ctx, store, s.ID(), func(state map[string]any) (map[string]any, error) {
+		if name, ok := state["name"]; ok {
+			state["userName"] = name
+			delete(state, "name")
+		}
+		return state, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, err := ts.TypedState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.UserName != "Earl" {
+		t.Errorf("migrated state = %+v, want userName Earl", state)
+	}
+}
@@ -0,0 +1,88 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ai
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/firebase/genkit/go/core/logger"
+	"github.com/firebase/genkit/go/core/tracing"
+)
+
+// This file implements shadow traffic: mirroring generation requests
+// to a candidate model without ever returning its output to users, so
+// a candidate can be compared against the current default on real
+// traffic before switching.
+
+// DefineShadowedModel registers a model that serves every request with
+// primary and asynchronously mirrors it to shadow. The user always
+// gets the primary's response; the shadow call runs in the background
+// (as its own span in the same trace) and its latency, output size and
+// similarity to the primary's output are recorded for offline
+// comparison.
+func DefineShadowedModel(provider, name string, primary, shadow *Model) *Model {
+	return DefineModel(provider, name, nil, func(ctx context.Context, req *GenerateRequest, cb ModelStreamingCallback) (*GenerateResponse, error) {
+		tracing.SetCustomMetadataAttr(ctx, "shadowModel", modelName(shadow))
+		start := time.Now()
+		resp, err := primary.Generate(ctx, req, cb)
+		if err != nil {
+			return nil, err
+		}
+		// Mirror in the background, detached from the request's
+		// cancellation so a fast client response does not cut the
+		// shadow short.
+		go mirrorToShadow(context.WithoutCancel(ctx), shadow, req, resp, time.Since(start))
+		return resp, nil
+	})
+}
+
+// mirrorToShadow runs req against the shadow model and records the
+// comparison with the primary's response.
+func mirrorToShadow(ctx context.Context, shadow *Model, req *GenerateRequest, primaryResp *GenerateResponse, primaryLatency time.Duration) {
+	start := time.Now()
+	shadowResp, err := shadow.Generate(ctx, req, nil)
+	attrs := map[string]string{
+		"shadowModel":      modelName(shadow),
+		"primaryLatencyMs": strconv.FormatInt(primaryLatency.Milliseconds(), 10),
+		"shadowLatencyMs":  strconv.FormatInt(time.Since(start).Milliseconds(), 10),
+	}
+	if err != nil {
+		attrs["shadowError"] = err.Error()
+	} else {
+		primaryText, _ := primaryResp.Text()
+		shadowText, _ := shadowResp.Text()
+		attrs["primaryChars"] = strconv.Itoa(len(primaryText))
+		attrs["shadowChars"] = strconv.Itoa(len(shadowText))
+		attrs["textSimilarity"] = strconv.FormatFloat(textSimilarity(primaryText, shadowText), 'f', 3, 64)
+	}
+	tracing.AddEvent(ctx, "shadow-comparison", attrs)
+	logArgs := make([]any, 0, 2*len(attrs))
+	for k, v := range attrs {
+		logArgs = append(logArgs, k, v)
+	}
+	logger.FromContext(ctx).Info("shadow comparison", logArgs...)
+}
+
+// textSimilarity is the Jaccard similarity of the two texts' token
+// sets, in [0, 1].
+func textSimilarity(a, b string) float64 {
+	as, bs := tokenSet(a), tokenSet(b)
+	if len(as) == 0 && len(bs) == 0 {
+		return 1
+	}
+	return overlap(as, bs)
+}
@@ -0,0 +1,75 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ai
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestDefineShadowedModel(t *testing.T) {
+	primary := echoModel("shadowPrimary")
+	shadowed := make(chan *GenerateRequest, 1)
+	shadow := DefineModel("test", "shadowCandidate", nil, func(_ context.Context, req *GenerateRequest, _ ModelStreamingCallback) (*GenerateResponse, error) {
+		shadowed <- req
+		return &GenerateResponse{
+			Request: req,
+			Candidates: []*Candidate{{
+				Message: &Message{Role: RoleModel, Content: []*Part{NewTextPart("candidate")}},
+			}},
+		}, nil
+	})
+
+	m := DefineShadowedModel("test", "shadowedDefault", primary, shadow)
+	req := &GenerateRequest{Messages: []*Message{NewUserTextMessage("hello")}}
+	resp, err := m.Generate(context.Background(), req, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The user sees only the primary's output.
+	text, err := resp.Text()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "shadowPrimary" {
+		t.Errorf("got %q, want the primary's output", text)
+	}
+	// The shadow received the same request in the background.
+	select {
+	case got := <-shadowed:
+		if len(got.Messages) != 1 {
+			t.Errorf("shadow got %d messages, want 1", len(got.Messages))
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("shadow model was never called")
+	}
+}
+
+func TestTextSimilarity(t *testing.T) {
+	for _, test := range []struct {
+		a, b string
+		want float64
+	}{
+		{"the same text", "the same text", 1},
+		{"", "", 1},
+		{"alpha beta", "gamma delta", 0},
+		{"alpha beta", "alpha gamma", 1.0 / 3},
+	} {
+		if got := textSimilarity(test.a, test.b); got != test.want {
+			t.Errorf("textSimilarity(%q, %q) = %g, want %g", test.a, test.b, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ai
+
+import (
+	"encoding/base64"
+	"fmt"
+)
+
+// This file supports media-valued tool arguments, so a model can pass
+// an image it generated or received to an image-editing or
+// vision-verification tool. Tool inputs are JSON objects; a media
+// argument travels as {"url": ..., "contentType": ...}, where the URL
+// may be a data: URI carrying the bytes inline. Declare the argument
+// with [MediaSchema] and read it with [MediaFromInput].
+
+// MediaSchema returns the JSON schema for a media-valued tool argument,
+// for use in a tool definition's input schema:
+//
+//	InputSchema: map[string]any{
+//		"type": "object",
+//		"properties": map[string]any{
+//			"image":  ai.MediaSchema(),
+//			"prompt": map[string]any{"type": "string"},
+//		},
+//	}
+func MediaSchema() map[string]any {
+	return map[string]any{
+		"type":        "object",
+		"description": "A media value: a URL or data: URI, with an optional content type.",
+		"properties": map[string]any{
+			"url":         map[string]any{"type": "string"},
+			"contentType": map[string]any{"type": "string"},
+		},
+		"required": []any{"url"},
+	}
+}
+
+// MediaFromInput extracts the media argument named field from a tool
+// input, decoding inline data: URIs. The argument may be the
+// {"url", "contentType"} object described by [MediaSchema], or a bare
+// URL string.
+func MediaFromInput(input map[string]any, field string) (*MediaContent, error) {
+	v, ok := input[field]
+	if !ok {
+		return nil, fmt.Errorf("tool input has no media field %q", field)
+	}
+	var url, contentType string
+	switch m := v.(type) {
+	case string:
+		url = m
+	case map[string]any:
+		url, _ = m["url"].(string)
+		contentType, _ = m["contentType"].(string)
+	default:
+		return nil, fmt.Errorf("media field %q is %T, want an object or URL string", field, v)
+	}
+	if url == "" {
+		return nil, fmt.Errorf("media field %q has no URL", field)
+	}
+	mc, err := mediaContentFromPart(NewMediaPart(contentType, url))
+	if err != nil {
+		return nil, fmt.Errorf("media field %q: %w", field, err)
+	}
+	return &mc, nil
+}
+
+// MediaToolValue converts media to the tool-argument form described by
+// [MediaSchema], inlining bytes as a data: URI. Use it to hand a part
+// of a model response to [RunTool].
+func MediaToolValue(mc *MediaContent) map[string]any {
+	v := map[string]any{}
+	if mc.ContentType != "" {
+		v["contentType"] = mc.ContentType
+	}
+	if mc.URI != "" {
+		v["url"] = mc.URI
+	} else {
+		v["url"] = fmt.Sprintf("data:%s;base64,%s", mc.ContentType, base64.StdEncoding.EncodeToString(mc.Data))
+	}
+	return v
+}
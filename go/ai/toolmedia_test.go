@@ -0,0 +1,62 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ai
+
+import (
+	"testing"
+)
+
+func TestMediaFromInput(t *testing.T) {
+	t.Run("data URI object", func(t *testing.T) {
+		input := map[string]any{
+			"image": map[string]any{
+				"url":         "data:image/png;base64,aGVsbG8=",
+				"contentType": "image/png",
+			},
+		}
+		mc, err := MediaFromInput(input, "image")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mc.ContentType != "image/png" || string(mc.Data) != "hello" {
+			t.Errorf("got %+v", mc)
+		}
+	})
+	t.Run("bare URL string", func(t *testing.T) {
+		mc, err := MediaFromInput(map[string]any{"image": "https://example.com/a.png"}, "image")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mc.URI != "https://example.com/a.png" {
+			t.Errorf("got %+v", mc)
+		}
+	})
+	t.Run("missing field", func(t *testing.T) {
+		if _, err := MediaFromInput(map[string]any{}, "image"); err == nil {
+			t.Error("expected an error")
+		}
+	})
+}
+
+func TestMediaToolValueRoundTrip(t *testing.T) {
+	mc := &MediaContent{ContentType: "image/png", Data: []byte("hello")}
+	got, err := MediaFromInput(map[string]any{"image": MediaToolValue(mc)}, "image")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ContentType != mc.ContentType || string(got.Data) != string(mc.Data) {
+		t.Errorf("round trip got %+v, want %+v", got, mc)
+	}
+}
@@ -18,6 +18,8 @@ import (
 	"context"
 	"fmt"
 	"maps"
+	"strconv"
+	"strings"
 
 	"github.com/firebase/genkit/go/core"
 	"github.com/firebase/genkit/go/internal/atype"
@@ -47,6 +49,24 @@ func DefineTool(definition *ToolDefinition, metadata map[string]any, fn func(ctx
 	core.DefineAction(provider, definition.Name, atype.Tool, metadata, fn)
 }
 
+// DefineStrictTool is like [DefineTool], but validates the input
+// against the definition's input schema before running the tool:
+// unknown fields are rejected (with a "did you mean" suggestion when a
+// property name is close) and string values are coerced to the schema's
+// number or boolean types where that is lossless. Validation failures
+// are returned as a [*ToolInputError], which the generate loop feeds
+// back to the model as the tool's output so the model can correct its
+// arguments, instead of surfacing an unmarshal error to the caller.
+func DefineStrictTool(definition *ToolDefinition, metadata map[string]any, fn func(ctx context.Context, input map[string]any) (map[string]any, error)) {
+	DefineTool(definition, metadata, func(ctx context.Context, input map[string]any) (map[string]any, error) {
+		input, err := conformToolInput(definition, input)
+		if err != nil {
+			return nil, err
+		}
+		return fn(ctx, input)
+	})
+}
+
 // RunTool looks up a tool registered by [DefineTool],
 // runs it with the given input, and returns the result.
 func RunTool(ctx context.Context, name string, input map[string]any) (map[string]any, error) {
@@ -54,5 +74,127 @@ func RunTool(ctx context.Context, name string, input map[string]any) (map[string
 	if action == nil {
 		return nil, fmt.Errorf("no tool named %q", name)
 	}
+	// Tag the context so chunks sent with SendToolChunk carry the
+	// tool's name.
+	ctx = toolNameKey.NewContext(ctx, name)
 	return action.Run(ctx, input, nil)
 }
+
+// A ToolInputError reports tool arguments that do not match the tool's
+// input schema, phrased so it can be returned to the model verbatim.
+type ToolInputError struct {
+	Tool     string
+	Problems []string
+}
+
+func (e *ToolInputError) Error() string {
+	return fmt.Sprintf("invalid arguments for tool %q: %s", e.Tool, strings.Join(e.Problems, "; "))
+}
+
+// conformToolInput checks input against the tool's input schema,
+// rejecting unknown fields and coercing string values to declared
+// number and boolean types where lossless. It returns the (possibly
+// coerced) input, or a [*ToolInputError].
+func conformToolInput(definition *ToolDefinition, input map[string]any) (map[string]any, error) {
+	props, _ := definition.InputSchema["properties"].(map[string]any)
+	var problems []string
+	out := make(map[string]any, len(input))
+	for name, value := range input {
+		schema, ok := props[name].(map[string]any)
+		if !ok {
+			problem := fmt.Sprintf("unknown field %q", name)
+			if s := closestName(name, props); s != "" {
+				problem += fmt.Sprintf(", did you mean %q?", s)
+			}
+			problems = append(problems, problem)
+			continue
+		}
+		v, err := coerceValue(value, schema)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("field %q: %v", name, err))
+			continue
+		}
+		out[name] = v
+	}
+	if required, ok := definition.InputSchema["required"].([]any); ok {
+		for _, r := range required {
+			name, _ := r.(string)
+			if _, ok := input[name]; !ok {
+				problems = append(problems, fmt.Sprintf("missing required field %q", name))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return nil, &ToolInputError{Tool: definition.Name, Problems: problems}
+	}
+	return out, nil
+}
+
+// coerceValue converts value to the schema's type when the conversion
+// is lossless, and reports a type mismatch otherwise.
+func coerceValue(value any, schema map[string]any) (any, error) {
+	typ, _ := schema["type"].(string)
+	switch typ {
+	case "number", "integer":
+		switch v := value.(type) {
+		case float64:
+			return v, nil
+		case string:
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				return f, nil
+			}
+		}
+	case "boolean":
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case string:
+			if b, err := strconv.ParseBool(v); err == nil {
+				return b, nil
+			}
+		}
+	case "string":
+		switch v := value.(type) {
+		case string:
+			return v, nil
+		case float64, bool:
+			return fmt.Sprint(v), nil
+		}
+	default:
+		return value, nil
+	}
+	return nil, fmt.Errorf("cannot use %v (%T) as %s", value, value, typ)
+}
+
+// closestName returns the property name most similar to name, or "" if
+// none is close enough to suggest.
+func closestName(name string, props map[string]any) string {
+	best, bestDist := "", 3
+	for p := range props {
+		if d := editDistance(name, p); d < bestDist {
+			best, bestDist = p, d
+		}
+	}
+	return best
+}
+
+// editDistance is the Levenshtein distance between two strings.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, min(cur[j-1]+1, prev[j-1]+cost))
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,94 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ai
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestStrictTool(t *testing.T) {
+	DefineStrictTool(&ToolDefinition{
+		Name:        "weather",
+		Description: "Gets the weather.",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"city":  map[string]any{"type": "string"},
+				"count": map[string]any{"type": "integer"},
+			},
+			"required": []any{"city"},
+		},
+	}, nil, func(ctx context.Context, input map[string]any) (map[string]any, error) {
+		return input, nil
+	})
+	ctx := context.Background()
+
+	t.Run("unknown field suggestion", func(t *testing.T) {
+		_, err := RunTool(ctx, "weather", map[string]any{"citty": "SF"})
+		var tie *ToolInputError
+		if !errors.As(err, &tie) {
+			t.Fatalf("got %v, want a ToolInputError", err)
+		}
+		if !strings.Contains(tie.Error(), `did you mean "city"?`) {
+			t.Errorf("error %q does not suggest the close field name", tie.Error())
+		}
+	})
+	t.Run("missing required field", func(t *testing.T) {
+		_, err := RunTool(ctx, "weather", map[string]any{"count": 1.0})
+		var tie *ToolInputError
+		if !errors.As(err, &tie) {
+			t.Fatalf("got %v, want a ToolInputError", err)
+		}
+		if !strings.Contains(tie.Error(), `missing required field "city"`) {
+			t.Errorf("error = %q", tie.Error())
+		}
+	})
+	t.Run("safe coercion", func(t *testing.T) {
+		out, err := RunTool(ctx, "weather", map[string]any{"city": "SF", "count": "3"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out["count"] != float64(3) {
+			t.Errorf("count = %v (%T), want 3", out["count"], out["count"])
+		}
+	})
+	t.Run("bad type", func(t *testing.T) {
+		_, err := RunTool(ctx, "weather", map[string]any{"city": "SF", "count": "lots"})
+		var tie *ToolInputError
+		if !errors.As(err, &tie) {
+			t.Fatalf("got %v, want a ToolInputError", err)
+		}
+	})
+}
+
+func TestEditDistance(t *testing.T) {
+	for _, test := range []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"city", "city", 0},
+		{"citty", "city", 1},
+		{"ctiy", "city", 2},
+		{"zip", "city", 3},
+	} {
+		if got := editDistance(test.a, test.b); got != test.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", test.a, test.b, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ai
+
+import (
+	"context"
+
+	"github.com/firebase/genkit/go/internal"
+)
+
+// This file lets tools stream progress. A long tool run ("indexing 500
+// files") has no channel back to the client: the flow's streaming
+// callback is typed for the flow's own chunks. The host installs a
+// callback on the context with WithToolStreaming — typically forwarding
+// to its SSE or flow stream — and tools call SendToolChunk.
+
+// A ToolChunk is one progress chunk streamed by a tool, tagged with the
+// tool's name so interleaved tools can be told apart.
+type ToolChunk struct {
+	Tool string `json:"tool"`
+	Data any    `json:"data"`
+}
+
+var (
+	// toolStreamKey carries the host's tool-progress callback.
+	toolStreamKey = internal.NewContextKey[func(context.Context, ToolChunk) error]()
+	// toolNameKey carries the name of the tool currently running, set
+	// by RunTool.
+	toolNameKey = internal.NewContextKey[string]()
+)
+
+// WithToolStreaming returns a context that forwards tool progress
+// chunks to cb. Install it around a Generate call or flow execution to
+// surface progress from long-running tools in the response stream.
+func WithToolStreaming(ctx context.Context, cb func(context.Context, ToolChunk) error) context.Context {
+	return toolStreamKey.NewContext(ctx, cb)
+}
+
+// SendToolChunk streams a progress chunk from inside a tool function.
+// It is a no-op if the host did not install a callback with
+// [WithToolStreaming], so tools can call it unconditionally.
+func SendToolChunk(ctx context.Context, data any) error {
+	cb := toolStreamKey.FromContext(ctx)
+	if cb == nil {
+		return nil
+	}
+	return cb(ctx, ToolChunk{Tool: toolNameKey.FromContext(ctx), Data: data})
+}
@@ -0,0 +1,60 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ai
+
+import (
+	"context"
+	"testing"
+)
+
+func TestToolStreaming(t *testing.T) {
+	DefineTool(&ToolDefinition{
+		Name:        "indexer",
+		Description: "Indexes files.",
+	}, nil, func(ctx context.Context, input map[string]any) (map[string]any, error) {
+		for i := 1; i <= 3; i++ {
+			if err := SendToolChunk(ctx, map[string]any{"indexed": i}); err != nil {
+				return nil, err
+			}
+		}
+		return map[string]any{"done": true}, nil
+	})
+
+	var chunks []ToolChunk
+	ctx := WithToolStreaming(context.Background(), func(_ context.Context, c ToolChunk) error {
+		chunks = append(chunks, c)
+		return nil
+	})
+	out, err := RunTool(ctx, "indexer", map[string]any{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out["done"] != true {
+		t.Errorf("got %v", out)
+	}
+	if len(chunks) != 3 {
+		t.Fatalf("got %d chunks, want 3", len(chunks))
+	}
+	for _, c := range chunks {
+		if c.Tool != "indexer" {
+			t.Errorf("chunk tool = %q, want indexer", c.Tool)
+		}
+	}
+
+	// Without a callback, SendToolChunk is a no-op.
+	if _, err := RunTool(context.Background(), "indexer", map[string]any{}); err != nil {
+		t.Fatal(err)
+	}
+}
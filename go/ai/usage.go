@@ -0,0 +1,54 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ai
+
+import "fmt"
+
+// This file aggregates token usage across the turns of a tool loop.
+// A single [Model.Generate] call may run the model several times, once per
+// tool round trip; reporting only the final turn's usage understates cost.
+
+// aggregateUsage sums the usage reported by each turn of a tool loop into a
+// single [GenerationUsage]. Turns that reported no usage are skipped. The
+// per-turn numbers are preserved in the Custom map under keys like
+// "turn.0.inputTokens" so cost dashboards can break the total down.
+func aggregateUsage(turns []*GenerationUsage) *GenerationUsage {
+	total := &GenerationUsage{Custom: map[string]float64{}}
+	for i, u := range turns {
+		if u == nil {
+			continue
+		}
+		total.InputCharacters += u.InputCharacters
+		total.InputImages += u.InputImages
+		total.InputTokens += u.InputTokens
+		total.OutputCharacters += u.OutputCharacters
+		total.OutputImages += u.OutputImages
+		total.OutputTokens += u.OutputTokens
+		total.TotalTokens += u.TotalTokens
+		for k, v := range u.Custom {
+			total.Custom[k] += v
+		}
+		if u.InputTokens != 0 {
+			total.Custom[fmt.Sprintf("turn.%d.inputTokens", i)] = float64(u.InputTokens)
+		}
+		if u.OutputTokens != 0 {
+			total.Custom[fmt.Sprintf("turn.%d.outputTokens", i)] = float64(u.OutputTokens)
+		}
+	}
+	if len(total.Custom) == 0 {
+		total.Custom = nil
+	}
+	return total
+}
@@ -0,0 +1,44 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ai
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestAggregateUsage(t *testing.T) {
+	got := aggregateUsage([]*GenerationUsage{
+		{InputTokens: 10, OutputTokens: 5, TotalTokens: 15},
+		nil,
+		{InputTokens: 20, OutputTokens: 7, TotalTokens: 27, Custom: map[string]float64{"cachedTokens": 3}},
+	})
+	want := &GenerationUsage{
+		InputTokens:  30,
+		OutputTokens: 12,
+		TotalTokens:  42,
+		Custom: map[string]float64{
+			"cachedTokens":        3,
+			"turn.0.inputTokens":  10,
+			"turn.0.outputTokens": 5,
+			"turn.2.inputTokens":  20,
+			"turn.2.outputTokens": 7,
+		},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("mismatch (-want, +got):\n%s", diff)
+	}
+}
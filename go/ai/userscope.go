@@ -0,0 +1,69 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ai
+
+import (
+	"context"
+	"errors"
+
+	"github.com/firebase/genkit/go/core"
+	"github.com/firebase/genkit/go/internal"
+)
+
+// This file supports retrieval over user-private documents. A flow
+// handling an authenticated request attaches the user's ID to the
+// context with [WithUserID]; [RetrieveForUser] then refuses to run
+// without one and injects it into the retriever's backend filter, so a
+// missing or forgotten filter fails closed instead of leaking another
+// tenant's documents.
+
+// userIDKey carries the authenticated user's ID in a context.
+var userIDKey = internal.NewContextKey[string]()
+
+// WithUserID returns a context that carries the authenticated user's
+// ID, for use with [RetrieveForUser].
+func WithUserID(ctx context.Context, uid string) context.Context {
+	return userIDKey.NewContext(ctx, uid)
+}
+
+// UserIDFromContext returns the user ID attached with [WithUserID], if
+// any.
+func UserIDFromContext(ctx context.Context) (string, bool) {
+	uid := userIDKey.FromContext(ctx)
+	return uid, uid != ""
+}
+
+// A UserFilter rewrites retriever options to restrict results to the
+// given user, in whatever form the backend expects — typically an
+// equality filter such as ownerId == uid.
+type UserFilter func(options any, uid string) (any, error)
+
+// RetrieveForUser runs the retriever with the request's options
+// rewritten by filter to restrict results to the user attached to the
+// context. It fails with [core.StatusPermissionDenied] if no user ID is
+// attached, so a request that skipped authentication cannot retrieve
+// anything.
+func RetrieveForUser(ctx context.Context, r *Retriever, req *RetrieverRequest, filter UserFilter) (*RetrieverResponse, error) {
+	uid, ok := UserIDFromContext(ctx)
+	if !ok {
+		return nil, core.NewError(core.StatusPermissionDenied,
+			errors.New("ai.RetrieveForUser: no user ID in context"))
+	}
+	options, err := filter(req.Options, uid)
+	if err != nil {
+		return nil, err
+	}
+	return r.Retrieve(ctx, &RetrieverRequest{Document: req.Document, Options: options})
+}
@@ -0,0 +1,58 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ai
+
+import (
+	"context"
+	"testing"
+
+	"github.com/firebase/genkit/go/core"
+)
+
+func TestRetrieveForUser(t *testing.T) {
+	// The corpus maps owner to documents; the retriever honors an
+	// {"ownerId": ...} filter in its options.
+	corpus := map[string][]string{
+		"alice": {"alice's note"},
+		"bob":   {"bob's note"},
+	}
+	r := DefineRetriever("test", "userScoped", func(ctx context.Context, req *RetrieverRequest) (*RetrieverResponse, error) {
+		owner := req.Options.(map[string]any)["ownerId"].(string)
+		var docs []*Document
+		for _, text := range corpus[owner] {
+			docs = append(docs, DocumentFromText(text, nil))
+		}
+		return &RetrieverResponse{Documents: docs}, nil
+	})
+	filter := UserFilter(func(options any, uid string) (any, error) {
+		return map[string]any{"ownerId": uid}, nil
+	})
+	req := &RetrieverRequest{Document: DocumentFromText("notes", nil)}
+
+	// Without a user ID the retrieval fails closed.
+	_, err := RetrieveForUser(context.Background(), r, req, filter)
+	if core.StatusOf(err) != core.StatusPermissionDenied {
+		t.Errorf("got %v, want PERMISSION_DENIED", err)
+	}
+
+	ctx := WithUserID(context.Background(), "alice")
+	resp, err := RetrieveForUser(ctx, r, req, filter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Documents) != 1 || docText(resp.Documents[0]) != "alice's note " {
+		t.Errorf("got %v, want alice's documents only", docTexts(resp.Documents))
+	}
+}
@@ -19,6 +19,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"maps"
+	"reflect"
+	"sync"
 	"time"
 
 	"github.com/firebase/genkit/go/core/logger"
@@ -292,12 +294,22 @@ func (a *Action[I, O, S]) desc() actionDesc {
 	return ad
 }
 
+// inferredSchemas caches reflected schemas per Go type: many actions
+// share input and output types, and reflection is comparatively
+// expensive. Cached schemas are shared and must not be mutated.
+var inferredSchemas sync.Map // reflect.Type -> *jsonschema.Schema
+
 func inferJSONSchema(x any) (s *jsonschema.Schema) {
+	t := reflect.TypeOf(x)
+	if cached, ok := inferredSchemas.Load(t); ok {
+		return cached.(*jsonschema.Schema)
+	}
 	r := jsonschema.Reflector{
 		DoNotReference: true,
 	}
 	s = r.Reflect(x)
 	// TODO: Unwind this change once Monaco Editor supports newer than JSON schema draft-07.
 	s.Version = ""
+	inferredSchemas.Store(t, s)
 	return s
 }
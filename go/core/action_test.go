@@ -126,3 +126,54 @@ func TestActionTracing(t *testing.T) {
 	}
 	t.Fatalf("did not find trace named %q", actionName)
 }
+
+type benchInput struct {
+	Query string   `json:"query"`
+	Count int      `json:"count"`
+	Tags  []string `json:"tags"`
+}
+
+func BenchmarkActionRun(b *testing.B) {
+	a := newAction("bench/echo", atype.Custom, nil, func(_ context.Context, in benchInput) (benchInput, error) {
+		return in, nil
+	})
+	in := benchInput{Query: "what is a benchmark?", Count: 3, Tags: []string{"a", "b", "c"}}
+	ctx := context.Background()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := a.Run(ctx, in, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkActionRunJSON(b *testing.B) {
+	a := newAction("bench/echoJSON", atype.Custom, nil, func(_ context.Context, in benchInput) (benchInput, error) {
+		return in, nil
+	})
+	input := []byte(`{"query": "what is a benchmark?", "count": 3, "tags": ["a", "b", "c"]}`)
+	ctx := context.Background()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := a.runJSON(ctx, input, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkValidateJSON(b *testing.B) {
+	schema := inferJSONSchema(benchInput{})
+	data := []byte(`{"query": "what is a benchmark?", "count": 3, "tags": ["a", "b", "c"]}`)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := validateJSON(data, schema); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestInferJSONSchemaCached(t *testing.T) {
+	if inferJSONSchema(benchInput{}) != inferJSONSchema(benchInput{}) {
+		t.Error("same type reflected twice")
+	}
+}
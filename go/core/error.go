@@ -0,0 +1,171 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// This file defines a structured error taxonomy. Plugins normalize
+// provider errors into these status codes so retry and fallback logic
+// can branch on error class instead of matching provider error strings.
+
+// A StatusCode classifies an error, mirroring the canonical gRPC codes.
+type StatusCode string
+
+const (
+	StatusInvalidArgument    StatusCode = "INVALID_ARGUMENT"
+	StatusDeadlineExceeded   StatusCode = "DEADLINE_EXCEEDED"
+	StatusNotFound           StatusCode = "NOT_FOUND"
+	StatusPermissionDenied   StatusCode = "PERMISSION_DENIED"
+	StatusUnauthenticated    StatusCode = "UNAUTHENTICATED"
+	StatusResourceExhausted  StatusCode = "RESOURCE_EXHAUSTED"
+	StatusFailedPrecondition StatusCode = "FAILED_PRECONDITION"
+	StatusAborted            StatusCode = "ABORTED"
+	StatusInternal           StatusCode = "INTERNAL"
+	StatusUnavailable        StatusCode = "UNAVAILABLE"
+	// StatusBlocked means the provider's safety filters blocked the
+	// request or response.
+	StatusBlocked StatusCode = "BLOCKED"
+)
+
+// HTTPStatus returns the HTTP status code conventionally used for the
+// status.
+func (c StatusCode) HTTPStatus() int {
+	switch c {
+	case StatusInvalidArgument:
+		return http.StatusBadRequest
+	case StatusDeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case StatusNotFound:
+		return http.StatusNotFound
+	case StatusPermissionDenied, StatusBlocked:
+		return http.StatusForbidden
+	case StatusUnauthenticated:
+		return http.StatusUnauthorized
+	case StatusResourceExhausted:
+		return http.StatusTooManyRequests
+	case StatusFailedPrecondition:
+		return http.StatusBadRequest
+	case StatusAborted:
+		return http.StatusConflict
+	case StatusUnavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// A GenkitError is an error normalized from a provider error. Callers
+// can branch on Status, and retry middleware can honor Retryable and
+// RetryAfter, without knowing which provider produced the error.
+type GenkitError struct {
+	// Status classifies the error.
+	Status StatusCode
+	// Provider is the plugin that produced the error, e.g. "googleai".
+	Provider string
+	// RawCode is the provider's own error code, e.g. an HTTP status.
+	RawCode string
+	// Retryable reports whether retrying the same request may succeed.
+	Retryable bool
+	// RetryAfter is how long the provider asked us to wait before
+	// retrying, if it said.
+	RetryAfter time.Duration
+	// Err is the underlying error.
+	Err error
+}
+
+func (e *GenkitError) Error() string {
+	if e.Provider != "" {
+		return fmt.Sprintf("%s: %s: %v", e.Provider, e.Status, e.Err)
+	}
+	return fmt.Sprintf("%s: %v", e.Status, e.Err)
+}
+
+func (e *GenkitError) Unwrap() error { return e.Err }
+
+// NewError returns a [GenkitError] with the given status wrapping err.
+func NewError(status StatusCode, err error) *GenkitError {
+	return &GenkitError{Status: status, Err: err}
+}
+
+// StatusOf returns the status of err: the Status of the [GenkitError]
+// in its chain, or [StatusInternal] if there is none.
+func StatusOf(err error) StatusCode {
+	var ge *GenkitError
+	if errors.As(err, &ge) {
+		return ge.Status
+	}
+	return StatusInternal
+}
+
+// IsRetryable reports whether err is marked retryable.
+func IsRetryable(err error) bool {
+	var ge *GenkitError
+	return errors.As(err, &ge) && ge.Retryable
+}
+
+// RetryAfter returns the retry-after hint attached to err, if any.
+func RetryAfter(err error) (time.Duration, bool) {
+	var ge *GenkitError
+	if errors.As(err, &ge) && ge.RetryAfter > 0 {
+		return ge.RetryAfter, true
+	}
+	return 0, false
+}
+
+// NormalizeHTTPError converts a provider HTTP error into a
+// [GenkitError], mapping the HTTP status to a [StatusCode] and marking
+// rate limits and transient server errors retryable. retryAfter is the
+// value of the Retry-After response header, if any, in seconds.
+func NormalizeHTTPError(provider string, httpStatus int, retryAfter string, err error) *GenkitError {
+	ge := &GenkitError{
+		Provider: provider,
+		RawCode:  fmt.Sprintf("%d", httpStatus),
+		Err:      err,
+	}
+	switch httpStatus {
+	case http.StatusBadRequest:
+		ge.Status = StatusInvalidArgument
+	case http.StatusUnauthorized:
+		ge.Status = StatusUnauthenticated
+	case http.StatusForbidden:
+		ge.Status = StatusPermissionDenied
+	case http.StatusNotFound:
+		ge.Status = StatusNotFound
+	case http.StatusConflict:
+		ge.Status = StatusAborted
+	case http.StatusTooManyRequests:
+		ge.Status = StatusResourceExhausted
+		ge.Retryable = true
+	case http.StatusRequestTimeout, http.StatusGatewayTimeout:
+		ge.Status = StatusDeadlineExceeded
+		ge.Retryable = true
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable:
+		ge.Status = StatusUnavailable
+		ge.Retryable = true
+	default:
+		ge.Status = StatusInternal
+	}
+	if retryAfter != "" {
+		if d, err := time.ParseDuration(retryAfter + "s"); err == nil {
+			ge.RetryAfter = d
+		}
+	}
+	return ge
+}
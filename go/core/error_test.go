@@ -0,0 +1,71 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestNormalizeHTTPError(t *testing.T) {
+	base := errors.New("quota exceeded")
+	ge := NormalizeHTTPError("googleai", 429, "30", base)
+	if ge.Status != StatusResourceExhausted {
+		t.Errorf("Status = %s, want %s", ge.Status, StatusResourceExhausted)
+	}
+	if !ge.Retryable {
+		t.Error("not retryable")
+	}
+	if ge.RetryAfter != 30*time.Second {
+		t.Errorf("RetryAfter = %s, want 30s", ge.RetryAfter)
+	}
+	if !errors.Is(ge, base) {
+		t.Error("does not wrap the underlying error")
+	}
+
+	// The helpers see the GenkitError through wrapping.
+	wrapped := fmt.Errorf("calling model: %w", ge)
+	if got := StatusOf(wrapped); got != StatusResourceExhausted {
+		t.Errorf("StatusOf = %s, want %s", got, StatusResourceExhausted)
+	}
+	if !IsRetryable(wrapped) {
+		t.Error("IsRetryable = false")
+	}
+	if d, ok := RetryAfter(wrapped); !ok || d != 30*time.Second {
+		t.Errorf("RetryAfter = %s, %t", d, ok)
+	}
+
+	if got := StatusOf(base); got != StatusInternal {
+		t.Errorf("StatusOf(plain error) = %s, want %s", got, StatusInternal)
+	}
+}
+
+func TestStatusHTTPStatus(t *testing.T) {
+	for _, test := range []struct {
+		status StatusCode
+		want   int
+	}{
+		{StatusResourceExhausted, 429},
+		{StatusUnavailable, 503},
+		{StatusBlocked, 403},
+		{StatusInternal, 500},
+	} {
+		if got := test.status.HTTPStatus(); got != test.want {
+			t.Errorf("%s.HTTPStatus() = %d, want %d", test.status, got, test.want)
+		}
+	}
+}
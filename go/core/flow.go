@@ -90,12 +90,15 @@ import (
 // A Flow[In, Out, Stream] represents a function from In to Out. The Stream parameter is for
 // flows that support streaming: providing their results incrementally.
 type Flow[In, Out, Stream any] struct {
-	name         string                // The last component of the flow's key in the registry.
-	fn           Func[In, Out, Stream] // The function to run.
-	stateStore   FlowStateStore        // Where FlowStates are stored, to support resumption.
-	tstate       *tracing.State        // set from the action when the flow is defined
-	inputSchema  *jsonschema.Schema    // Schema of the input to the flow
-	outputSchema *jsonschema.Schema    // Schema of the output out of the flow
+	name         string                     // The last component of the flow's key in the registry.
+	fn           Func[In, Out, Stream]      // The function to run.
+	stateStore   FlowStateStore             // Where FlowStates are stored, to support resumption.
+	tstate       *tracing.State             // set from the action when the flow is defined
+	inputSchema  *jsonschema.Schema         // Schema of the input to the flow
+	outputSchema *jsonschema.Schema         // Schema of the output out of the flow
+	meta         map[string]any             // the flow action's metadata, shared with the action
+	deprecated   string                     // if non-empty, a deprecation notice logged on each run
+	enabled      func(context.Context) bool // if non-nil, gates each run
 	// TODO(jba): scheduler
 	// TODO(jba): experimentalDurable
 	// TODO(jba): authPolicy
@@ -122,10 +125,85 @@ func defineFlow[In, Out, Stream any](r *registry, name string, fn Func[In, Out,
 	r.registerAction(a)
 	// TODO(jba): this is a roundabout way to transmit the tracing state. Is there a cleaner way?
 	f.tstate = a.tstate
+	f.meta = a.metadata
 	r.registerFlow(f)
 	return f
 }
 
+// InputSchema returns the JSON schema of the flow's input type.
+func (f *Flow[In, Out, Stream]) InputSchema() *jsonschema.Schema { return f.inputSchema }
+
+// OutputSchema returns the JSON schema of the flow's output type.
+func (f *Flow[In, Out, Stream]) OutputSchema() *jsonschema.Schema { return f.outputSchema }
+
+// SetExamples attaches curated example inputs to the flow. The examples
+// ship in the flow's action metadata under the "examples" key, so the
+// Dev UI can offer them as one-click input presets instead of testers
+// guessing the input shape. Call it where the flow is defined:
+//
+//	genkit.DefineFlow("summarize", fn).SetExamples(
+//		SummarizeInput{URL: "https://example.com", MaxWords: 100},
+//	)
+//
+// It returns the flow, for chaining. Examples are not validated against
+// the input schema.
+func (f *Flow[In, Out, Stream]) SetExamples(examples ...In) *Flow[In, Out, Stream] {
+	f.meta["examples"] = examples
+	return f
+}
+
+// SetDescription attaches a human-readable description to the flow's
+// action metadata, shown in the Dev UI and action catalogs. It returns
+// the flow, for chaining.
+func (f *Flow[In, Out, Stream]) SetDescription(desc string) *Flow[In, Out, Stream] {
+	f.meta["description"] = desc
+	return f
+}
+
+// SetTags attaches tags (e.g. "billing", "experimental") to the flow's
+// action metadata. The reflection API's action list can be filtered by
+// tag, enabling catalogs and per-tag policies. It returns the flow, for
+// chaining.
+func (f *Flow[In, Out, Stream]) SetTags(tags ...string) *Flow[In, Out, Stream] {
+	f.meta["tags"] = tags
+	return f
+}
+
+// SetOwner records the team or person responsible for the flow in its
+// action metadata. The reflection API's action list can be filtered by
+// owner. It returns the flow, for chaining.
+func (f *Flow[In, Out, Stream]) SetOwner(owner string) *Flow[In, Out, Stream] {
+	f.meta["owner"] = owner
+	return f
+}
+
+// SetDeprecated marks the flow deprecated. The notice (e.g. "use
+// summarizeV2") ships in the action metadata so the Dev UI can flag the
+// flow, and is logged as a warning on every run. A deprecated flow
+// still runs. It returns the flow, for chaining.
+func (f *Flow[In, Out, Stream]) SetDeprecated(notice string) *Flow[In, Out, Stream] {
+	f.deprecated = notice
+	f.meta["deprecated"] = notice
+	return f
+}
+
+// SetEnabledWhen gates the flow behind a runtime check, evaluated on
+// every run. When enabled returns false, the run fails with a
+// [GenkitError] with status [StatusFailedPrecondition] instead of
+// running the flow function, so callers can distinguish "turned off"
+// from a real failure. Use it for feature flags:
+//
+//	genkit.DefineFlow("beta", fn).SetEnabledWhen(func(ctx context.Context) bool {
+//		return flags.Enabled(ctx, "beta-flow")
+//	})
+//
+// It returns the flow, for chaining.
+func (f *Flow[In, Out, Stream]) SetEnabledWhen(enabled func(context.Context) bool) *Flow[In, Out, Stream] {
+	f.enabled = enabled
+	f.meta["gated"] = true
+	return f
+}
+
 // TODO(jba): use flowError?
 
 // A flowInstruction is an instruction to follow with a flow.
@@ -335,6 +413,12 @@ func (f *Flow[In, Out, Stream]) runJSON(ctx context.Context, input json.RawMessa
 
 // start starts executing the flow with the given input.
 func (f *Flow[In, Out, Stream]) start(ctx context.Context, input In, cb streamingCallback[Stream]) (_ *flowState[In, Out], err error) {
+	if f.enabled != nil && !f.enabled(ctx) {
+		return nil, NewError(StatusFailedPrecondition, fmt.Errorf("flow %q is disabled", f.name))
+	}
+	if f.deprecated != "" {
+		logger.FromContext(ctx).Warn("running deprecated flow", "flow", f.name, "notice", f.deprecated)
+	}
 	flowID, err := generateFlowID()
 	if err != nil {
 		return nil, err
@@ -538,6 +622,49 @@ func InternalRun[Out any](ctx context.Context, name string, f func() (Out, error
 	})
 }
 
+// InternalRunCached is for use by genkit.RunCached exclusively.
+// It is not subject to any backwards compatibility guarantees.
+func InternalRunCached[Out any](ctx context.Context, name, key string, f func() (Out, error)) (Out, error) {
+	fc := flowContextKey.FromContext(ctx)
+	if fc == nil {
+		var z Out
+		return z, fmt.Errorf("genkit.RunCached(%q): must be called from a flow", name)
+	}
+	return tracing.RunInNewSpan(ctx, fc.tracingState(), name, "flowStep", false, 0, func(ctx context.Context, _ int) (Out, error) {
+		tracing.SetCustomMetadataAttr(ctx, "flow:stepType", "runCached")
+		tracing.SetCustomMetadataAttr(ctx, "flow:stepName", name)
+		// Unlike [InternalRun], the cache key is provided by the caller rather
+		// than derived from the step's position in the flow, so the cached
+		// result survives retries that take a different path to this step.
+		cacheKey := name + "|" + key
+		fs := fc.stater()
+		fs.lock()
+		j, ok := fs.cache()[cacheKey]
+		fs.unlock()
+		if ok {
+			var t Out
+			if err := json.Unmarshal(j, &t); err != nil {
+				return internal.Zero[Out](), err
+			}
+			tracing.SetCustomMetadataAttr(ctx, "flow:state", "cached")
+			return t, nil
+		}
+		t, err := f()
+		if err != nil {
+			return internal.Zero[Out](), err
+		}
+		bytes, err := json.Marshal(t)
+		if err != nil {
+			return internal.Zero[Out](), err
+		}
+		fs.lock()
+		fs.cache()[cacheKey] = json.RawMessage(bytes)
+		fs.unlock()
+		tracing.SetCustomMetadataAttr(ctx, "flow:state", "run")
+		return t, nil
+	})
+}
+
 // Run runs the flow in the context of another flow. The flow must run to completion when started
 // (that is, it must not have interrupts).
 func (f *Flow[In, Out, Stream]) Run(ctx context.Context, input In) (Out, error) {
@@ -88,6 +88,40 @@ func TestFlowRun(t *testing.T) {
 	}
 }
 
+func TestFlowRunCached(t *testing.T) {
+	n := 0
+	stepf := func() (int, error) {
+		n++
+		return n, nil
+	}
+
+	flow := InternalDefineFlow("runCached", func(ctx context.Context, key string, _ NoStream) ([]int, error) {
+		// The same key should run the step only once.
+		g1, err := InternalRunCached(ctx, "s", key, stepf)
+		if err != nil {
+			return nil, err
+		}
+		g2, err := InternalRunCached(ctx, "s", key, stepf)
+		if err != nil {
+			return nil, err
+		}
+		g3, err := InternalRunCached(ctx, "s", "other", stepf)
+		if err != nil {
+			return nil, err
+		}
+		return []int{g1, g2, g3}, nil
+	})
+	state, err := flow.start(context.Background(), "key", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := state.Operation.Result.Response
+	want := []int{1, 1, 2}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
 func TestRunFlow(t *testing.T) {
 	reg, err := newRegistry()
 	if err != nil {
@@ -103,6 +137,31 @@ func TestRunFlow(t *testing.T) {
 	}
 }
 
+func TestFlowExamples(t *testing.T) {
+	reg, err := newRegistry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defineFlow(reg, "inc", incFlow).SetExamples(1, 2, 3)
+	var desc *actionDesc
+	for _, d := range reg.listActions() {
+		if d.Name == "inc" {
+			d := d
+			desc = &d
+		}
+	}
+	if desc == nil {
+		t.Fatal("flow action not registered")
+	}
+	got, ok := desc.Metadata["examples"].([]int)
+	if !ok {
+		t.Fatalf("examples metadata is %T, want []int", desc.Metadata["examples"])
+	}
+	if want := []int{1, 2, 3}; !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
 func TestFlowState(t *testing.T) {
 	// A flowState is an action output, so it must support JSON marshaling.
 	// Verify that a fully populated flowState can round-trip via JSON.
@@ -145,3 +204,44 @@ func TestFlowState(t *testing.T) {
 		t.Errorf("mismatch (-want, +got):\n%s", diff)
 	}
 }
+
+func TestFlowGating(t *testing.T) {
+	reg, err := newRegistry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	on := true
+	f := defineFlow(reg, "gated", incFlow).SetEnabledWhen(func(context.Context) bool {
+		return on
+	})
+	if _, err := f.Run(context.Background(), 1); err != nil {
+		t.Fatalf("enabled flow failed: %v", err)
+	}
+	on = false
+	_, err = f.Run(context.Background(), 1)
+	if err == nil {
+		t.Fatal("disabled flow ran")
+	}
+	if got := StatusOf(err); got != StatusFailedPrecondition {
+		t.Errorf("status = %s, want %s", got, StatusFailedPrecondition)
+	}
+}
+
+func TestFlowDeprecated(t *testing.T) {
+	reg, err := newRegistry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := defineFlow(reg, "old", incFlow).SetDeprecated("use new")
+	// A deprecated flow still runs.
+	got, err := f.Run(context.Background(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != 2 {
+		t.Errorf("got %d, want 2", got)
+	}
+	if f.meta["deprecated"] != "use new" {
+		t.Errorf("deprecated metadata = %v, want %q", f.meta["deprecated"], "use new")
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+// This file implements health and readiness endpoints for the flow
+// server, so deployments behind Kubernetes or Cloud Run can gate
+// traffic on the process actually being able to serve.
+
+var (
+	healthChecksMu sync.RWMutex
+	healthChecks   = map[string]func(context.Context) error{}
+)
+
+// RegisterHealthCheck registers a named readiness probe, replacing any
+// probe with the same name. Plugins register probes for the backends
+// they depend on (server reachable, credentials valid); the flow
+// server's /readyz endpoint reports ready only when every probe passes.
+func RegisterHealthCheck(name string, check func(context.Context) error) {
+	healthChecksMu.Lock()
+	defer healthChecksMu.Unlock()
+	healthChecks[name] = check
+}
+
+// runHealthChecks runs all registered probes and returns the failures
+// by probe name.
+func runHealthChecks(ctx context.Context) map[string]string {
+	healthChecksMu.RLock()
+	checks := make(map[string]func(context.Context) error, len(healthChecks))
+	for name, check := range healthChecks {
+		checks[name] = check
+	}
+	healthChecksMu.RUnlock()
+
+	failures := map[string]string{}
+	for name, check := range checks {
+		if err := check(ctx); err != nil {
+			failures[name] = err.Error()
+		}
+	}
+	return failures
+}
+
+// handleHealthz implements the liveness endpoint: the process is up.
+func handleHealthz(w http.ResponseWriter, r *http.Request) error {
+	_, err := w.Write([]byte("ok"))
+	return err
+}
+
+// handleReadyz implements the readiness endpoint: every registered
+// probe must pass. On failure it serves a 503 with the failing probes.
+func handleReadyz(w http.ResponseWriter, r *http.Request) error {
+	failures := runHealthChecks(r.Context())
+	if len(failures) > 0 {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return writeJSON(r.Context(), w, map[string]any{"status": "unavailable", "checks": failures})
+	}
+	return writeJSON(r.Context(), w, map[string]any{"status": "ok"})
+}
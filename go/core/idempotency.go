@@ -0,0 +1,74 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+)
+
+// This file implements idempotency keys for the flow server. A client
+// that times out and retries an expensive flow can send the same
+// Idempotency-Key header on the retry; the server replays the stored
+// result instead of running the flow again.
+
+// An IdempotencyStore persists flow results keyed by client-provided
+// idempotency keys. Implementations must be safe for concurrent use.
+type IdempotencyStore interface {
+	// Get returns the stored result for the key and whether one exists.
+	Get(ctx context.Context, key string) (json.RawMessage, bool, error)
+	// Put stores the result for the key.
+	Put(ctx context.Context, key string, result json.RawMessage) error
+}
+
+// idempotencyStore is the store used by flow servers, if any.
+var idempotencyStore IdempotencyStore
+
+// SetIdempotencyStore sets the store that flow servers use to honor the
+// Idempotency-Key request header. With no store set, the header is
+// ignored. Call this before starting the server.
+func SetIdempotencyStore(store IdempotencyStore) {
+	idempotencyStore = store
+}
+
+// An InMemoryIdempotencyStore is an [IdempotencyStore] that keeps
+// results in memory. Results survive only as long as the process, so
+// use a persistent implementation when retries may cross deployments.
+type InMemoryIdempotencyStore struct {
+	mu      sync.Mutex
+	results map[string]json.RawMessage
+}
+
+// NewInMemoryIdempotencyStore returns an empty [InMemoryIdempotencyStore].
+func NewInMemoryIdempotencyStore() *InMemoryIdempotencyStore {
+	return &InMemoryIdempotencyStore{results: map[string]json.RawMessage{}}
+}
+
+// Get implements [IdempotencyStore].
+func (s *InMemoryIdempotencyStore) Get(ctx context.Context, key string) (json.RawMessage, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result, ok := s.results[key]
+	return result, ok, nil
+}
+
+// Put implements [IdempotencyStore].
+func (s *InMemoryIdempotencyStore) Put(ctx context.Context, key string, result json.RawMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.results[key] = result
+	return nil
+}
@@ -0,0 +1,93 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// This file implements inspection mode: a read-only, authenticated
+// subset of the reflection API that a deployed service can expose so
+// the Dev UI and CLI can browse actions and traces on staging without
+// an SSH tunnel. Unlike the dev server, nothing here mutates state and
+// only explicitly allowlisted flows can be run.
+
+// InspectionOptions configures [NewInspectionServeMux].
+type InspectionOptions struct {
+	// AuthToken is required on every request, as
+	// "Authorization: Bearer <token>" or in the X-Genkit-Auth-Token
+	// header. It must be non-empty.
+	AuthToken string
+	// AllowedFlows names the flows that may be run remotely. Flows
+	// not listed (and all non-flow actions) can be listed but not
+	// run. If empty, no action can be run.
+	AllowedFlows []string
+}
+
+// NewInspectionServeMux constructs an [http.Handler] serving a
+// read-only subset of the reflection API: listing actions, fetching
+// traces from the trace store, and running the flows named in
+// opts.AllowedFlows. Mount it on a deployed service alongside the flow
+// routes:
+//
+//	mux.Handle("/api/", core.NewInspectionServeMux(&core.InspectionOptions{
+//		AuthToken: os.Getenv("INSPECT_TOKEN"),
+//	}))
+//
+// It returns an error if opts.AuthToken is empty; inspection mode is
+// never served unauthenticated.
+func NewInspectionServeMux(opts *InspectionOptions) (http.Handler, error) {
+	if opts == nil || opts.AuthToken == "" {
+		return nil, errors.New("core.NewInspectionServeMux: an AuthToken is required")
+	}
+	return newInspectionServeMux(globalRegistry, opts), nil
+}
+
+func newInspectionServeMux(reg *registry, opts *InspectionOptions) http.Handler {
+	s := &devServer{reg: reg, ops: newOperationStore()}
+	allowed := map[string]bool{}
+	for _, f := range opts.AllowedFlows {
+		allowed["/flow/"+f] = true
+	}
+	mux := http.NewServeMux()
+	handle(mux, "GET /api/__health", func(w http.ResponseWriter, _ *http.Request) error {
+		return nil
+	})
+	handle(mux, "GET /api/actions", s.handleListActions)
+	handle(mux, "GET /api/envs/{env}/traces/{traceID}", s.handleGetTrace)
+	handle(mux, "GET /api/envs/{env}/traces", s.handleListTraces)
+	handle(mux, "POST /api/runAction", func(w http.ResponseWriter, r *http.Request) error {
+		var body struct {
+			Key   string          `json:"key"`
+			Input json.RawMessage `json:"input"`
+		}
+		defer r.Body.Close()
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			return &httpError{http.StatusBadRequest, err}
+		}
+		if !allowed[body.Key] {
+			return &httpError{http.StatusForbidden, fmt.Errorf("action %q is not allowlisted for remote runs", body.Key)}
+		}
+		resp, err := runAction(r.Context(), reg, body.Key, body.Input, nil)
+		if err != nil {
+			return err
+		}
+		return writeJSON(r.Context(), w, resp)
+	})
+	return requireAuthToken(opts.AuthToken, mux)
+}
@@ -0,0 +1,83 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/firebase/genkit/go/internal/atype"
+)
+
+func TestInspectionServeMux(t *testing.T) {
+	r, err := newRegistry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.registerAction(newAction("inspect/inc", atype.Custom, nil, inc))
+	h := newInspectionServeMux(r, &InspectionOptions{AuthToken: "secret"})
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	do := func(t *testing.T, method, path, body string) int {
+		t.Helper()
+		var rd *strings.Reader
+		if body == "" {
+			rd = strings.NewReader("")
+		} else {
+			rd = strings.NewReader(body)
+		}
+		req, err := http.NewRequest(method, srv.URL+path, rd)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Authorization", "Bearer secret")
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		return res.StatusCode
+	}
+
+	t.Run("requires auth", func(t *testing.T) {
+		res, err := http.Get(srv.URL + "/api/actions")
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.StatusCode != http.StatusUnauthorized {
+			t.Errorf("got status %d, want 401", res.StatusCode)
+		}
+	})
+	t.Run("lists actions", func(t *testing.T) {
+		if got := do(t, "GET", "/api/actions", ""); got != 200 {
+			t.Errorf("got status %d, want 200", got)
+		}
+	})
+	t.Run("rejects unallowlisted runs", func(t *testing.T) {
+		body := `{"key": "/custom/inspect/inc", "input": 3}`
+		if got := do(t, "POST", "/api/runAction", body); got != http.StatusForbidden {
+			t.Errorf("got status %d, want 403", got)
+		}
+	})
+	t.Run("requires a token to construct", func(t *testing.T) {
+		if _, err := NewInspectionServeMux(&InspectionOptions{}); err == nil {
+			t.Error("NewInspectionServeMux with no token did not fail")
+		}
+	})
+}
@@ -0,0 +1,101 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// ErrQueueFull is returned by [ConcurrencyLimiter.Acquire] when the
+// limit is reached and the wait queue is already at its maximum length.
+var ErrQueueFull = errors.New("concurrency limit queue is full")
+
+// A ConcurrencyLimiter bounds the number of concurrent executions of
+// some operation. Executions beyond the limit wait in a queue of
+// bounded length; once the queue is full, further executions fail fast
+// with [ErrQueueFull] so that a traffic spike queues briefly instead of
+// opening an unbounded number of simultaneous requests.
+type ConcurrencyLimiter struct {
+	sem      chan struct{}
+	queued   atomic.Int64
+	maxQueue int64
+}
+
+// NewConcurrencyLimiter returns a limiter allowing n concurrent
+// executions with up to maxQueue executions waiting.
+func NewConcurrencyLimiter(n, maxQueue int) *ConcurrencyLimiter {
+	return &ConcurrencyLimiter{
+		sem:      make(chan struct{}, n),
+		maxQueue: int64(maxQueue),
+	}
+}
+
+// Acquire blocks until an execution slot is available or ctx is done.
+// It returns [ErrQueueFull] immediately if too many executions are
+// already waiting. A successful Acquire must be paired with a call to
+// [ConcurrencyLimiter.Release].
+func (l *ConcurrencyLimiter) Acquire(ctx context.Context) error {
+	select {
+	case l.sem <- struct{}{}:
+		return nil
+	default:
+	}
+	if l.queued.Add(1) > l.maxQueue {
+		l.queued.Add(-1)
+		return ErrQueueFull
+	}
+	defer l.queued.Add(-1)
+	select {
+	case l.sem <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Release returns an execution slot to the limiter.
+func (l *ConcurrencyLimiter) Release() {
+	<-l.sem
+}
+
+// QueueDepth returns the number of executions currently waiting.
+func (l *ConcurrencyLimiter) QueueDepth() int {
+	return int(l.queued.Load())
+}
+
+var (
+	flowLimitersMu sync.RWMutex
+	flowLimiters   = map[string]*ConcurrencyLimiter{}
+)
+
+// SetFlowConcurrencyLimit bounds the number of concurrent executions of
+// the named flow when served by the flow server: n requests run at
+// once, up to maxQueue more wait, and requests beyond that receive a
+// 429 response. Call it before starting the server.
+func SetFlowConcurrencyLimit(flowName string, n, maxQueue int) {
+	flowLimitersMu.Lock()
+	defer flowLimitersMu.Unlock()
+	flowLimiters[flowName] = NewConcurrencyLimiter(n, maxQueue)
+}
+
+// flowLimiter returns the limiter for the named flow, or nil.
+func flowLimiter(flowName string) *ConcurrencyLimiter {
+	flowLimitersMu.RLock()
+	defer flowLimitersMu.RUnlock()
+	return flowLimiters[flowName]
+}
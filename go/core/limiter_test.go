@@ -0,0 +1,67 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestConcurrencyLimiter(t *testing.T) {
+	ctx := context.Background()
+	l := NewConcurrencyLimiter(1, 1)
+	if err := l.Acquire(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// A second acquirer waits in the queue.
+	waited := make(chan error, 1)
+	go func() {
+		err := l.Acquire(ctx)
+		if err == nil {
+			defer l.Release()
+		}
+		waited <- err
+	}()
+	for l.QueueDepth() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// The queue is full, so a third acquirer fails fast.
+	if err := l.Acquire(ctx); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("got %v, want ErrQueueFull", err)
+	}
+
+	// Releasing the slot lets the queued acquirer proceed.
+	l.Release()
+	if err := <-waited; err != nil {
+		t.Errorf("queued Acquire failed: %v", err)
+	}
+}
+
+func TestConcurrencyLimiterContext(t *testing.T) {
+	l := NewConcurrencyLimiter(1, 1)
+	if err := l.Acquire(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	defer l.Release()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := l.Acquire(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+// This file implements per-path log level overrides. Logs emitted
+// through [FromContext] inside an action span are attributed to the
+// span's trace path (e.g. "/myFlow/googleai/gemini-1.5-pro"); an
+// override set with [SetLevelFor] changes the minimum level for that
+// path and everything beneath it at runtime, so debug logging can be
+// turned on for one misbehaving flow in production without a redeploy.
+
+var (
+	levelMu        sync.RWMutex
+	levelOverrides = map[string]slog.Level{}
+)
+
+// SetLevelFor sets the minimum log level for logs emitted within spans
+// whose trace path starts with path. Paths begin with "/" followed by
+// the top-level span name; "flow/name" is accepted as shorthand for a
+// flow's path "/name". Overrides take effect immediately for new log
+// records.
+func SetLevelFor(path string, level slog.Level) {
+	levelMu.Lock()
+	defer levelMu.Unlock()
+	levelOverrides[normalizePath(path)] = level
+}
+
+// ClearLevelFor removes the override for path, restoring the handler's
+// own level.
+func ClearLevelFor(path string) {
+	levelMu.Lock()
+	defer levelMu.Unlock()
+	delete(levelOverrides, normalizePath(path))
+}
+
+// LevelOverrides returns a copy of the current overrides, keyed by
+// normalized path.
+func LevelOverrides() map[string]slog.Level {
+	levelMu.RLock()
+	defer levelMu.RUnlock()
+	m := make(map[string]slog.Level, len(levelOverrides))
+	for k, v := range levelOverrides {
+		m[k] = v
+	}
+	return m
+}
+
+// normalizePath converts the accepted path forms to "/name...".
+func normalizePath(path string) string {
+	path = strings.TrimPrefix(path, "flow/")
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
+	}
+	return path
+}
+
+// levelFor returns the override for the longest matching path prefix.
+func levelFor(spanPath string) (slog.Level, bool) {
+	levelMu.RLock()
+	defer levelMu.RUnlock()
+	best := -1
+	var level slog.Level
+	for prefix, l := range levelOverrides {
+		if len(prefix) > best && (spanPath == prefix || strings.HasPrefix(spanPath, prefix+"/")) {
+			best = len(prefix)
+			level = l
+		}
+	}
+	return level, best >= 0
+}
+
+// WithSpanPath returns a logger that applies the [SetLevelFor]
+// override for spanPath, if any, in place of the handler's own level.
+// The tracing package calls it when it scopes a logger to a span.
+func WithSpanPath(l *slog.Logger, spanPath string) *slog.Logger {
+	return slog.New(&pathHandler{spanPath: spanPath, Handler: l.Handler()})
+}
+
+// pathHandler defers to the wrapped handler except that Enabled
+// consults the path's level override.
+type pathHandler struct {
+	spanPath string
+	slog.Handler
+}
+
+func (h *pathHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	if min, ok := levelFor(h.spanPath); ok {
+		return level >= min
+	}
+	return h.Handler.Enabled(ctx, level)
+}
+
+func (h *pathHandler) WithAttrs(as []slog.Attr) slog.Handler {
+	return &pathHandler{spanPath: h.spanPath, Handler: h.Handler.WithAttrs(as)}
+}
+
+func (h *pathHandler) WithGroup(name string) slog.Handler {
+	return &pathHandler{spanPath: h.spanPath, Handler: h.Handler.WithGroup(name)}
+}
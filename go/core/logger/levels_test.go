@@ -0,0 +1,66 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logger
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestLevelOverrides(t *testing.T) {
+	SetLevelFor("flow/noisy", slog.LevelDebug)
+	defer ClearLevelFor("flow/noisy")
+
+	// The "flow/" shorthand normalizes to the flow's trace path.
+	if _, ok := LevelOverrides()["/noisy"]; !ok {
+		t.Fatalf("overrides = %v, want /noisy", LevelOverrides())
+	}
+	// The override applies to the path and spans beneath it.
+	if l, ok := levelFor("/noisy"); !ok || l != slog.LevelDebug {
+		t.Errorf("levelFor(/noisy) = %v, %t", l, ok)
+	}
+	if _, ok := levelFor("/noisy/model"); !ok {
+		t.Error("override should apply to child spans")
+	}
+	if _, ok := levelFor("/noisyOther"); ok {
+		t.Error("override should not apply to a sibling with a shared name prefix")
+	}
+
+	ClearLevelFor("/noisy")
+	if _, ok := levelFor("/noisy"); ok {
+		t.Error("override survived ClearLevelFor")
+	}
+}
+
+func TestWithSpanPath(t *testing.T) {
+	var buf bytes.Buffer
+	// The handler's own level is Info; the override lowers it to Debug
+	// for one path.
+	base := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo}))
+	SetLevelFor("/verbose", slog.LevelDebug)
+	defer ClearLevelFor("/verbose")
+
+	WithSpanPath(base, "/verbose").Debug("wanted")
+	WithSpanPath(base, "/other").Debug("unwanted")
+	out := buf.String()
+	if !strings.Contains(out, "wanted") {
+		t.Error("debug log for the overridden path was dropped")
+	}
+	if strings.Contains(out, "unwanted") {
+		t.Error("debug log for another path was emitted")
+	}
+}
@@ -34,6 +34,11 @@ func init() {
 
 var loggerKey = internal.NewContextKey[*slog.Logger]()
 
+// NewContext returns a context whose Logger is l, for [FromContext].
+func NewContext(ctx context.Context, l *slog.Logger) context.Context {
+	return loggerKey.NewContext(ctx, l)
+}
+
 // FromContext returns the Logger in ctx, or the default Logger
 // if there is none.
 func FromContext(ctx context.Context) *slog.Logger {
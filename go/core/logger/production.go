@@ -0,0 +1,126 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logger
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"sync/atomic"
+)
+
+// This file provides the production log handler: one-line JSON records
+// with log-aggregator-friendly field names ("severity", "message"), so
+// the trace correlation attributes attached by the tracing package
+// (trace_id, span_id, path) land as top-level JSON fields, plus
+// optional sampling of high-volume info logs. Warnings and errors are
+// never sampled.
+
+// ProductionHandlerOptions configures [NewProductionHandler].
+type ProductionHandlerOptions struct {
+	// Level is the minimum record level, defaulting to Info.
+	Level slog.Leveler
+	// InfoSampleEvery, if greater than 1, keeps only one in every N
+	// records at Info level and below. Warnings and errors are always
+	// kept.
+	InfoSampleEvery int
+	// Writer receives the JSON records, defaulting to standard error.
+	Writer io.Writer
+}
+
+// NewProductionHandler returns a handler emitting one-line JSON
+// records, for production deployments. Replace the default handler
+// with it:
+//
+//	slog.SetDefault(slog.New(logger.NewProductionHandler(nil)))
+func NewProductionHandler(opts *ProductionHandlerOptions) slog.Handler {
+	if opts == nil {
+		opts = &ProductionHandlerOptions{}
+	}
+	w := opts.Writer
+	if w == nil {
+		w = os.Stderr
+	}
+	level := opts.Level
+	if level == nil {
+		level = slog.LevelInfo
+	}
+	inner := slog.NewJSONHandler(w, &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: productionAttr,
+	})
+	return &samplingHandler{
+		Handler: inner,
+		every:   uint64(max(opts.InfoSampleEvery, 0)),
+		count:   new(atomic.Uint64),
+	}
+}
+
+// productionAttr maps slog's default keys to the names log aggregators
+// expect.
+func productionAttr(groups []string, a slog.Attr) slog.Attr {
+	if len(groups) > 0 {
+		return a
+	}
+	switch a.Key {
+	case slog.LevelKey:
+		if level, ok := a.Value.Any().(slog.Level); ok {
+			return slog.String("severity", severityName(level))
+		}
+	case slog.MessageKey:
+		a.Key = "message"
+	}
+	return a
+}
+
+// severityName is the aggregator-conventional name for a level.
+func severityName(l slog.Level) string {
+	switch {
+	case l < slog.LevelInfo:
+		return "DEBUG"
+	case l < slog.LevelWarn:
+		return "INFO"
+	case l < slog.LevelError:
+		return "WARNING"
+	default:
+		return "ERROR"
+	}
+}
+
+// samplingHandler drops all but one in every N records at Info level
+// and below. The counter is shared across WithAttrs/WithGroup copies.
+type samplingHandler struct {
+	slog.Handler
+	every uint64
+	count *atomic.Uint64
+}
+
+func (h *samplingHandler) Handle(ctx context.Context, r slog.Record) error {
+	if h.every > 1 && r.Level < slog.LevelWarn {
+		if h.count.Add(1)%h.every != 1 {
+			return nil
+		}
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *samplingHandler) WithAttrs(as []slog.Attr) slog.Handler {
+	return &samplingHandler{Handler: h.Handler.WithAttrs(as), every: h.every, count: h.count}
+}
+
+func (h *samplingHandler) WithGroup(name string) slog.Handler {
+	return &samplingHandler{Handler: h.Handler.WithGroup(name), every: h.every, count: h.count}
+}
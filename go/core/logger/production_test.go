@@ -0,0 +1,83 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestProductionHandlerFields(t *testing.T) {
+	var buf bytes.Buffer
+	l := slog.New(NewProductionHandler(&ProductionHandlerOptions{Writer: &buf}))
+	l.Warn("something happened", "trace_id", "abc123", "model", "gemini-1.5-pro")
+
+	var rec map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatalf("output is not one-line JSON: %v\n%s", err, buf.String())
+	}
+	if rec["severity"] != "WARNING" {
+		t.Errorf("severity = %v, want WARNING", rec["severity"])
+	}
+	if rec["message"] != "something happened" {
+		t.Errorf("message = %v", rec["message"])
+	}
+	if rec["trace_id"] != "abc123" || rec["model"] != "gemini-1.5-pro" {
+		t.Errorf("attributes missing: %v", rec)
+	}
+}
+
+func TestProductionHandlerSampling(t *testing.T) {
+	var buf bytes.Buffer
+	l := slog.New(NewProductionHandler(&ProductionHandlerOptions{
+		Writer:          &buf,
+		InfoSampleEvery: 10,
+	}))
+	for i := 0; i < 100; i++ {
+		l.Info("chatty")
+	}
+	if got := strings.Count(buf.String(), "\n"); got != 10 {
+		t.Errorf("kept %d info records, want 10", got)
+	}
+
+	// Warnings bypass sampling.
+	buf.Reset()
+	for i := 0; i < 5; i++ {
+		l.Warn("important")
+	}
+	if got := strings.Count(buf.String(), "\n"); got != 5 {
+		t.Errorf("kept %d warnings, want all 5", got)
+	}
+}
+
+func TestSeverityName(t *testing.T) {
+	for _, test := range []struct {
+		level slog.Level
+		want  string
+	}{
+		{slog.LevelDebug, "DEBUG"},
+		{slog.LevelInfo, "INFO"},
+		{slog.LevelWarn, "WARNING"},
+		{slog.LevelError, "ERROR"},
+		{slog.LevelError + 4, "ERROR"},
+	} {
+		if got := severityName(test.level); got != test.want {
+			t.Errorf("severityName(%v) = %q, want %q", test.level, got, test.want)
+		}
+	}
+}
@@ -30,6 +30,8 @@ type metricInstruments struct {
 	actionLatencies metric.Int64Histogram
 	flowCounter     metric.Int64Counter
 	flowLatencies   metric.Int64Histogram
+	streamOccupancy metric.Int64Histogram
+	streamDropped   metric.Int64Counter
 }
 
 // Delay instrument creation until first use to ensure that
@@ -64,9 +66,35 @@ func initInstruments() (*metricInstruments, error) {
 	if err != nil {
 		return nil, err
 	}
+	insts.streamOccupancy, err = meter.Int64Histogram("genkit/stream/bufferOccupancy")
+	if err != nil {
+		return nil, err
+	}
+	insts.streamDropped, err = meter.Int64Counter("genkit/stream/droppedChunks")
+	if err != nil {
+		return nil, err
+	}
 	return insts, nil
 }
 
+// recordStreamOccupancy records the stream buffer's occupancy after an
+// enqueue, so slow consumers show up as high occupancy.
+func recordStreamOccupancy(n int) {
+	if insts := fetchInstruments(); insts != nil {
+		insts.streamOccupancy.Record(context.Background(), int64(n),
+			metric.WithAttributes(attribute.String("source", "go")))
+	}
+}
+
+// recordStreamDropped records chunks discarded by the
+// [BackpressureDropOldest] policy.
+func recordStreamDropped(n int) {
+	if insts := fetchInstruments(); insts != nil {
+		insts.streamDropped.Add(context.Background(), int64(n),
+			metric.WithAttributes(attribute.String("source", "go")))
+	}
+}
+
 func writeActionSuccess(ctx context.Context, actionName string, latency time.Duration) {
 	recordAction(ctx, latency,
 		attribute.String("name", actionName),
@@ -0,0 +1,126 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// This file implements operations: action runs that outlive a single
+// request. The dev server uses them so long-running actions can be
+// started, polled and cancelled from the Dev UI instead of holding one
+// HTTP request open for the whole run.
+
+// A devOperation is one background run of an action.
+type devOperation struct {
+	ID        string          `json:"id"`
+	ActionKey string          `json:"actionKey"`
+	Done      bool            `json:"done"`
+	Result    json.RawMessage `json:"result,omitempty"`
+	Error     string          `json:"error,omitempty"`
+	StartTime time.Time       `json:"startTime"`
+	EndTime   time.Time       `json:"endTime,omitempty"`
+
+	cancel context.CancelFunc
+}
+
+// An operationStore tracks the operations of one dev server.
+type operationStore struct {
+	mu  sync.Mutex
+	ops map[string]*devOperation
+}
+
+func newOperationStore() *operationStore {
+	return &operationStore{ops: map[string]*devOperation{}}
+}
+
+// start launches the action in the background and returns the new
+// operation. The returned snapshot is safe to marshal.
+func (s *operationStore) start(reg *registry, key string, input json.RawMessage) (*devOperation, error) {
+	action := reg.lookupAction(key)
+	if action == nil {
+		return nil, fmt.Errorf("no action with key %q", key)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	op := &devOperation{
+		ID:        uuid.NewString(),
+		ActionKey: key,
+		StartTime: time.Now(),
+		cancel:    cancel,
+	}
+	s.mu.Lock()
+	s.ops[op.ID] = op
+	s.mu.Unlock()
+
+	go func() {
+		defer cancel()
+		output, err := action.runJSON(ctx, input, nil)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		op.Done = true
+		op.EndTime = time.Now()
+		if err != nil {
+			op.Error = err.Error()
+		} else {
+			op.Result = output
+		}
+	}()
+	return s.snapshot(op.ID)
+}
+
+// snapshot returns a copy of the operation for marshaling.
+func (s *operationStore) snapshot(id string) (*devOperation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	op, ok := s.ops[id]
+	if !ok {
+		return nil, fmt.Errorf("no operation with ID %q", id)
+	}
+	cp := *op
+	cp.cancel = nil
+	return &cp, nil
+}
+
+// cancel cancels the operation's context. The operation completes with
+// the context error.
+func (s *operationStore) cancel(id string) error {
+	s.mu.Lock()
+	op, ok := s.ops[id]
+	s.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("no operation with ID %q", id)
+	}
+	op.cancel()
+	return nil
+}
+
+// list returns snapshots of all operations.
+func (s *operationStore) list() []*devOperation {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ops := make([]*devOperation, 0, len(s.ops))
+	for _, op := range s.ops {
+		cp := *op
+		cp.cancel = nil
+		ops = append(ops, &cp)
+	}
+	return ops
+}
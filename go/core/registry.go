@@ -23,6 +23,7 @@ import (
 	"os"
 	"path/filepath"
 	"slices"
+	"strings"
 	"sync"
 
 	"github.com/firebase/genkit/go/core/tracing"
@@ -118,6 +119,49 @@ func (r *registry) freeze() {
 	r.frozen = true
 }
 
+func (r *registry) setFrozen(frozen bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.frozen = frozen
+}
+
+// unregisterProvider removes all of a provider's actions from the
+// registry. Flows are not tied to a provider and are unaffected.
+func (r *registry) unregisterProvider(provider string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for key := range r.actions {
+		// Keys look like "/<type>/<provider>/<name>" for provider actions.
+		parts := strings.SplitN(key, "/", 4)
+		if len(parts) == 4 && parts[2] == provider {
+			delete(r.actions, key)
+			slog.Info("UnregisterAction", "key", key)
+		}
+	}
+}
+
+// runtimeRegMu serializes runtime registration windows.
+var runtimeRegMu sync.Mutex
+
+// InternalRegisterAtRuntime is for use by the genkit package only.
+// It is not subject to compatibility guarantees.
+//
+// It runs register, which may define new actions, against a registry
+// that [InternalInit] has already frozen.
+func InternalRegisterAtRuntime(register func()) {
+	runtimeRegMu.Lock()
+	defer runtimeRegMu.Unlock()
+	globalRegistry.setFrozen(false)
+	defer globalRegistry.setFrozen(true)
+	register()
+}
+
+// InternalUnregisterProvider is for use by the genkit package only.
+// It is not subject to compatibility guarantees.
+func InternalUnregisterProvider(provider string) {
+	globalRegistry.unregisterProvider(provider)
+}
+
 // lookupAction returns the action for the given key, or nil if there is none.
 func (r *registry) lookupAction(key string) action {
 	r.mu.Lock()
@@ -0,0 +1,43 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/firebase/genkit/go/internal/atype"
+)
+
+func TestRuntimeRegistration(t *testing.T) {
+	echo := func(ctx context.Context, s string) (string, error) { return s, nil }
+
+	globalRegistry.freeze()
+	defer globalRegistry.setFrozen(false)
+
+	// A frozen registry accepts registrations inside RegisterAtRuntime.
+	InternalRegisterAtRuntime(func() {
+		DefineAction("lateProvider", "echo", atype.Custom, nil, echo)
+	})
+	if a := LookupActionFor[string, string, struct{}](atype.Custom, "lateProvider", "echo"); a == nil {
+		t.Fatal("runtime-registered action not found")
+	}
+
+	// Unregistering a provider removes its actions.
+	InternalUnregisterProvider("lateProvider")
+	if a := LookupActionFor[string, string, struct{}](atype.Custom, "lateProvider", "echo"); a != nil {
+		t.Fatal("action still registered after UnregisterProvider")
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// This file defines how plugins obtain API keys and other secrets.
+// Rather than each plugin reading environment variables directly,
+// plugins call [ResolveSecret] with the secret's name (conventionally
+// the environment variable name, e.g. "PINECONE_API_KEY"). The
+// application can install a [SecretResolver] backed by a secret
+// manager, enabling rotation and keeping keys out of the process
+// environment on shared hosts; the default resolver reads the
+// environment, preserving the old behavior.
+
+// A SecretResolver resolves named secrets, such as plugin API keys.
+type SecretResolver interface {
+	// Secret returns the value of the named secret. It returns an
+	// error if the secret does not exist or cannot be read.
+	Secret(ctx context.Context, name string) (string, error)
+}
+
+// SecretResolverFunc adapts a function to the [SecretResolver]
+// interface.
+type SecretResolverFunc func(ctx context.Context, name string) (string, error)
+
+// Secret implements [SecretResolver].
+func (f SecretResolverFunc) Secret(ctx context.Context, name string) (string, error) {
+	return f(ctx, name)
+}
+
+// EnvResolver resolves each secret from the environment variable of
+// the same name. It is the default resolver.
+type EnvResolver struct{}
+
+// Secret implements [SecretResolver].
+func (EnvResolver) Secret(_ context.Context, name string) (string, error) {
+	v := os.Getenv(name)
+	if v == "" {
+		return "", fmt.Errorf("secret %s not set in the environment", name)
+	}
+	return v, nil
+}
+
+var secretMu sync.RWMutex
+
+// secretResolver is the installed resolver.
+var secretResolver SecretResolver = EnvResolver{}
+
+// SetSecretResolver installs the resolver used by [ResolveSecret].
+// Call it before initializing plugins. Passing nil restores the
+// default [EnvResolver].
+func SetSecretResolver(r SecretResolver) {
+	secretMu.Lock()
+	defer secretMu.Unlock()
+	if r == nil {
+		r = EnvResolver{}
+	}
+	secretResolver = r
+}
+
+// ResolveSecret returns the named secret from the installed resolver.
+// Plugins call this instead of reading environment variables, so
+// deployments can serve keys from a secret manager.
+func ResolveSecret(ctx context.Context, name string) (string, error) {
+	secretMu.RLock()
+	r := secretResolver
+	secretMu.RUnlock()
+	return r.Secret(ctx, name)
+}
@@ -0,0 +1,63 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestResolveSecret(t *testing.T) {
+	ctx := context.Background()
+
+	// The default resolver reads the environment.
+	t.Setenv("TEST_SECRET", "from-env")
+	got, err := ResolveSecret(ctx, "TEST_SECRET")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "from-env" {
+		t.Errorf("got %q, want from-env", got)
+	}
+	if _, err := ResolveSecret(ctx, "TEST_SECRET_UNSET"); err == nil {
+		t.Error("expected an error for an unset secret")
+	}
+
+	// A custom resolver takes over; nil restores the default.
+	SetSecretResolver(SecretResolverFunc(func(_ context.Context, name string) (string, error) {
+		if name == "TEST_SECRET" {
+			return "from-resolver", nil
+		}
+		return "", fmt.Errorf("no secret %s", name)
+	}))
+	defer SetSecretResolver(nil)
+	got, err = ResolveSecret(ctx, "TEST_SECRET")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "from-resolver" {
+		t.Errorf("got %q, want from-resolver", got)
+	}
+
+	SetSecretResolver(nil)
+	got, err = ResolveSecret(ctx, "TEST_SECRET")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "from-env" {
+		t.Errorf("after reset got %q, want from-env", got)
+	}
+}
@@ -24,6 +24,7 @@ package core
 
 import (
 	"context"
+	"crypto/subtle"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -34,6 +35,7 @@ import (
 	"os"
 	"os/signal"
 	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"syscall"
@@ -95,13 +97,18 @@ func InternalInit(ctx context.Context, opts *Options) error {
 		return ctx.Err()
 	}
 
+	defer shutdownDoneOnce.Do(func() { close(shutdownDoneCh) })
+
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
 
-	// It will block here (i.e. servers will run) until we get an interrupt signal.
+	// It will block here (i.e. servers will run) until we get an interrupt
+	// signal, a call to [InternalShutdown], or a cancelled context.
 	select {
 	case sig := <-sigCh:
 		slog.Info("received signal, initiating shutdown", "signal", sig)
+	case <-shutdownCh:
+		slog.Info("shutdown requested, initiating shutdown")
 	case err := <-errCh:
 		slog.Error("server error", "err", err)
 		return err
@@ -109,17 +116,69 @@ func InternalInit(ctx context.Context, opts *Options) error {
 		slog.Info("context cancelled, initiating shutdown")
 	}
 
-	return shutdownServers(servers)
+	return shutdownServers(servers, opts.ShutdownTimeout)
+}
+
+var (
+	shutdownOnce     sync.Once
+	shutdownCh       = make(chan struct{})
+	shutdownDoneOnce sync.Once
+	shutdownDoneCh   = make(chan struct{})
+)
+
+// InternalShutdown is for use by the genkit package only.
+// It is not subject to compatibility guarantees.
+//
+// It initiates a graceful shutdown of the servers started by
+// [InternalInit]: they stop accepting new requests, and in-flight
+// requests are allowed to drain up to the configured shutdown timeout.
+// It then waits for the shutdown to complete, or for ctx to be done.
+func InternalShutdown(ctx context.Context) error {
+	shutdownOnce.Do(func() { close(shutdownCh) })
+	select {
+	case <-shutdownDoneCh:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
-// startReflectionServer starts the Reflection API server listening at the
-// value of the environment variable GENKIT_REFLECTION_PORT for the port,
-// or ":3100" if it is empty.
+// startReflectionServer starts the Reflection API server listening at
+// the value of the environment variable GENKIT_REFLECTION_ADDRESS, or
+// the port in GENKIT_REFLECTION_PORT, or "127.0.0.1:3100" if both are
+// empty. If GENKIT_REFLECTION_AUTH_TOKEN is set, every request must
+// carry the token; set it when binding to a non-loopback address on a
+// shared machine, and give the same value to the CLI.
 func startReflectionServer(errCh chan<- error) *http.Server {
 	slog.Info("starting reflection server")
-	addr := serverAddress("", "GENKIT_REFLECTION_PORT", "127.0.0.1:3100")
-	mux := newDevServeMux(globalRegistry)
-	return startServer(addr, mux, errCh)
+	addr := os.Getenv("GENKIT_REFLECTION_ADDRESS")
+	if addr == "" {
+		addr = serverAddress("", "GENKIT_REFLECTION_PORT", "127.0.0.1:3100")
+	}
+	var handler http.Handler = newDevServeMux(globalRegistry)
+	handler = requireAuthToken(os.Getenv("GENKIT_REFLECTION_AUTH_TOKEN"), handler)
+	return startServer(addr, handler, errCh)
+}
+
+// requireAuthToken wraps h to reject requests that do not carry the
+// token, either as "Authorization: Bearer <token>" or in the
+// X-Genkit-Auth-Token header. If token is empty, h is returned
+// unchanged.
+func requireAuthToken(token string, h http.Handler) http.Handler {
+	if token == "" {
+		return h
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Compare in constant time so the token cannot be recovered
+		// byte by byte from response timing.
+		bearerOK := subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+token)) == 1
+		headerOK := subtle.ConstantTimeCompare([]byte(r.Header.Get("X-Genkit-Auth-Token")), []byte(token)) == 1
+		if !bearerOK && !headerOK {
+			http.Error(w, "missing or invalid auth token", http.StatusUnauthorized)
+			return
+		}
+		h.ServeHTTP(w, r)
+	})
 }
 
 // startFlowServer starts a production server listening at the given address.
@@ -153,10 +212,14 @@ func startServer(addr string, handler http.Handler, errCh chan<- error) *http.Se
 	return server
 }
 
-// shutdownServers initiates shutdown of the servers and waits for the shutdown to complete.
-// After 5 seconds, it will timeout.
-func shutdownServers(servers []*http.Server) error {
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+// shutdownServers initiates shutdown of the servers and waits for the
+// shutdown to complete, allowing in-flight requests to drain up to the
+// given timeout (5 seconds if zero).
+func shutdownServers(servers []*http.Server, timeout time.Duration) error {
+	if timeout == 0 {
+		timeout = 5 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 
 	var wg sync.WaitGroup
@@ -197,15 +260,22 @@ type Options struct {
 	// The names of flows to serve.
 	// If empty, all registered flows are served.
 	Flows []string
+	// How long to allow in-flight requests to drain during shutdown
+	// before giving up. If zero, 5 seconds.
+	ShutdownTimeout time.Duration
 }
 
 type devServer struct {
 	reg *registry
+	ops *operationStore
+	// exposedTypes, if non-empty, restricts the server to actions of
+	// these types (e.g. "flow", "model").
+	exposedTypes []string
 }
 
 func newDevServeMux(r *registry) *http.ServeMux {
 	mux := http.NewServeMux()
-	s := &devServer{r}
+	s := &devServer{r, newOperationStore(), exposedTypesFromEnv()}
 	handle(mux, "GET /api/__health", func(w http.ResponseWriter, _ *http.Request) error {
 		return nil
 	})
@@ -214,10 +284,147 @@ func newDevServeMux(r *registry) *http.ServeMux {
 	handle(mux, "GET /api/envs/{env}/traces/{traceID}", s.handleGetTrace)
 	handle(mux, "GET /api/envs/{env}/traces", s.handleListTraces)
 	handle(mux, "GET /api/envs/{env}/flowStates", s.handleListFlowStates)
+	handle(mux, "POST /api/operations", s.handleStartOperation)
+	handle(mux, "GET /api/operations", s.handleListOperations)
+	handle(mux, "GET /api/operations/{operationID}", s.handleGetOperation)
+	handle(mux, "POST /api/operations/{operationID}/cancel", s.handleCancelOperation)
+	handle(mux, "GET /api/logLevels", s.handleGetLogLevels)
+	handle(mux, "POST /api/logLevels", s.handleSetLogLevel)
+	handle(mux, "GET /api/streams", s.handleListStreams)
+	handle(mux, "DELETE /api/streams/{streamID}", s.handleExpireStream)
+	handle(mux, "POST /api/streams/purge", s.handlePurgeStreams)
 
 	return mux
 }
 
+// handleListStreams lists the durable streams known to the configured
+// [StreamManager], optionally restricted with the "state" query
+// parameter ("active" or "done").
+func (s *devServer) handleListStreams(w http.ResponseWriter, r *http.Request) error {
+	m := currentStreamManager()
+	if m == nil {
+		return &httpError{http.StatusNotFound, errors.New("no StreamManager configured")}
+	}
+	infos, err := m.List(r.Context(), &StreamFilter{State: r.FormValue("state")})
+	if err != nil {
+		return err
+	}
+	if infos == nil {
+		infos = []*StreamInfo{}
+	}
+	return writeJSON(r.Context(), w, infos)
+}
+
+// handleExpireStream force-expires one stream: it is marked finished
+// with an error, so producers stop and a later purge removes it.
+func (s *devServer) handleExpireStream(w http.ResponseWriter, r *http.Request) error {
+	m := currentStreamManager()
+	if m == nil {
+		return &httpError{http.StatusNotFound, errors.New("no StreamManager configured")}
+	}
+	id := r.PathValue("streamID")
+	if err := m.Finish(r.Context(), id, nil, "expired by operator"); err != nil {
+		return err
+	}
+	return writeJSON(r.Context(), w, map[string]string{"id": id})
+}
+
+// handlePurgeStreams removes finished streams older than the
+// "olderThan" duration in the body (e.g. "24h"; zero or absent purges
+// all finished streams) and reports how many were removed.
+func (s *devServer) handlePurgeStreams(w http.ResponseWriter, r *http.Request) error {
+	m := currentStreamManager()
+	if m == nil {
+		return &httpError{http.StatusNotFound, errors.New("no StreamManager configured")}
+	}
+	var body struct {
+		OlderThan string `json:"olderThan"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil && err != io.EOF {
+		return &httpError{http.StatusBadRequest, err}
+	}
+	var olderThan time.Duration
+	if body.OlderThan != "" {
+		var err error
+		olderThan, err = time.ParseDuration(body.OlderThan)
+		if err != nil {
+			return &httpError{http.StatusBadRequest, err}
+		}
+	}
+	n, err := m.Purge(r.Context(), olderThan)
+	if err != nil {
+		return err
+	}
+	return writeJSON(r.Context(), w, map[string]int{"purged": n})
+}
+
+// handleGetLogLevels returns the current per-path log level overrides.
+func (s *devServer) handleGetLogLevels(w http.ResponseWriter, r *http.Request) error {
+	overrides := logger.LevelOverrides()
+	m := make(map[string]string, len(overrides))
+	for path, level := range overrides {
+		m[path] = level.String()
+	}
+	return writeJSON(r.Context(), w, m)
+}
+
+// handleSetLogLevel sets the log level override for one trace path, or
+// clears it when no level is given, so debug logging can be toggled
+// for a single flow at runtime.
+func (s *devServer) handleSetLogLevel(w http.ResponseWriter, r *http.Request) error {
+	var body struct {
+		Path  string `json:"path"`
+		Level string `json:"level"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return &httpError{http.StatusBadRequest, err}
+	}
+	if body.Path == "" {
+		return &httpError{http.StatusBadRequest, errors.New("missing path")}
+	}
+	if body.Level == "" {
+		logger.ClearLevelFor(body.Path)
+		return writeJSON(r.Context(), w, map[string]string{"path": body.Path})
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(body.Level)); err != nil {
+		return &httpError{http.StatusBadRequest, err}
+	}
+	logger.SetLevelFor(body.Path, level)
+	return writeJSON(r.Context(), w, map[string]string{"path": body.Path, "level": level.String()})
+}
+
+// exposedTypesFromEnv parses GENKIT_REFLECTION_EXPOSED_TYPES, a
+// comma-separated list of action types (e.g. "flow,model"). An empty or
+// unset variable exposes every type.
+func exposedTypesFromEnv() []string {
+	var types []string
+	for _, t := range strings.Split(os.Getenv("GENKIT_REFLECTION_EXPOSED_TYPES"), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			types = append(types, t)
+		}
+	}
+	return types
+}
+
+// exposes reports whether the action with the given key may be listed
+// and run by this server. Keys have the form "/<type>/...".
+func (s *devServer) exposes(key string) bool {
+	if len(s.exposedTypes) == 0 {
+		return true
+	}
+	parts := strings.SplitN(key, "/", 3)
+	if len(parts) < 2 {
+		return false
+	}
+	for _, t := range s.exposedTypes {
+		if parts[1] == t {
+			return true
+		}
+	}
+	return false
+}
+
 // handleRunAction looks up an action by name in the registry, runs it with the
 // provided JSON input, and writes back the JSON-marshaled request.
 func (s *devServer) handleRunAction(w http.ResponseWriter, r *http.Request) error {
@@ -238,25 +445,73 @@ func (s *devServer) handleRunAction(w http.ResponseWriter, r *http.Request) erro
 			return err
 		}
 	}
+	if !s.exposes(body.Key) {
+		return &httpError{http.StatusForbidden, fmt.Errorf("action type of %q is not exposed by this server", body.Key)}
+	}
 	logger.FromContext(ctx).Debug("running action",
 		"key", body.Key,
 		"stream", stream)
 	var callback streamingCallback[json.RawMessage]
 	if stream {
-		// Stream results are newline-separated JSON.
-		callback = func(ctx context.Context, msg json.RawMessage) error {
+		// Mark the stream dead and cancel the action as soon as the
+		// client disconnects, so the underlying model stream stops
+		// promptly.
+		streamCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		var alive *atomic.Bool
+		streamCtx, alive = withStreamLiveness(streamCtx)
+		go func() {
+			<-streamCtx.Done()
+			alive.Store(false)
+		}()
+		ctx = streamCtx
+		// Stream results are newline-separated JSON, written by the
+		// buffer's consumer goroutine so a slow client applies
+		// backpressure by policy instead of stalling the producer.
+		buf := newChunkBuffer(currentStreamBufferOptions(), func(msg json.RawMessage) error {
 			_, err := fmt.Fprintf(w, "%s\n", msg)
 			if err != nil {
-				return err
+				alive.Store(false)
+				cancel()
+				return fmt.Errorf("%w: %v", ErrStreamClosed, err)
 			}
 			if f, ok := w.(http.Flusher); ok {
 				f.Flush()
 			}
 			return nil
+		})
+		defer buf.Close()
+		callback = func(ctx context.Context, msg json.RawMessage) error {
+			if !alive.Load() {
+				return ErrStreamClosed
+			}
+			return buf.Send(msg)
+		}
+		resp, err := runAction(ctx, s.reg, body.Key, body.Input, callback)
+		if err == nil {
+			// Flush buffered chunks before the final response so the
+			// client sees them in order.
+			err = buf.Close()
+		}
+		if err != nil {
+			if errors.Is(err, ErrStreamClosed) {
+				// The client is gone; there is no one to report the
+				// error to.
+				logger.FromContext(ctx).Debug("stream closed by client", "key", body.Key)
+				return nil
+			}
+			return err
 		}
+		return writeJSON(ctx, w, resp)
 	}
 	resp, err := runAction(ctx, s.reg, body.Key, body.Input, callback)
 	if err != nil {
+		if errors.Is(err, ErrStreamClosed) {
+			// The client is gone; there is no one to report the error
+			// to.
+			logger.FromContext(ctx).Debug("stream closed by client", "key", body.Key)
+			return nil
+		}
 		return err
 	}
 	return writeJSON(ctx, w, resp)
@@ -291,11 +546,73 @@ func runAction(ctx context.Context, reg *registry, key string, input json.RawMes
 	}, nil
 }
 
-// handleListActions lists all the registered actions.
+// handleStartOperation starts an action running in the background and
+// returns the new operation, which can be polled with
+// handleGetOperation. Use it for long-running actions that should not
+// hold a request open.
+func (s *devServer) handleStartOperation(w http.ResponseWriter, r *http.Request) error {
+	var body struct {
+		Key   string          `json:"key"`
+		Input json.RawMessage `json:"input"`
+	}
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		return &httpError{http.StatusBadRequest, err}
+	}
+	if !s.exposes(body.Key) {
+		return &httpError{http.StatusForbidden, fmt.Errorf("action type of %q is not exposed by this server", body.Key)}
+	}
+	op, err := s.ops.start(s.reg, body.Key, body.Input)
+	if err != nil {
+		return &httpError{http.StatusNotFound, err}
+	}
+	logger.FromContext(r.Context()).Debug("started operation",
+		"operationID", op.ID,
+		"key", body.Key)
+	return writeJSON(r.Context(), w, op)
+}
+
+// handleGetOperation returns the current state of an operation.
+func (s *devServer) handleGetOperation(w http.ResponseWriter, r *http.Request) error {
+	op, err := s.ops.snapshot(r.PathValue("operationID"))
+	if err != nil {
+		return &httpError{http.StatusNotFound, err}
+	}
+	return writeJSON(r.Context(), w, op)
+}
+
+// handleListOperations lists all operations of this server.
+func (s *devServer) handleListOperations(w http.ResponseWriter, r *http.Request) error {
+	return writeJSON(r.Context(), w, s.ops.list())
+}
+
+// handleCancelOperation cancels a running operation; it completes with
+// a cancellation error.
+func (s *devServer) handleCancelOperation(w http.ResponseWriter, r *http.Request) error {
+	if err := s.ops.cancel(r.PathValue("operationID")); err != nil {
+		return &httpError{http.StatusNotFound, err}
+	}
+	return writeJSON(r.Context(), w, map[string]any{"cancelled": true})
+}
+
+// handleListActions lists all the registered actions. The list can be
+// narrowed with the "tag" and "owner" query parameters, which match
+// the corresponding action metadata keys.
 func (s *devServer) handleListActions(w http.ResponseWriter, r *http.Request) error {
+	tag := r.FormValue("tag")
+	owner := r.FormValue("owner")
 	descs := s.reg.listActions()
 	descMap := map[string]actionDesc{}
 	for _, d := range descs {
+		if !s.exposes(d.Key) {
+			continue
+		}
+		if tag != "" && !metadataHasTag(d.Metadata, tag) {
+			continue
+		}
+		if owner != "" && d.Metadata["owner"] != owner {
+			continue
+		}
 		descMap[d.Key] = d
 	}
 	return writeJSON(r.Context(), w, descMap)
@@ -358,6 +675,27 @@ func (s *devServer) handleListFlowStates(w http.ResponseWriter, r *http.Request)
 	return writeJSON(r.Context(), w, listFlowStatesResult{[]flowStater{}, ""})
 }
 
+// metadataHasTag reports whether md's "tags" entry contains tag. Tags
+// set via [Flow.SetTags] are a []string; tags that have round-tripped
+// through JSON are a []any.
+func metadataHasTag(md map[string]any, tag string) bool {
+	switch tags := md["tags"].(type) {
+	case []string:
+		for _, t := range tags {
+			if t == tag {
+				return true
+			}
+		}
+	case []any:
+		for _, t := range tags {
+			if t == tag {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 type listFlowStatesResult struct {
 	FlowStates        []flowStater `json:"flowStates"`
 	ContinuationToken string       `json:"continuationToken"`
@@ -390,12 +728,23 @@ func newFlowServeMux(r *registry, flows []string) *http.ServeMux {
 			handle(mux, "POST /"+f.Name(), nonDurableFlowHandler(f))
 		}
 	}
+	handle(mux, "GET /healthz", handleHealthz)
+	handle(mux, "GET /readyz", handleReadyz)
 	return mux
 }
 
 func nonDurableFlowHandler(f flow) func(http.ResponseWriter, *http.Request) error {
 	return func(w http.ResponseWriter, r *http.Request) error {
 		defer r.Body.Close()
+		if l := flowLimiter(f.Name()); l != nil {
+			if err := l.Acquire(r.Context()); err != nil {
+				if errors.Is(err, ErrQueueFull) {
+					return &httpError{http.StatusTooManyRequests, err}
+				}
+				return err
+			}
+			defer l.Release()
+		}
 		input, err := io.ReadAll(r.Body)
 		if err != nil {
 			return err
@@ -405,14 +754,32 @@ func nonDurableFlowHandler(f flow) func(http.ResponseWriter, *http.Request) erro
 			return err
 		}
 		if stream {
-			// TODO(jba): implement streaming.
-			return &httpError{http.StatusNotImplemented, errors.New("streaming")}
+			return streamFlowResponse(r.Context(), w, f, json.RawMessage(input), currentStreamProtocol())
 		} else {
+			// If the client sent an idempotency key and we have a stored
+			// result for it, replay that instead of re-running the flow.
+			idemKey := ""
+			if key := r.Header.Get("Idempotency-Key"); key != "" && idempotencyStore != nil {
+				idemKey = f.Name() + "|" + key
+				out, ok, err := idempotencyStore.Get(r.Context(), idemKey)
+				if err != nil {
+					return err
+				}
+				if ok {
+					_, err = fmt.Fprintf(w, `{"result": %s}\n`, out)
+					return err
+				}
+			}
 			// TODO(jba): telemetry
 			out, err := f.runJSON(r.Context(), json.RawMessage(input), nil)
 			if err != nil {
 				return err
 			}
+			if idemKey != "" {
+				if err := idempotencyStore.Put(r.Context(), idemKey, out); err != nil {
+					logger.FromContext(r.Context()).Error("storing idempotent result", "err", err)
+				}
+			}
 			// Responses for non-streaming, non-durable flows are passed back
 			// with the flow result stored in a field called "result."
 			_, err = fmt.Fprintf(w, `{"result": %s}\n`, out)
@@ -480,10 +847,14 @@ func handle(mux *http.ServeMux, pattern string, f func(w http.ResponseWriter, r
 		err = f(w, r)
 		if err != nil {
 			// If the error is an httpError, serve the status code it contains.
+			// A GenkitError maps to the HTTP status for its status code.
 			// Otherwise, assume this is an unexpected error and serve a 500.
 			var herr *httpError
+			var gerr *GenkitError
 			if errors.As(err, &herr) {
 				http.Error(w, herr.Error(), herr.code)
+			} else if errors.As(err, &gerr) {
+				http.Error(w, gerr.Error(), gerr.Status.HTTPStatus())
 			} else {
 				http.Error(w, err.Error(), http.StatusInternalServerError)
 			}
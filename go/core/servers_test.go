@@ -17,12 +17,15 @@ package core
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/firebase/genkit/go/core/logger"
 	"github.com/firebase/genkit/go/core/tracing"
 	"github.com/firebase/genkit/go/internal/atype"
 	"github.com/google/go-cmp/cmp"
@@ -222,3 +225,321 @@ func readJSON[T any](r io.Reader) (T, error) {
 	}
 	return x, nil
 }
+
+func TestIdempotencyKey(t *testing.T) {
+	r, err := newRegistry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	calls := 0
+	defineFlow(r, "count", func(_ context.Context, i int, _ NoStream) (int, error) {
+		calls++
+		return i + calls, nil
+	})
+	SetIdempotencyStore(NewInMemoryIdempotencyStore())
+	defer SetIdempotencyStore(nil)
+	srv := httptest.NewServer(newFlowServeMux(r, nil))
+	defer srv.Close()
+
+	post := func(t *testing.T, key string) int {
+		req, err := http.NewRequest("POST", srv.URL+"/count", strings.NewReader("10"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if key != "" {
+			req.Header.Set("Idempotency-Key", key)
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+		if res.StatusCode != 200 {
+			t.Fatalf("status: got %d, want 200", res.StatusCode)
+		}
+		type resultType struct {
+			Result int
+		}
+		got, err := readJSON[resultType](res.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return got.Result
+	}
+
+	first := post(t, "abc")
+	if again := post(t, "abc"); again != first {
+		t.Errorf("retry with same key returned %d, want cached %d", again, first)
+	}
+	if other := post(t, "xyz"); other == first {
+		t.Error("different key should re-run the flow")
+	}
+	if calls != 2 {
+		t.Errorf("flow ran %d times, want 2", calls)
+	}
+}
+
+func TestHealthEndpoints(t *testing.T) {
+	r, err := newRegistry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(newFlowServeMux(r, nil))
+	defer srv.Close()
+
+	get := func(t *testing.T, path string) int {
+		res, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		return res.StatusCode
+	}
+
+	if got := get(t, "/healthz"); got != 200 {
+		t.Errorf("/healthz = %d, want 200", got)
+	}
+	if got := get(t, "/readyz"); got != 200 {
+		t.Errorf("/readyz with no checks = %d, want 200", got)
+	}
+
+	RegisterHealthCheck("backend", func(ctx context.Context) error {
+		return errors.New("unreachable")
+	})
+	defer func() {
+		healthChecksMu.Lock()
+		delete(healthChecks, "backend")
+		healthChecksMu.Unlock()
+	}()
+	if got := get(t, "/readyz"); got != http.StatusServiceUnavailable {
+		t.Errorf("/readyz with failing check = %d, want 503", got)
+	}
+}
+
+func TestRequireAuthToken(t *testing.T) {
+	h := requireAuthToken("secret", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, "ok")
+	}))
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+
+	get := func(t *testing.T, header, value string) int {
+		t.Helper()
+		req, err := http.NewRequest("GET", srv.URL, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if header != "" {
+			req.Header.Set(header, value)
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		return res.StatusCode
+	}
+
+	if got := get(t, "", ""); got != http.StatusUnauthorized {
+		t.Errorf("no token = %d, want 401", got)
+	}
+	if got := get(t, "Authorization", "Bearer wrong"); got != http.StatusUnauthorized {
+		t.Errorf("wrong token = %d, want 401", got)
+	}
+	if got := get(t, "Authorization", "Bearer secret"); got != 200 {
+		t.Errorf("bearer token = %d, want 200", got)
+	}
+	if got := get(t, "X-Genkit-Auth-Token", "secret"); got != 200 {
+		t.Errorf("header token = %d, want 200", got)
+	}
+}
+
+func TestExposedTypes(t *testing.T) {
+	s := &devServer{exposedTypes: []string{"flow"}}
+	if !s.exposes("/flow/myFlow") {
+		t.Error("exposed type rejected")
+	}
+	if s.exposes("/custom/devServer/inc") {
+		t.Error("unexposed type allowed")
+	}
+	s = &devServer{}
+	if !s.exposes("/custom/devServer/inc") {
+		t.Error("empty allowlist should expose everything")
+	}
+}
+
+func TestListActionsFilter(t *testing.T) {
+	r, err := newRegistry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.registerAction(newAction("filter/a", atype.Custom, map[string]any{
+		"tags":  []string{"billing", "experimental"},
+		"owner": "team-x",
+	}, inc))
+	r.registerAction(newAction("filter/b", atype.Custom, map[string]any{
+		"owner": "team-y",
+	}, inc))
+	srv := httptest.NewServer(newDevServeMux(r))
+	defer srv.Close()
+
+	list := func(t *testing.T, query string) map[string]actionDesc {
+		t.Helper()
+		res, err := http.Get(srv.URL + "/api/actions" + query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+		if res.StatusCode != 200 {
+			t.Fatalf("got status %d, wanted 200", res.StatusCode)
+		}
+		got, err := readJSON[map[string]actionDesc](res.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+
+	for _, test := range []struct {
+		query string
+		want  []string
+	}{
+		{"", []string{"/custom/filter/a", "/custom/filter/b"}},
+		{"?tag=billing", []string{"/custom/filter/a"}},
+		{"?owner=team-y", []string{"/custom/filter/b"}},
+		{"?tag=billing&owner=team-y", nil},
+	} {
+		got := list(t, test.query)
+		if len(got) != len(test.want) {
+			t.Errorf("%q: got %d actions, want %d", test.query, len(got), len(test.want))
+			continue
+		}
+		for _, key := range test.want {
+			if _, ok := got[key]; !ok {
+				t.Errorf("%q: missing action %q", test.query, key)
+			}
+		}
+	}
+}
+
+func TestLogLevelEndpoint(t *testing.T) {
+	r, err := newRegistry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(newDevServeMux(r))
+	defer srv.Close()
+	defer logger.ClearLevelFor("/debugMe")
+
+	res, err := http.Post(srv.URL+"/api/logLevels", "application/json",
+		strings.NewReader(`{"path": "flow/debugMe", "level": "DEBUG"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != 200 {
+		t.Fatalf("set override: got status %d", res.StatusCode)
+	}
+
+	res, err = http.Get(srv.URL + "/api/logLevels")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var overrides map[string]string
+	if err := json.NewDecoder(res.Body).Decode(&overrides); err != nil {
+		t.Fatal(err)
+	}
+	if overrides["/debugMe"] != "DEBUG" {
+		t.Errorf("overrides = %v, want /debugMe: DEBUG", overrides)
+	}
+
+	// An empty level clears the override.
+	res, err = http.Post(srv.URL+"/api/logLevels", "application/json",
+		strings.NewReader(`{"path": "/debugMe"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != 200 {
+		t.Fatalf("clear override: got status %d", res.StatusCode)
+	}
+	if _, ok := logger.LevelOverrides()["/debugMe"]; ok {
+		t.Error("override survived clearing")
+	}
+
+	// A missing path is rejected.
+	res, err = http.Post(srv.URL+"/api/logLevels", "application/json",
+		strings.NewReader(`{"level": "DEBUG"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != 400 {
+		t.Errorf("missing path: got status %d, want 400", res.StatusCode)
+	}
+}
+
+func TestStreamClientDisconnect(t *testing.T) {
+	r, err := newRegistry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	observed := make(chan bool, 1)
+	r.registerAction(newStreamingAction("stream/disconnect", atype.Custom, nil,
+		func(ctx context.Context, _ int, cb func(context.Context, int) error) (int, error) {
+			for i := 0; i < 1000; i++ {
+				if !StreamAlive(ctx) {
+					observed <- true
+					return i, nil
+				}
+				if err := cb(ctx, i); err != nil {
+					observed <- errors.Is(err, ErrStreamClosed)
+					return 0, err
+				}
+				time.Sleep(2 * time.Millisecond)
+			}
+			observed <- false
+			return 0, nil
+		}))
+	srv := httptest.NewServer(newDevServeMux(r))
+	defer srv.Close()
+
+	reqCtx, cancelReq := context.WithCancel(context.Background())
+	defer cancelReq()
+	req, err := http.NewRequestWithContext(reqCtx, "POST", srv.URL+"/api/runAction?stream=true",
+		strings.NewReader(`{"key": "/custom/stream/disconnect", "input": 0}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	// Wait for the first chunk, then disconnect.
+	if _, err := resp.Body.Read(make([]byte, 16)); err != nil {
+		t.Fatal(err)
+	}
+	cancelReq()
+
+	select {
+	case ok := <-observed:
+		if !ok {
+			t.Error("action did not observe the closed stream")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("action never stopped after the client disconnected")
+	}
+}
+
+func TestStreamAliveDefault(t *testing.T) {
+	if !StreamAlive(context.Background()) {
+		t.Error("StreamAlive should be true without a stream")
+	}
+	ctx, alive := withStreamLiveness(context.Background())
+	if !StreamAlive(ctx) {
+		t.Error("StreamAlive should start true")
+	}
+	alive.Store(false)
+	if StreamAlive(ctx) {
+		t.Error("StreamAlive should be false after the flag clears")
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+
+	"github.com/firebase/genkit/go/internal"
+)
+
+// This file defines the error semantics for streaming to a client that
+// has gone away. When a chunk cannot be delivered because the HTTP
+// client disconnected, the streaming callback returns
+// [ErrStreamClosed] and the request context is canceled, so the
+// underlying model stream stops promptly. Flows doing expensive work
+// between chunks can additionally poll [StreamAlive] to stop early.
+
+// ErrStreamClosed is returned by a streaming callback when the client
+// consuming the stream has disconnected. Flows should treat it as a
+// signal to stop, not as a failure of their own.
+var ErrStreamClosed = errors.New("stream closed by client")
+
+// streamAliveKey carries the liveness flag for the request's stream.
+var streamAliveKey = internal.NewContextKey[*atomic.Bool]()
+
+// withStreamLiveness returns a context carrying a liveness flag for a
+// new stream, and the flag, which the server clears when the client
+// disconnects.
+func withStreamLiveness(ctx context.Context) (context.Context, *atomic.Bool) {
+	alive := new(atomic.Bool)
+	alive.Store(true)
+	return streamAliveKey.NewContext(ctx, alive), alive
+}
+
+// StreamAlive reports whether the client consuming this request's
+// stream is still connected. It returns true for requests that have no
+// stream. Long-running flows can poll it between pieces of expensive
+// work and stop early once the consumer has gone.
+func StreamAlive(ctx context.Context) bool {
+	if alive := streamAliveKey.FromContext(ctx); alive != nil {
+		return alive.Load()
+	}
+	return true
+}
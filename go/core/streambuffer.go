@@ -0,0 +1,197 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"encoding/json"
+	"sync"
+)
+
+// This file implements per-stream chunk buffering between the model
+// read loop and the HTTP response writer, so one slow consumer neither
+// stalls the producer nor balloons memory. When the buffer fills, the
+// configured [BackpressurePolicy] decides what happens to new chunks.
+// Buffer occupancy and dropped chunks are recorded as metrics (see
+// metrics.go).
+
+// A BackpressurePolicy says what a full stream buffer does with a new
+// chunk.
+type BackpressurePolicy int
+
+const (
+	// BackpressureBlock blocks the producer until the consumer drains
+	// a chunk. This is the default: no chunk is lost, but a slow
+	// consumer slows the model read loop.
+	BackpressureBlock BackpressurePolicy = iota
+	// BackpressureDropOldest drops the oldest buffered chunk to make
+	// room for the new one.
+	BackpressureDropOldest
+	// BackpressureCoalesce merges the new chunk into the newest
+	// buffered one using the Coalesce function of
+	// [StreamBufferOptions], keeping the buffer size constant.
+	BackpressureCoalesce
+)
+
+// defaultStreamBufferCapacity is the buffer capacity used when
+// [StreamBufferOptions.Capacity] is zero.
+const defaultStreamBufferCapacity = 32
+
+// StreamBufferOptions configures chunk buffering for streaming
+// responses.
+type StreamBufferOptions struct {
+	// Capacity is the maximum number of buffered chunks. If zero,
+	// defaultStreamBufferCapacity is used.
+	Capacity int
+	// Policy says what happens when the buffer is full.
+	Policy BackpressurePolicy
+	// Coalesce merges a new chunk into the newest buffered one when
+	// Policy is [BackpressureCoalesce]. If nil, the new chunk replaces
+	// the buffered one.
+	Coalesce func(buffered, next json.RawMessage) json.RawMessage
+}
+
+var (
+	streamBufferMu   sync.RWMutex
+	streamBufferOpts *StreamBufferOptions
+)
+
+// SetStreamBufferOptions sets the buffering options used for streaming
+// responses served by this process. Passing nil restores the defaults.
+func SetStreamBufferOptions(opts *StreamBufferOptions) {
+	streamBufferMu.Lock()
+	defer streamBufferMu.Unlock()
+	streamBufferOpts = opts
+}
+
+func currentStreamBufferOptions() *StreamBufferOptions {
+	streamBufferMu.RLock()
+	defer streamBufferMu.RUnlock()
+	if streamBufferOpts != nil {
+		return streamBufferOpts
+	}
+	return &StreamBufferOptions{}
+}
+
+// A chunkBuffer sits between a producer calling Send and a single
+// consumer goroutine that passes chunks to write, in order.
+type chunkBuffer struct {
+	opts  *StreamBufferOptions
+	write func(json.RawMessage) error
+
+	mu       sync.Mutex
+	cond     *sync.Cond
+	chunks   []json.RawMessage
+	closed   bool
+	writeErr error
+	done     chan struct{}
+}
+
+// newChunkBuffer returns a buffer draining to write and starts its
+// consumer goroutine. The caller must call Close.
+func newChunkBuffer(opts *StreamBufferOptions, write func(json.RawMessage) error) *chunkBuffer {
+	if opts.Capacity <= 0 {
+		o := *opts
+		o.Capacity = defaultStreamBufferCapacity
+		opts = &o
+	}
+	b := &chunkBuffer{
+		opts:  opts,
+		write: write,
+		done:  make(chan struct{}),
+	}
+	b.cond = sync.NewCond(&b.mu)
+	go b.drain()
+	return b
+}
+
+// Send buffers msg, applying the backpressure policy if the buffer is
+// full. It returns the consumer's write error, if any, so the producer
+// stops once the client is gone.
+func (b *chunkBuffer) Send(msg json.RawMessage) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for len(b.chunks) >= b.opts.Capacity {
+		if b.writeErr != nil {
+			return b.writeErr
+		}
+		switch b.opts.Policy {
+		case BackpressureDropOldest:
+			b.chunks = b.chunks[1:]
+			recordStreamDropped(1)
+		case BackpressureCoalesce:
+			last := len(b.chunks) - 1
+			if f := b.opts.Coalesce; f != nil {
+				b.chunks[last] = f(b.chunks[last], msg)
+			} else {
+				b.chunks[last] = msg
+			}
+			recordStreamOccupancy(len(b.chunks))
+			return nil
+		default: // BackpressureBlock
+			b.cond.Wait()
+			continue
+		}
+		break
+	}
+	if b.writeErr != nil {
+		return b.writeErr
+	}
+	b.chunks = append(b.chunks, msg)
+	recordStreamOccupancy(len(b.chunks))
+	b.cond.Broadcast()
+	return nil
+}
+
+// Close tells the consumer no more chunks are coming, waits for the
+// buffer to flush, and returns the first write error, if any.
+func (b *chunkBuffer) Close() error {
+	b.mu.Lock()
+	b.closed = true
+	b.cond.Broadcast()
+	b.mu.Unlock()
+	<-b.done
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.writeErr
+}
+
+// drain is the consumer goroutine: it pops chunks in order and passes
+// them to the write function until the buffer is closed and empty, or
+// a write fails.
+func (b *chunkBuffer) drain() {
+	defer close(b.done)
+	for {
+		b.mu.Lock()
+		for len(b.chunks) == 0 && !b.closed {
+			b.cond.Wait()
+		}
+		if len(b.chunks) == 0 {
+			b.mu.Unlock()
+			return
+		}
+		msg := b.chunks[0]
+		b.chunks = b.chunks[1:]
+		b.cond.Broadcast()
+		b.mu.Unlock()
+		if err := b.write(msg); err != nil {
+			b.mu.Lock()
+			b.writeErr = err
+			b.chunks = nil
+			b.cond.Broadcast()
+			b.mu.Unlock()
+			return
+		}
+	}
+}
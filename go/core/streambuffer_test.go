@@ -0,0 +1,144 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// gatedWriter collects written chunks, blocking each write until
+// release is closed. It signals started once the first write begins,
+// so tests can fill the buffer deterministically.
+type gatedWriter struct {
+	mu      sync.Mutex
+	got     []string
+	started chan struct{}
+	once    sync.Once
+	release chan struct{}
+}
+
+func newGatedWriter() *gatedWriter {
+	return &gatedWriter{
+		started: make(chan struct{}),
+		release: make(chan struct{}),
+	}
+}
+
+func (g *gatedWriter) write(msg json.RawMessage) error {
+	g.once.Do(func() { close(g.started) })
+	<-g.release
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.got = append(g.got, string(msg))
+	return nil
+}
+
+func TestChunkBufferBlock(t *testing.T) {
+	var mu sync.Mutex
+	var got []string
+	b := newChunkBuffer(&StreamBufferOptions{Capacity: 2}, func(msg json.RawMessage) error {
+		mu.Lock()
+		defer mu.Unlock()
+		got = append(got, string(msg))
+		return nil
+	})
+	want := []string{"1", "2", "3", "4", "5", "6", "7", "8"}
+	for _, c := range want {
+		if err := b.Send(json.RawMessage(c)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestChunkBufferDropOldest(t *testing.T) {
+	g := newGatedWriter()
+	b := newChunkBuffer(&StreamBufferOptions{
+		Capacity: 2,
+		Policy:   BackpressureDropOldest,
+	}, g.write)
+	if err := b.Send(json.RawMessage("1")); err != nil {
+		t.Fatal(err)
+	}
+	<-g.started // the consumer holds "1"; the buffer is empty
+	for _, c := range []string{"2", "3", "4", "5"} {
+		if err := b.Send(json.RawMessage(c)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	close(g.release)
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// "2" and "3" were dropped to make room.
+	if want := []string{"1", "4", "5"}; !slices.Equal(g.got, want) {
+		t.Errorf("got %v, want %v", g.got, want)
+	}
+}
+
+func TestChunkBufferCoalesce(t *testing.T) {
+	g := newGatedWriter()
+	b := newChunkBuffer(&StreamBufferOptions{
+		Capacity: 1,
+		Policy:   BackpressureCoalesce,
+		Coalesce: func(buffered, next json.RawMessage) json.RawMessage {
+			return append(slices.Clone(buffered), next...)
+		},
+	}, g.write)
+	if err := b.Send(json.RawMessage("a")); err != nil {
+		t.Fatal(err)
+	}
+	<-g.started
+	for _, c := range []string{"b", "c", "d"} {
+		if err := b.Send(json.RawMessage(c)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	close(g.release)
+	if err := b.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a", "bcd"}; !slices.Equal(g.got, want) {
+		t.Errorf("got %v, want %v", g.got, want)
+	}
+}
+
+func TestChunkBufferWriteError(t *testing.T) {
+	writeErr := errors.New("broken pipe")
+	b := newChunkBuffer(&StreamBufferOptions{Capacity: 1}, func(json.RawMessage) error {
+		return writeErr
+	})
+	var err error
+	for i := 0; i < 100; i++ {
+		if err = b.Send(json.RawMessage("x")); err != nil {
+			break
+		}
+	}
+	if !errors.Is(err, writeErr) {
+		t.Errorf("Send error = %v, want %v", err, writeErr)
+	}
+	if err := b.Close(); !errors.Is(err, writeErr) {
+		t.Errorf("Close error = %v, want %v", err, writeErr)
+	}
+}
@@ -0,0 +1,242 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	"github.com/firebase/genkit/go/core/logger"
+)
+
+// This file defines the typed event vocabulary that streaming flows
+// put on the wire, and the protocols that frame those events, so a
+// frontend AI SDK can consume a Go flow server without a custom
+// adapter. A flow whose Stream type is [StreamEvent] emits events
+// directly; other chunk types are wrapped (see wrapStreamChunk).
+
+// A StreamEventType identifies what a [StreamEvent] carries.
+type StreamEventType string
+
+const (
+	// StreamEventTextDelta carries an increment of model text.
+	StreamEventTextDelta StreamEventType = "text-delta"
+	// StreamEventToolStatus reports a tool call starting or finishing.
+	StreamEventToolStatus StreamEventType = "tool-status"
+	// StreamEventSourceDocument carries a retrieved document backing
+	// the response.
+	StreamEventSourceDocument StreamEventType = "source-document"
+	// StreamEventUIComponent asks the frontend to render a named
+	// component with the given props.
+	StreamEventUIComponent StreamEventType = "ui-component"
+	// StreamEventError reports that the flow failed. It is the last
+	// event of its stream.
+	StreamEventError StreamEventType = "error"
+	// StreamEventDone carries the flow's final result. It is the last
+	// event of its stream.
+	StreamEventDone StreamEventType = "done"
+)
+
+// A StreamEvent is one event of a flow's response stream. Only the
+// fields for its Type are set.
+type StreamEvent struct {
+	Type StreamEventType `json:"type"`
+	// Text is the text increment of a text-delta event.
+	Text string `json:"text,omitempty"`
+	// Tool and Status describe a tool-status event, e.g.
+	// ("weather", "running").
+	Tool   string `json:"tool,omitempty"`
+	Status string `json:"status,omitempty"`
+	// Document is the document of a source-document event.
+	Document json.RawMessage `json:"document,omitempty"`
+	// Component and Props describe a ui-component event.
+	Component string          `json:"component,omitempty"`
+	Props     json.RawMessage `json:"props,omitempty"`
+	// Error is the message of an error event.
+	Error string `json:"error,omitempty"`
+	// Result is the flow's final result, on a done event.
+	Result json.RawMessage `json:"result,omitempty"`
+}
+
+// TextDeltaEvent returns a text-delta event carrying text.
+func TextDeltaEvent(text string) *StreamEvent {
+	return &StreamEvent{Type: StreamEventTextDelta, Text: text}
+}
+
+// ToolStatusEvent returns a tool-status event for the named tool.
+func ToolStatusEvent(tool, status string) *StreamEvent {
+	return &StreamEvent{Type: StreamEventToolStatus, Tool: tool, Status: status}
+}
+
+// SourceDocumentEvent returns a source-document event carrying doc,
+// which must marshal to JSON.
+func SourceDocumentEvent(doc any) (*StreamEvent, error) {
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	return &StreamEvent{Type: StreamEventSourceDocument, Document: data}, nil
+}
+
+// UIComponentEvent returns a ui-component event asking the frontend to
+// render the named component with props, which must marshal to JSON.
+func UIComponentEvent(component string, props any) (*StreamEvent, error) {
+	data, err := json.Marshal(props)
+	if err != nil {
+		return nil, err
+	}
+	return &StreamEvent{Type: StreamEventUIComponent, Component: component, Props: data}, nil
+}
+
+// A StreamProtocol is a wire framing for [StreamEvent]s.
+type StreamProtocol int
+
+const (
+	// StreamProtocolJSONL frames each event as one line of JSON. This
+	// is the default, matching the framing of the reflection API.
+	StreamProtocolJSONL StreamProtocol = iota
+	// StreamProtocolSSE frames each event as a server-sent event
+	// ("data: <json>\n\n"), the framing expected by EventSource and
+	// by popular frontend AI SDKs.
+	StreamProtocolSSE
+)
+
+// contentType is the Content-Type header for responses framed with p.
+func (p StreamProtocol) contentType() string {
+	if p == StreamProtocolSSE {
+		return "text/event-stream"
+	}
+	return "application/json"
+}
+
+var (
+	streamProtocolMu sync.RWMutex
+	streamProtocol   StreamProtocol
+)
+
+// SetStreamProtocol sets the wire framing used by flow servers in this
+// process for streaming responses. The default is
+// [StreamProtocolJSONL].
+func SetStreamProtocol(p StreamProtocol) {
+	streamProtocolMu.Lock()
+	defer streamProtocolMu.Unlock()
+	streamProtocol = p
+}
+
+func currentStreamProtocol() StreamProtocol {
+	streamProtocolMu.RLock()
+	defer streamProtocolMu.RUnlock()
+	return streamProtocol
+}
+
+// writeStreamEvent writes one framed event and flushes it to the
+// client.
+func writeStreamEvent(w io.Writer, p StreamProtocol, ev *StreamEvent) error {
+	data, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	if p == StreamProtocolSSE {
+		_, err = fmt.Fprintf(w, "data: %s\n\n", data)
+	} else {
+		_, err = fmt.Fprintf(w, "%s\n", data)
+	}
+	if err != nil {
+		return err
+	}
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+	return nil
+}
+
+// wrapStreamChunk turns one marshaled flow chunk into a [StreamEvent].
+// Chunks that already are events — objects with a "type" from the
+// vocabulary — pass through; a chunk that is a JSON string becomes a
+// text-delta of that string; any other chunk becomes a text-delta of
+// its JSON text.
+func wrapStreamChunk(msg json.RawMessage) *StreamEvent {
+	var ev StreamEvent
+	if err := json.Unmarshal(msg, &ev); err == nil {
+		switch ev.Type {
+		case StreamEventTextDelta, StreamEventToolStatus, StreamEventSourceDocument,
+			StreamEventUIComponent, StreamEventError, StreamEventDone:
+			return &ev
+		}
+	}
+	var s string
+	if err := json.Unmarshal(msg, &s); err == nil {
+		return TextDeltaEvent(s)
+	}
+	return TextDeltaEvent(string(msg))
+}
+
+// streamFlowResponse runs f with streaming enabled, writing vocabulary
+// events framed by p, and finishes the stream with a done or error
+// event.
+func streamFlowResponse(ctx context.Context, w http.ResponseWriter, f flow, input json.RawMessage, p StreamProtocol) error {
+	w.Header().Set("Content-Type", p.contentType())
+	// Mark the stream dead and cancel the flow as soon as the client
+	// disconnects, so the underlying model stream stops promptly.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var alive *atomic.Bool
+	ctx, alive = withStreamLiveness(ctx)
+	go func() {
+		<-ctx.Done()
+		alive.Store(false)
+	}()
+	// Chunk events are written by the buffer's consumer goroutine, so a
+	// slow client applies backpressure by policy instead of stalling
+	// the flow's producer.
+	buf := newChunkBuffer(currentStreamBufferOptions(), func(msg json.RawMessage) error {
+		if err := writeStreamEvent(w, p, wrapStreamChunk(msg)); err != nil {
+			alive.Store(false)
+			cancel()
+			return fmt.Errorf("%w: %v", ErrStreamClosed, err)
+		}
+		return nil
+	})
+	defer buf.Close()
+	cb := func(ctx context.Context, msg json.RawMessage) error {
+		if !alive.Load() {
+			return ErrStreamClosed
+		}
+		return buf.Send(msg)
+	}
+	out, err := f.runJSON(ctx, input, cb)
+	// Flush buffered chunks before the final event so the client sees
+	// them in order.
+	if cerr := buf.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		if errors.Is(err, ErrStreamClosed) {
+			// The client is gone; there is no one to report the error
+			// to.
+			logger.FromContext(ctx).Debug("stream closed by client", "flow", f.Name())
+			return nil
+		}
+		// The response has already started; report the failure in-band.
+		return writeStreamEvent(w, p, &StreamEvent{Type: StreamEventError, Error: err.Error()})
+	}
+	return writeStreamEvent(w, p, &StreamEvent{Type: StreamEventDone, Result: out})
+}
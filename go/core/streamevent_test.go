@@ -0,0 +1,161 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWrapStreamChunk(t *testing.T) {
+	for _, test := range []struct {
+		chunk string
+		want  StreamEvent
+	}{
+		{`{"type":"tool-status","tool":"weather","status":"running"}`,
+			StreamEvent{Type: StreamEventToolStatus, Tool: "weather", Status: "running"}},
+		{`"hello"`, StreamEvent{Type: StreamEventTextDelta, Text: "hello"}},
+		{`{"count":3}`, StreamEvent{Type: StreamEventTextDelta, Text: `{"count":3}`}},
+		{`7`, StreamEvent{Type: StreamEventTextDelta, Text: `7`}},
+	} {
+		got := wrapStreamChunk(json.RawMessage(test.chunk))
+		if got.Type != test.want.Type || got.Text != test.want.Text ||
+			got.Tool != test.want.Tool || got.Status != test.want.Status {
+			t.Errorf("wrapStreamChunk(%s) = %+v, want %+v", test.chunk, got, test.want)
+		}
+	}
+}
+
+func TestFlowServerStreaming(t *testing.T) {
+	r, err := newRegistry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defineFlow(r, "spell", func(ctx context.Context, word string, cb func(context.Context, string) error) (int, error) {
+		for _, c := range word {
+			if err := cb(ctx, string(c)); err != nil {
+				return 0, err
+			}
+		}
+		return len(word), nil
+	})
+	srv := httptest.NewServer(newFlowServeMux(r, nil))
+	defer srv.Close()
+
+	post := func(t *testing.T) string {
+		res, err := http.Post(srv.URL+"/spell?stream=true", "application/json", strings.NewReader(`"hi"`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+		if res.StatusCode != 200 {
+			t.Fatalf("got status %d, wanted 200", res.StatusCode)
+		}
+		body, err := io.ReadAll(res.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(body)
+	}
+
+	t.Run("jsonl", func(t *testing.T) {
+		want := `{"type":"text-delta","text":"h"}` + "\n" +
+			`{"type":"text-delta","text":"i"}` + "\n" +
+			`{"type":"done","result":2}` + "\n"
+		if got := post(t); got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	})
+	t.Run("sse", func(t *testing.T) {
+		SetStreamProtocol(StreamProtocolSSE)
+		defer SetStreamProtocol(StreamProtocolJSONL)
+		want := "data: " + `{"type":"text-delta","text":"h"}` + "\n\n" +
+			"data: " + `{"type":"text-delta","text":"i"}` + "\n\n" +
+			"data: " + `{"type":"done","result":2}` + "\n\n"
+		if got := post(t); got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	})
+	t.Run("client disconnect", func(t *testing.T) {
+		observed := make(chan bool, 1)
+		defineFlow(r, "drip", func(ctx context.Context, _ int, cb func(context.Context, int) error) (int, error) {
+			for i := 0; i < 1000; i++ {
+				if !StreamAlive(ctx) {
+					observed <- true
+					return i, nil
+				}
+				if err := cb(ctx, i); err != nil {
+					observed <- errors.Is(err, ErrStreamClosed)
+					return 0, err
+				}
+				time.Sleep(2 * time.Millisecond)
+			}
+			observed <- false
+			return 0, nil
+		})
+		srv := httptest.NewServer(newFlowServeMux(r, nil))
+		defer srv.Close()
+
+		reqCtx, cancelReq := context.WithCancel(context.Background())
+		defer cancelReq()
+		req, err := http.NewRequestWithContext(reqCtx, "POST", srv.URL+"/drip?stream=true",
+			strings.NewReader(`0`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+		// Wait for the first event, then disconnect.
+		if _, err := res.Body.Read(make([]byte, 16)); err != nil {
+			t.Fatal(err)
+		}
+		cancelReq()
+
+		select {
+		case ok := <-observed:
+			if !ok {
+				t.Error("flow did not observe the closed stream")
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("flow never stopped after the client disconnected")
+		}
+	})
+
+	t.Run("error event", func(t *testing.T) {
+		res, err := http.Post(srv.URL+"/spell?stream=true", "application/json", strings.NewReader(`3`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+		body, _ := io.ReadAll(res.Body)
+		var ev StreamEvent
+		if err := json.Unmarshal(body, &ev); err != nil {
+			t.Fatal(err)
+		}
+		if ev.Type != StreamEventError || ev.Error == "" {
+			t.Errorf("got %+v, want an error event", ev)
+		}
+	})
+}
@@ -0,0 +1,421 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/firebase/genkit/go/core/logger"
+	"github.com/google/uuid"
+)
+
+// This file implements durable streams: streams whose chunks are
+// persisted through a [StreamManager] rather than written to an HTTP
+// response, so a background job can produce a stream with no client
+// attached and clients can subscribe to it later (or re-subscribe
+// after a disconnect) by stream ID.
+
+// A StreamManager persists the chunks of durable streams. Every stream
+// has a unique string identifier. Streams are created implicitly by
+// the first Append or Read with a new ID.
+type StreamManager interface {
+	// Append adds a chunk to the end of the stream.
+	Append(ctx context.Context, streamID string, chunk json.RawMessage) error
+	// Finish marks the stream complete, recording the producer's
+	// result or, if errMsg is non-empty, its failure.
+	Finish(ctx context.Context, streamID string, result json.RawMessage, errMsg string) error
+	// Read returns the stream's chunks at index offset and beyond,
+	// blocking until at least one is available, the stream finishes,
+	// or ctx is done.
+	Read(ctx context.Context, streamID string, offset int) (*StreamState, error)
+	// List returns information about the streams matching filter,
+	// most recently updated first.
+	List(ctx context.Context, filter *StreamFilter) ([]*StreamInfo, error)
+	// Purge removes finished streams whose last activity is older
+	// than olderThan and reports how many it removed. A zero
+	// olderThan removes all finished streams.
+	Purge(ctx context.Context, olderThan time.Duration) (int, error)
+	// Cursor returns the named subscriber's acknowledged position on
+	// the stream: the index of the next chunk it has yet to process.
+	// A subscriber that has never acknowledged is at 0.
+	Cursor(ctx context.Context, streamID, subscriber string) (int, error)
+	// Ack records that the named subscriber has processed the chunks
+	// before position. Positions only advance; acknowledging behind
+	// the stored cursor is a no-op.
+	Ack(ctx context.Context, streamID, subscriber string, position int) error
+}
+
+// A StreamFilter selects streams for [StreamManager.List]. The zero
+// value matches all streams.
+type StreamFilter struct {
+	// State restricts the result: "active" for streams whose producer
+	// has not finished, "done" for finished ones. Empty matches both.
+	State string
+}
+
+// A StreamInfo describes one durable stream for operators.
+type StreamInfo struct {
+	ID string `json:"id"`
+	// Chunks and Bytes are the stream's size.
+	Chunks int   `json:"chunks"`
+	Bytes  int64 `json:"bytes"`
+	Done   bool  `json:"done"`
+	// Error is the producer's failure message, if it failed.
+	Error string `json:"error,omitempty"`
+	// Created and Updated bound the stream's activity.
+	Created time.Time `json:"created"`
+	Updated time.Time `json:"updated"`
+}
+
+// A StreamState is one observation of a durable stream.
+type StreamState struct {
+	// Chunks are the chunks at and after the requested offset.
+	Chunks []json.RawMessage
+	// Done reports whether the producer has finished.
+	Done bool
+	// Result is the producer's final result, when Done.
+	Result json.RawMessage
+	// Error is the producer's failure message, when Done and it
+	// failed.
+	Error string
+}
+
+var (
+	streamManagerMu sync.RWMutex
+	streamManager   StreamManager
+)
+
+// SetStreamManager sets the manager used to persist durable streams.
+// Passing nil disables durable streaming.
+func SetStreamManager(m StreamManager) {
+	streamManagerMu.Lock()
+	defer streamManagerMu.Unlock()
+	streamManager = m
+}
+
+func currentStreamManager() StreamManager {
+	streamManagerMu.RLock()
+	defer streamManagerMu.RUnlock()
+	return streamManager
+}
+
+// StreamDurable starts the flow in the background and returns a stream
+// ID immediately. Chunks and the final result are persisted through
+// the manager set with [SetStreamManager], even if no client is
+// attached; clients read them with [SubscribeStream]. The flow runs
+// detached from ctx's cancellation.
+func (f *Flow[In, Out, Stream]) StreamDurable(ctx context.Context, input In) (string, error) {
+	m := currentStreamManager()
+	if m == nil {
+		return "", errors.New("durable streaming requires a StreamManager; call SetStreamManager first")
+	}
+	streamID := uuid.NewString()
+	bctx := context.WithoutCancel(ctx)
+	go func() {
+		cb := func(ctx context.Context, s Stream) error {
+			data, err := json.Marshal(s)
+			if err != nil {
+				return err
+			}
+			return m.Append(ctx, streamID, data)
+		}
+		out, err := f.run(bctx, input, cb)
+		var result json.RawMessage
+		errMsg := ""
+		if err != nil {
+			errMsg = err.Error()
+		} else if result, err = json.Marshal(out); err != nil {
+			errMsg = err.Error()
+		}
+		if err := m.Finish(bctx, streamID, result, errMsg); err != nil {
+			logger.FromContext(bctx).Error("finishing durable stream", "streamID", streamID, "err", err)
+		}
+	}()
+	return streamID, nil
+}
+
+// SubscribeStream reads the durable stream with the given ID, calling
+// cb (if non-nil) with each chunk in order, and returns the producer's
+// final result once the stream finishes. A subscriber that already
+// consumed some chunks can pass their count as offset to resume.
+// Subscribing to an ID that is never produced blocks until ctx is
+// done.
+func SubscribeStream(ctx context.Context, streamID string, offset int, cb func(context.Context, json.RawMessage) error) (json.RawMessage, error) {
+	m := currentStreamManager()
+	if m == nil {
+		return nil, errors.New("durable streaming requires a StreamManager; call SetStreamManager first")
+	}
+	for {
+		st, err := m.Read(ctx, streamID, offset)
+		if err != nil {
+			return nil, err
+		}
+		for _, chunk := range st.Chunks {
+			if cb != nil {
+				if err := cb(ctx, chunk); err != nil {
+					return nil, err
+				}
+			}
+			offset++
+		}
+		if st.Done {
+			if st.Error != "" {
+				return nil, errors.New(st.Error)
+			}
+			return st.Result, nil
+		}
+	}
+}
+
+// ProcessStream consumes the durable stream with the given ID on
+// behalf of a named subscriber, with at-least-once delivery: it
+// resumes from the subscriber's acknowledged cursor and acknowledges
+// each chunk only after cb has processed it. If cb fails or the
+// process crashes, the unacknowledged chunk is redelivered on the next
+// call, so reliable pipelines (say, a moderation worker) can be built
+// on durable streams rather than best-effort replay.
+func ProcessStream(ctx context.Context, streamID, subscriber string, cb func(context.Context, json.RawMessage) error) (json.RawMessage, error) {
+	m := currentStreamManager()
+	if m == nil {
+		return nil, errors.New("durable streaming requires a StreamManager; call SetStreamManager first")
+	}
+	offset, err := m.Cursor(ctx, streamID, subscriber)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		st, err := m.Read(ctx, streamID, offset)
+		if err != nil {
+			return nil, err
+		}
+		for _, chunk := range st.Chunks {
+			if err := cb(ctx, chunk); err != nil {
+				return nil, err
+			}
+			offset++
+			if err := m.Ack(ctx, streamID, subscriber, offset); err != nil {
+				return nil, err
+			}
+		}
+		if st.Done {
+			if st.Error != "" {
+				return nil, errors.New(st.Error)
+			}
+			return st.Result, nil
+		}
+	}
+}
+
+// ErrStreamTooLarge is returned by [StreamManager.Append] when a
+// stream has reached its configured size limit. The producer should
+// stop; the chunks stored so far remain readable.
+var ErrStreamTooLarge = errors.New("stream exceeds size limit")
+
+// StreamLimits bound how large a durable stream may grow and how much
+// one Read returns, so a runaway producer cannot exhaust the backing
+// store and readers of long streams fetch pages rather than the whole
+// stream at once. Persistent implementations should derive their
+// per-record batching from the store's own document limits.
+type StreamLimits struct {
+	// MaxChunks and MaxBytes cap a stream's stored size. Appends past
+	// either limit fail with [ErrStreamTooLarge]. Zero means no limit.
+	MaxChunks int
+	MaxBytes  int64
+	// PageChunks caps how many chunks one Read returns. Zero means no
+	// limit. [SubscribeStream] handles pagination transparently.
+	PageChunks int
+}
+
+// An InMemoryStreamManager is a StreamManager that keeps streams in
+// process memory. It is suitable for tests and single-process servers;
+// use a persistent implementation to let streams survive restarts.
+type InMemoryStreamManager struct {
+	limits  StreamLimits
+	mu      sync.Mutex
+	cond    *sync.Cond
+	streams map[string]*memStream
+}
+
+// SetLimits sets the manager's [StreamLimits] and returns the manager.
+// Call it before producing streams.
+func (m *InMemoryStreamManager) SetLimits(limits StreamLimits) *InMemoryStreamManager {
+	m.limits = limits
+	return m
+}
+
+type memStream struct {
+	chunks  []json.RawMessage
+	bytes   int64
+	done    bool
+	result  json.RawMessage
+	errMsg  string
+	created time.Time
+	updated time.Time
+	cursors map[string]int
+}
+
+// NewInMemoryStreamManager returns a new, empty InMemoryStreamManager.
+func NewInMemoryStreamManager() *InMemoryStreamManager {
+	m := &InMemoryStreamManager{streams: map[string]*memStream{}}
+	m.cond = sync.NewCond(&m.mu)
+	return m
+}
+
+func (m *InMemoryStreamManager) stream(id string) *memStream {
+	s := m.streams[id]
+	if s == nil {
+		now := time.Now()
+		s = &memStream{created: now, updated: now}
+		m.streams[id] = s
+	}
+	return s
+}
+
+// Append implements [StreamManager.Append].
+func (m *InMemoryStreamManager) Append(ctx context.Context, streamID string, chunk json.RawMessage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := m.stream(streamID)
+	if s.done {
+		return errors.New("append to finished stream")
+	}
+	if (m.limits.MaxChunks > 0 && len(s.chunks) >= m.limits.MaxChunks) ||
+		(m.limits.MaxBytes > 0 && s.bytes+int64(len(chunk)) > m.limits.MaxBytes) {
+		return fmt.Errorf("%w: stream %s has %d chunks, %d bytes", ErrStreamTooLarge, streamID, len(s.chunks), s.bytes)
+	}
+	s.chunks = append(s.chunks, chunk)
+	s.bytes += int64(len(chunk))
+	s.updated = time.Now()
+	m.cond.Broadcast()
+	return nil
+}
+
+// Finish implements [StreamManager.Finish].
+func (m *InMemoryStreamManager) Finish(ctx context.Context, streamID string, result json.RawMessage, errMsg string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := m.stream(streamID)
+	s.done = true
+	s.result = result
+	s.errMsg = errMsg
+	s.updated = time.Now()
+	m.cond.Broadcast()
+	return nil
+}
+
+// List implements [StreamManager.List].
+func (m *InMemoryStreamManager) List(ctx context.Context, filter *StreamFilter) ([]*StreamInfo, error) {
+	if filter == nil {
+		filter = &StreamFilter{}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var infos []*StreamInfo
+	for id, s := range m.streams {
+		if (filter.State == "active" && s.done) || (filter.State == "done" && !s.done) {
+			continue
+		}
+		infos = append(infos, &StreamInfo{
+			ID:      id,
+			Chunks:  len(s.chunks),
+			Bytes:   s.bytes,
+			Done:    s.done,
+			Error:   s.errMsg,
+			Created: s.created,
+			Updated: s.updated,
+		})
+	}
+	slices.SortFunc(infos, func(a, b *StreamInfo) int {
+		return b.Updated.Compare(a.Updated)
+	})
+	return infos, nil
+}
+
+// Cursor implements [StreamManager.Cursor].
+func (m *InMemoryStreamManager) Cursor(ctx context.Context, streamID, subscriber string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.stream(streamID).cursors[subscriber], nil
+}
+
+// Ack implements [StreamManager.Ack].
+func (m *InMemoryStreamManager) Ack(ctx context.Context, streamID, subscriber string, position int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := m.stream(streamID)
+	if position < 0 || position > len(s.chunks) {
+		return fmt.Errorf("ack position %d out of range for stream %s with %d chunks", position, streamID, len(s.chunks))
+	}
+	if s.cursors == nil {
+		s.cursors = map[string]int{}
+	}
+	if position > s.cursors[subscriber] {
+		s.cursors[subscriber] = position
+	}
+	return nil
+}
+
+// Purge implements [StreamManager.Purge].
+func (m *InMemoryStreamManager) Purge(ctx context.Context, olderThan time.Duration) (int, error) {
+	cutoff := time.Now().Add(-olderThan)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := 0
+	for id, s := range m.streams {
+		if s.done && !s.updated.After(cutoff) {
+			delete(m.streams, id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// Read implements [StreamManager.Read].
+func (m *InMemoryStreamManager) Read(ctx context.Context, streamID string, offset int) (*StreamState, error) {
+	// Wake waiters when ctx is done so they can return its error.
+	stop := context.AfterFunc(ctx, func() {
+		m.mu.Lock()
+		m.cond.Broadcast()
+		m.mu.Unlock()
+	})
+	defer stop()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := m.stream(streamID)
+	for len(s.chunks) <= offset && !s.done {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		m.cond.Wait()
+	}
+	end := len(s.chunks)
+	if m.limits.PageChunks > 0 && end-offset > m.limits.PageChunks {
+		end = offset + m.limits.PageChunks
+	}
+	st := &StreamState{Result: s.result, Error: s.errMsg}
+	if offset < end {
+		st.Chunks = slices.Clone(s.chunks[offset:end])
+	}
+	// Report Done only once the reader has reached the end, so paging
+	// subscribers keep reading until the last chunk.
+	st.Done = s.done && end == len(s.chunks)
+	return st, nil
+}
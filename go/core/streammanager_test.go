@@ -0,0 +1,319 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStreamDurable(t *testing.T) {
+	r, err := newRegistry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := defineFlow(r, "durableSpell", func(ctx context.Context, word string, cb func(context.Context, string) error) (int, error) {
+		for _, c := range word {
+			if err := cb(ctx, string(c)); err != nil {
+				return 0, err
+			}
+		}
+		return len(word), nil
+	})
+	SetStreamManager(NewInMemoryStreamManager())
+	defer SetStreamManager(nil)
+
+	id, err := f.StreamDurable(context.Background(), "hi")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id == "" {
+		t.Fatal("empty stream ID")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var chunks []string
+	result, err := SubscribeStream(ctx, id, 0, func(_ context.Context, chunk json.RawMessage) error {
+		var s string
+		if err := json.Unmarshal(chunk, &s); err != nil {
+			return err
+		}
+		chunks = append(chunks, s)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"h", "i"}; !slices.Equal(chunks, want) {
+		t.Errorf("chunks = %v, want %v", chunks, want)
+	}
+	if g, w := string(result), "2"; g != w {
+		t.Errorf("result = %q, want %q", g, w)
+	}
+
+	// A late subscriber replays the whole stream; one resuming at an
+	// offset gets only the tail.
+	tail := 0
+	if _, err := SubscribeStream(ctx, id, 1, func(context.Context, json.RawMessage) error {
+		tail++
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if tail != 1 {
+		t.Errorf("got %d chunks from offset 1, want 1", tail)
+	}
+}
+
+func TestStreamDurableError(t *testing.T) {
+	r, err := newRegistry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := defineFlow(r, "durableFail", func(ctx context.Context, _ string, _ func(context.Context, string) error) (int, error) {
+		return 0, errors.New("boom")
+	})
+	SetStreamManager(NewInMemoryStreamManager())
+	defer SetStreamManager(nil)
+
+	id, err := f.StreamDurable(context.Background(), "x")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := SubscribeStream(ctx, id, 0, nil); err == nil {
+		t.Error("SubscribeStream succeeded, want the producer's error")
+	}
+}
+
+func TestStreamAdminEndpoints(t *testing.T) {
+	m := NewInMemoryStreamManager()
+	SetStreamManager(m)
+	defer SetStreamManager(nil)
+	ctx := context.Background()
+	if err := m.Append(ctx, "active1", json.RawMessage(`"a"`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Finish(ctx, "done1", json.RawMessage(`1`), ""); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := newRegistry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(newDevServeMux(r))
+	defer srv.Close()
+
+	list := func(t *testing.T, state string) []*StreamInfo {
+		res, err := http.Get(srv.URL + "/api/streams?state=" + state)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+		if res.StatusCode != 200 {
+			t.Fatalf("got status %d, wanted 200", res.StatusCode)
+		}
+		infos, err := readJSON[[]*StreamInfo](res.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return infos
+	}
+
+	if got := list(t, ""); len(got) != 2 {
+		t.Errorf("listed %d streams, want 2", len(got))
+	}
+	if got := list(t, "active"); len(got) != 1 || got[0].ID != "active1" {
+		t.Errorf("active streams = %v, want [active1]", got)
+	}
+
+	// Force-expire the active stream, then purge everything finished.
+	req, err := http.NewRequest("DELETE", srv.URL+"/api/streams/active1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != 200 {
+		t.Fatalf("expire: got status %d, wanted 200", res.StatusCode)
+	}
+	if got := list(t, "active"); len(got) != 0 {
+		t.Errorf("active streams after expire = %v, want none", got)
+	}
+
+	res, err = http.Post(srv.URL+"/api/streams/purge", "application/json", strings.NewReader(`{}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	got, err := readJSON[map[string]int](res.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got["purged"] != 2 {
+		t.Errorf("purged %d streams, want 2", got["purged"])
+	}
+	if got := list(t, ""); len(got) != 0 {
+		t.Errorf("streams after purge = %v, want none", got)
+	}
+}
+
+func TestStreamLimits(t *testing.T) {
+	ctx := context.Background()
+	m := NewInMemoryStreamManager().SetLimits(StreamLimits{MaxChunks: 2, MaxBytes: 100})
+	if err := m.Append(ctx, "s", json.RawMessage(`"a"`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Append(ctx, "s", json.RawMessage(`"b"`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Append(ctx, "s", json.RawMessage(`"c"`)); !errors.Is(err, ErrStreamTooLarge) {
+		t.Errorf("third append error = %v, want ErrStreamTooLarge", err)
+	}
+
+	m = NewInMemoryStreamManager().SetLimits(StreamLimits{MaxBytes: 4})
+	if err := m.Append(ctx, "s", json.RawMessage(`"abcdef"`)); !errors.Is(err, ErrStreamTooLarge) {
+		t.Errorf("oversized append error = %v, want ErrStreamTooLarge", err)
+	}
+}
+
+func TestStreamReadPagination(t *testing.T) {
+	ctx := context.Background()
+	m := NewInMemoryStreamManager().SetLimits(StreamLimits{PageChunks: 2})
+	SetStreamManager(m)
+	defer SetStreamManager(nil)
+	for i := 0; i < 5; i++ {
+		if err := m.Append(ctx, "s", json.RawMessage(`"x"`)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := m.Finish(ctx, "s", json.RawMessage(`5`), ""); err != nil {
+		t.Fatal(err)
+	}
+
+	// One Read returns at most a page, not reporting Done mid-stream.
+	st, err := m.Read(ctx, "s", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(st.Chunks) != 2 || st.Done {
+		t.Errorf("Read page = %d chunks, done %t; want 2 chunks, not done", len(st.Chunks), st.Done)
+	}
+
+	// SubscribeStream pages through the whole stream.
+	n := 0
+	result, err := SubscribeStream(ctx, "s", 0, func(context.Context, json.RawMessage) error {
+		n++
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 5 {
+		t.Errorf("subscriber saw %d chunks, want 5", n)
+	}
+	if g, w := string(result), "5"; g != w {
+		t.Errorf("result = %q, want %q", g, w)
+	}
+}
+
+func TestProcessStream(t *testing.T) {
+	ctx := context.Background()
+	m := NewInMemoryStreamManager()
+	SetStreamManager(m)
+	defer SetStreamManager(nil)
+	for _, c := range []string{`"a"`, `"b"`, `"c"`} {
+		if err := m.Append(ctx, "s", json.RawMessage(c)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := m.Finish(ctx, "s", json.RawMessage(`3`), ""); err != nil {
+		t.Fatal(err)
+	}
+
+	// A failing consumer does not acknowledge the chunk it failed on.
+	var got []string
+	boom := errors.New("boom")
+	_, err := ProcessStream(ctx, "s", "worker", func(_ context.Context, chunk json.RawMessage) error {
+		if string(chunk) == `"b"` {
+			return boom
+		}
+		got = append(got, string(chunk))
+		return nil
+	})
+	if !errors.Is(err, boom) {
+		t.Fatalf("err = %v, want boom", err)
+	}
+
+	// A retry redelivers from the failed chunk, not from the start.
+	result, err := ProcessStream(ctx, "s", "worker", func(_ context.Context, chunk json.RawMessage) error {
+		got = append(got, string(chunk))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{`"a"`, `"b"`, `"c"`}; !slices.Equal(got, want) {
+		t.Errorf("processed %v, want %v", got, want)
+	}
+	if g, w := string(result), "3"; g != w {
+		t.Errorf("result = %q, want %q", g, w)
+	}
+
+	// Cursors are per subscriber: a second worker starts from 0.
+	if pos, err := m.Cursor(ctx, "s", "other"); err != nil || pos != 0 {
+		t.Errorf("Cursor(other) = %d, %v; want 0, nil", pos, err)
+	}
+	if pos, err := m.Cursor(ctx, "s", "worker"); err != nil || pos != 3 {
+		t.Errorf("Cursor(worker) = %d, %v; want 3, nil", pos, err)
+	}
+
+	// Acks only advance.
+	if err := m.Ack(ctx, "s", "worker", 1); err != nil {
+		t.Fatal(err)
+	}
+	if pos, _ := m.Cursor(ctx, "s", "worker"); pos != 3 {
+		t.Errorf("cursor regressed to %d", pos)
+	}
+	if err := m.Ack(ctx, "s", "worker", 4); err == nil {
+		t.Error("Ack past the end succeeded")
+	}
+}
+
+func TestStreamDurableRequiresManager(t *testing.T) {
+	r, err := newRegistry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := defineFlow(r, "durableNoMgr", func(ctx context.Context, _ string, _ func(context.Context, string) error) (int, error) {
+		return 0, nil
+	})
+	if _, err := f.StreamDurable(context.Background(), "x"); err == nil {
+		t.Error("StreamDurable succeeded without a StreamManager")
+	}
+}
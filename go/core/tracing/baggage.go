@@ -0,0 +1,63 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"go.opentelemetry.io/otel/baggage"
+)
+
+// This file propagates W3C baggage (user ID, tenant, experiment, ...)
+// from incoming HTTP requests into Genkit spans, and builds deep links
+// to trace viewers so errors in logs link straight to traces.
+
+// ExtractBaggage is HTTP middleware that parses the W3C "baggage"
+// request header into the request context. Baggage members are then
+// recorded on every Genkit span started under that context, so trace
+// queries can filter by user ID, tenant, experiment and the like.
+// A malformed header is ignored.
+//
+// Wrap the flow server mux with it:
+//
+//	mux := core.NewFlowServeMux(nil)
+//	http.ListenAndServe(addr, tracing.ExtractBaggage(mux))
+func ExtractBaggage(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if header := r.Header.Get("baggage"); header != "" {
+			if b, err := baggage.Parse(header); err == nil {
+				r = r.WithContext(baggage.ContextWithBaggage(r.Context(), b))
+			}
+		}
+		h.ServeHTTP(w, r)
+	})
+}
+
+// CloudTraceURL returns a deep link to the given trace in the Cloud
+// Trace explorer for a Google Cloud project. Include it in error logs
+// so support tooling can jump from a failure straight to its trace.
+func CloudTraceURL(projectID, traceID string) string {
+	return fmt.Sprintf("https://console.cloud.google.com/traces/list?project=%s&tid=%s",
+		url.QueryEscape(projectID), url.QueryEscape(traceID))
+}
+
+// JaegerTraceURL returns a deep link to the given trace in a Jaeger UI
+// served at baseURL (for example "http://localhost:16686").
+func JaegerTraceURL(baseURL, traceID string) string {
+	return strings.TrimSuffix(baseURL, "/") + "/trace/" + url.PathEscape(traceID)
+}
@@ -0,0 +1,45 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel/baggage"
+)
+
+func TestExtractBaggage(t *testing.T) {
+	var got string
+	h := ExtractBaggage(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = baggage.FromContext(r.Context()).Member("userId").Value()
+	}))
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("baggage", "userId=u123,tenant=acme")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	if got != "u123" {
+		t.Errorf("got %q, want %q", got, "u123")
+	}
+}
+
+func TestTraceURLs(t *testing.T) {
+	if got, want := CloudTraceURL("my-proj", "abc123"), "https://console.cloud.google.com/traces/list?project=my-proj&tid=abc123"; got != want {
+		t.Errorf("CloudTraceURL = %q, want %q", got, want)
+	}
+	if got, want := JaegerTraceURL("http://localhost:16686/", "abc123"), "http://localhost:16686/trace/abc123"; got != want {
+		t.Errorf("JaegerTraceURL = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tracing
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strconv"
+)
+
+// A SQLStore is a Store that writes traces to a SQL database. It uses
+// PostgreSQL placeholder syntax; bring your own driver, for example:
+//
+//	db, err := sql.Open("pgx", connString)
+//	...
+//	store, err := tracing.NewSQLStore(ctx, db)
+//
+// Register it with [core.RegisterTraceStore] to persist production
+// traces, which can then be loaded into the Dev UI or queried by ID
+// from support tooling.
+type SQLStore struct {
+	db *sql.DB
+}
+
+// NewSQLStore creates a SQLStore that writes traces to the given
+// database, creating the genkit_traces table if it does not exist.
+func NewSQLStore(ctx context.Context, db *sql.DB) (*SQLStore, error) {
+	const schema = `
+		CREATE TABLE IF NOT EXISTS genkit_traces (
+			id TEXT PRIMARY KEY,
+			data TEXT NOT NULL,
+			updated_at BIGINT NOT NULL
+		)`
+	if _, err := db.ExecContext(ctx, schema); err != nil {
+		return nil, fmt.Errorf("creating genkit_traces table: %w", err)
+	}
+	return &SQLStore{db: db}, nil
+}
+
+// Save implements [Store.Save].
+func (s *SQLStore) Save(ctx context.Context, id string, td *Data) error {
+	existing, err := s.Load(ctx, id)
+	if err == nil {
+		// Merge the existing spans with the incoming ones.
+		// Mutate existing because we know it has no other references.
+		for k, v := range td.Spans {
+			existing.Spans[k] = v
+		}
+		existing.TraceID = id
+		existing.DisplayName = td.DisplayName
+		existing.StartTime = td.StartTime
+		existing.EndTime = td.EndTime
+		td = existing
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	data, err := json.Marshal(td)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO genkit_traces (id, data, updated_at) VALUES ($1, $2, $3)
+		ON CONFLICT (id) DO UPDATE SET data = $2, updated_at = $3`,
+		id, string(data), int64(td.EndTime))
+	return err
+}
+
+// Load implements [Store.Load].
+func (s *SQLStore) Load(ctx context.Context, id string) (*Data, error) {
+	var td *Data
+	if err := s.load(ctx, id, &td); err != nil {
+		return nil, err
+	}
+	return td, nil
+}
+
+func (s *SQLStore) load(ctx context.Context, id string, p any) error {
+	var data string
+	err := s.db.QueryRowContext(ctx, `SELECT data FROM genkit_traces WHERE id = $1`, id).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("trace %q: %w", id, fs.ErrNotExist)
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal([]byte(data), p)
+}
+
+// List implements [Store.List].
+// The traces are returned newest first. The default limit is 10.
+func (s *SQLStore) List(ctx context.Context, q *Query) ([]*Data, string, error) {
+	const defaultLimit = 10
+	limit := 0
+	offset := 0
+	if q != nil {
+		limit = q.Limit
+		if q.ContinuationToken != "" {
+			// As in FileStore, a continuation token is an integer
+			// index in string form.
+			var err error
+			offset, err = strconv.Atoi(q.ContinuationToken)
+			if err != nil {
+				return nil, "", fmt.Errorf("%w: parsing continuation token: %v", ErrBadQuery, err)
+			}
+			if offset < 0 {
+				return nil, "", fmt.Errorf("%w: continuation token out of range", ErrBadQuery)
+			}
+		}
+	}
+	if limit == 0 {
+		limit = defaultLimit
+	}
+	if limit < 0 {
+		return nil, "", fmt.Errorf("%w: negative limit", ErrBadQuery)
+	}
+	// Fetch one extra row to learn whether there are more.
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT data FROM genkit_traces ORDER BY updated_at DESC, id LIMIT $1 OFFSET $2`,
+		limit+1, offset)
+	if err != nil {
+		return nil, "", err
+	}
+	defer rows.Close()
+	var tds []*Data
+	for rows.Next() {
+		var data string
+		if err := rows.Scan(&data); err != nil {
+			return nil, "", err
+		}
+		var td *Data
+		if err := json.Unmarshal([]byte(data), &td); err != nil {
+			return nil, "", err
+		}
+		tds = append(tds, td)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, "", err
+	}
+	ctoken := ""
+	if len(tds) > limit {
+		tds = tds[:limit]
+		ctoken = strconv.Itoa(offset + limit)
+	}
+	return tds, ctoken, nil
+}
+
+// LoadAny implements [Store.LoadAny].
+func (s *SQLStore) LoadAny(id string, p any) error {
+	return s.load(context.Background(), id, p)
+}
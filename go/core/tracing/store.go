@@ -17,7 +17,6 @@ package tracing
 import (
 	"context"
 	"errors"
-
 )
 
 // A Store stores trace information.
@@ -60,8 +59,8 @@ type Query struct {
 type Data struct {
 	TraceID     string               `json:"traceId"`
 	DisplayName string               `json:"displayName"`
-	StartTime   Milliseconds   `json:"startTime"`
-	EndTime     Milliseconds   `json:"endTime"`
+	StartTime   Milliseconds         `json:"startTime"`
+	EndTime     Milliseconds         `json:"endTime"`
 	Spans       map[string]*SpanData `json:"spans"`
 }
 
@@ -74,8 +73,8 @@ type SpanData struct {
 	SpanID                 string                 `json:"spanId"`
 	TraceID                string                 `json:"traceId,omitempty"`
 	ParentSpanID           string                 `json:"parentSpanId,omitempty"`
-	StartTime              Milliseconds     `json:"startTime"`
-	EndTime                Milliseconds     `json:"endTime"`
+	StartTime              Milliseconds           `json:"startTime"`
+	EndTime                Milliseconds           `json:"endTime"`
 	Attributes             map[string]any         `json:"attributes,omitempty"`
 	DisplayName            string                 `json:"displayName"`
 	Links                  []*Link                `json:"links,omitempty"`
@@ -97,7 +96,7 @@ type BoolValue struct {
 
 type TimeEvent struct {
 	Time       Milliseconds `json:"time,omitempty"`
-	Annotation Annotation         `json:"annotation,omitempty"`
+	Annotation Annotation   `json:"annotation,omitempty"`
 }
 
 type Annotation struct {
@@ -22,6 +22,7 @@ import (
 	"github.com/firebase/genkit/go/core/logger"
 	"github.com/firebase/genkit/go/internal"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/baggage"
 	"go.opentelemetry.io/otel/codes"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"go.opentelemetry.io/otel/trace"
@@ -103,12 +104,28 @@ func RunInNewSpan[I, O any](
 		parentPath = parentSpanMeta.Path
 	}
 	sm.Path = parentPath + "/" + name
+	// Record W3C baggage (see [ExtractBaggage]) on the span.
+	for _, m := range baggage.FromContext(ctx).Members() {
+		sm.SetAttr("baggage:"+m.Key(), m.Value())
+	}
 	var opts []trace.SpanStartOption
 	if spanType != "" {
 		opts = append(opts, trace.WithAttributes(attribute.String(spanTypeAttr, spanType)))
 	}
 	ctx, span := tstate.tracer.Start(ctx, name, opts...)
 	defer span.End()
+	// Attach a logger carrying the span's identifiers, so logs written
+	// through logger.FromContext inside the span are joinable with the
+	// trace, and scope it to the span's path so per-path level
+	// overrides apply.
+	spanLogger := logger.FromContext(ctx)
+	if sc := span.SpanContext(); sc.HasTraceID() {
+		spanLogger = spanLogger.With(
+			"trace_id", sc.TraceID().String(),
+			"span_id", sc.SpanID().String(),
+			"path", sm.Path)
+	}
+	ctx = logger.NewContext(ctx, logger.WithSpanPath(spanLogger, sm.Path))
 	// At the end, copy some of the spanMetadata to the OpenTelemetry span.
 	defer func() { span.SetAttributes(sm.attributes()...) }()
 	// Add the spanMetadata to the context, so the function can access it.
@@ -191,6 +208,18 @@ func SetCustomMetadataAttr(ctx context.Context, key, value string) {
 	spanMetaKey.FromContext(ctx).SetAttr(key, value)
 }
 
+// AddEvent records a named point-in-time event with the given
+// attributes on the current span. Use it to mark intra-step progress
+// (bytes downloaded, pages indexed) in long steps, so the trace viewer
+// shows where the time went inside a single span.
+func AddEvent(ctx context.Context, name string, attrs map[string]string) {
+	kvs := make([]attribute.KeyValue, 0, len(attrs))
+	for k, v := range attrs {
+		kvs = append(kvs, attribute.String(k, v))
+	}
+	trace.SpanFromContext(ctx).AddEvent(name, trace.WithAttributes(kvs...))
+}
+
 // SpanPath returns the path as recroding in the current span metadata.
 func SpanPath(ctx context.Context) string {
 	return spanMetaKey.FromContext(ctx).Path
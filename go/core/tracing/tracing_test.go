@@ -15,10 +15,15 @@
 package tracing
 
 import (
+	"bytes"
+	"context"
+	"log/slog"
 	"slices"
 	"strconv"
+	"strings"
 	"testing"
 
+	"github.com/firebase/genkit/go/core/logger"
 	"go.opentelemetry.io/otel/attribute"
 )
 
@@ -51,3 +56,23 @@ func TestSpanMetadata(t *testing.T) {
 		t.Errorf("\ngot  %v\nwant %v", got, want)
 	}
 }
+
+func TestSpanLoggerCorrelation(t *testing.T) {
+	var buf bytes.Buffer
+	ctx := logger.NewContext(context.Background(),
+		slog.New(slog.NewTextHandler(&buf, nil)))
+	_, err := RunInNewSpan(ctx, NewState(), "corr", "flow", false, 0,
+		func(ctx context.Context, _ int) (int, error) {
+			logger.FromContext(ctx).Info("inside span")
+			return 0, nil
+		})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, attr := range []string{"trace_id=", "span_id=", "path=/corr"} {
+		if !strings.Contains(out, attr) {
+			t.Errorf("log output missing %q:\n%s", attr, out)
+		}
+	}
+}
@@ -15,32 +15,74 @@
 package core
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"strings"
+	"sync"
 
 	"github.com/invopop/jsonschema"
 	"github.com/xeipuuv/gojsonschema"
 )
 
+// validateBufPool pools the buffers used to marshal values for
+// validation, which happens twice per action call on the hot path.
+var validateBufPool = sync.Pool{
+	New: func() any { return new(bytes.Buffer) },
+}
+
 // validateValue will validate any value against the expected schema.
 // It will return an error if it doesn't match the schema, otherwise it will return nil.
 func validateValue(data any, schema *jsonschema.Schema) error {
-	dataBytes, err := json.Marshal(data)
-	if err != nil {
+	buf := validateBufPool.Get().(*bytes.Buffer)
+	defer validateBufPool.Put(buf)
+	buf.Reset()
+	if err := json.NewEncoder(buf).Encode(data); err != nil {
 		return fmt.Errorf("data is not a valid JSON type: %w", err)
 	}
-	return validateJSON(dataBytes, schema)
+	return validateJSON(buf.Bytes(), schema)
+}
+
+// compiledSchemas caches compiled validators, keyed by schema pointer:
+// action schemas are built once at definition time and not mutated
+// afterwards, so identity is a safe key.
+var compiledSchemas sync.Map // *jsonschema.Schema -> *gojsonschema.Schema
+
+// compiledSchema returns the compiled validator for schema, compiling
+// it on first use.
+func compiledSchema(schema *jsonschema.Schema) (*gojsonschema.Schema, error) {
+	if cs, ok := compiledSchemas.Load(schema); ok {
+		return cs.(*gojsonschema.Schema), nil
+	}
+	schemaBytes, err := schema.MarshalJSON()
+	if err != nil {
+		return nil, fmt.Errorf("expected schema is not valid: %w", err)
+	}
+	cs, err := gojsonschema.NewSchema(gojsonschema.NewBytesLoader(schemaBytes))
+	if err != nil {
+		return nil, fmt.Errorf("expected schema is not valid: %w", err)
+	}
+	compiledSchemas.Store(schema, cs)
+	return cs, nil
 }
 
 // validateJSON will validate JSON against the expected schema.
 // It will return an error if it doesn't match the schema, otherwise it will return nil.
 func validateJSON(dataBytes json.RawMessage, schema *jsonschema.Schema) error {
-	schemaBytes, err := schema.MarshalJSON()
+	cs, err := compiledSchema(schema)
+	if err != nil {
+		return err
+	}
+	var data any
+	// Do this check separately from below to get a better error message.
+	if err := json.Unmarshal(dataBytes, &data); err != nil {
+		return fmt.Errorf("data is not valid JSON: %w", err)
+	}
+	result, err := cs.Validate(gojsonschema.NewBytesLoader(dataBytes))
 	if err != nil {
-		return fmt.Errorf("expected schema is not valid: %w", err)
+		return fmt.Errorf("failed to validate data against expected schema: %w", err)
 	}
-	return ValidateRaw(dataBytes, schemaBytes)
+	return validationError(result)
 }
 
 // ValidateRaw will validate JSON data against the JSON schema.
@@ -59,14 +101,18 @@ func ValidateRaw(dataBytes json.RawMessage, schemaBytes json.RawMessage) error {
 	if err != nil {
 		return fmt.Errorf("failed to validate data against expected schema: %w", err)
 	}
+	return validationError(result)
+}
 
-	if !result.Valid() {
-		var errors []string
-		for _, err := range result.Errors() {
-			errors = append(errors, fmt.Sprintf("- %s", err))
-		}
-		return fmt.Errorf("data did not match expected schema:\n%s", strings.Join(errors, "\n"))
+// validationError converts a failed validation result into an error
+// listing every violation, or nil if the result is valid.
+func validationError(result *gojsonschema.Result) error {
+	if result.Valid() {
+		return nil
 	}
-
-	return nil
+	var errors []string
+	for _, err := range result.Errors() {
+		errors = append(errors, fmt.Sprintf("- %s", err))
+	}
+	return fmt.Errorf("data did not match expected schema:\n%s", strings.Join(errors, "\n"))
 }
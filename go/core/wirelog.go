@@ -0,0 +1,208 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/firebase/genkit/go/core/tracing"
+)
+
+// This file implements wire logging for provider debugging. Genkit's
+// abstraction hides the raw provider HTTP exchange; when a provider
+// misbehaves, install a WireLogTransport in the plugin's HTTP client to
+// capture a sampled set of raw requests and responses.
+
+// maxWireLogBody bounds how much of a body is captured per message.
+const maxWireLogBody = 64 << 10 // 64 KiB
+
+// redactedHeaders are request headers whose values carry secrets.
+var redactedHeaders = []string{
+	"Authorization",
+	"Api-Key",
+	"X-Api-Key",
+	"X-Goog-Api-Key",
+	"Cookie",
+	"Proxy-Authorization",
+}
+
+// redactedQueryParams are query parameters whose values carry secrets,
+// such as the key parameter Google APIs accept in place of a header.
+var redactedQueryParams = []string{
+	"key",
+	"api_key",
+	"apikey",
+	"token",
+	"access_token",
+}
+
+// A WireLogTransport is an [http.RoundTripper] that logs raw provider
+// requests and responses, with secret-bearing headers redacted and
+// bodies truncated to 64 KiB, and records the exchange as an event on
+// the current span. Install it in the HTTP client passed to a plugin:
+//
+//	googleai.SetHTTPClient(&http.Client{
+//		Transport: &core.WireLogTransport{SampleRate: 0.01},
+//	})
+type WireLogTransport struct {
+	// Base performs the actual round trip. If nil,
+	// http.DefaultTransport is used.
+	Base http.RoundTripper
+	// Sink receives the wire logs. If nil, slog.Default() is used.
+	Sink *slog.Logger
+	// SampleRate is the fraction of calls to log, in [0, 1].
+	// Zero or negative logs every call.
+	SampleRate float64
+}
+
+// RoundTrip implements [http.RoundTripper].
+func (t *WireLogTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if t.SampleRate > 0 && rand.Float64() >= t.SampleRate {
+		return base.RoundTrip(req)
+	}
+	sink := t.Sink
+	if sink == nil {
+		sink = slog.Default()
+	}
+	logURL := redactURL(req.URL)
+
+	var reqBody string
+	if req.Body != nil {
+		data, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Body = io.NopCloser(bytes.NewReader(data))
+		reqBody = truncateBody(data)
+	}
+	sink.Debug("wire request",
+		"method", req.Method,
+		"url", logURL,
+		"headers", redactHeaders(req.Header),
+		"body", reqBody)
+
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		sink.Debug("wire error", "method", req.Method, "url", logURL, "err", err)
+		return nil, err
+	}
+	tracing.AddEvent(req.Context(), "wire", map[string]string{
+		"method": req.Method,
+		"url":    logURL,
+		"status": resp.Status,
+	})
+	// Log the response body as it is consumed, so streaming responses
+	// are not buffered up front.
+	resp.Body = &wireLogBody{
+		body: resp.Body,
+		log: func(body string) {
+			sink.Debug("wire response",
+				"method", req.Method,
+				"url", logURL,
+				"status", resp.Status,
+				"headers", redactHeaders(resp.Header),
+				"body", body)
+		},
+	}
+	return resp, nil
+}
+
+// redactURL returns u as a string for logging, with the values of
+// secret-bearing query parameters replaced.
+func redactURL(u *url.URL) string {
+	q := u.Query()
+	changed := false
+	for k := range q {
+		for _, secret := range redactedQueryParams {
+			if strings.EqualFold(k, secret) {
+				q[k] = []string{"REDACTED"}
+				changed = true
+				break
+			}
+		}
+	}
+	if !changed {
+		return u.String()
+	}
+	red := *u
+	red.RawQuery = q.Encode()
+	return red.String()
+}
+
+// redactHeaders copies headers, replacing secret-bearing values.
+func redactHeaders(h http.Header) http.Header {
+	red := make(http.Header, len(h))
+	for k, vs := range h {
+		red[k] = vs
+		for _, secret := range redactedHeaders {
+			if strings.EqualFold(k, secret) {
+				red[k] = []string{"REDACTED"}
+				break
+			}
+		}
+	}
+	return red
+}
+
+func truncateBody(data []byte) string {
+	if len(data) > maxWireLogBody {
+		return string(data[:maxWireLogBody]) + "...(truncated)"
+	}
+	return string(data)
+}
+
+// A wireLogBody tees a response body into a bounded buffer and logs it
+// once the body is fully read or closed.
+type wireLogBody struct {
+	body   io.ReadCloser
+	buf    bytes.Buffer
+	log    func(body string)
+	logged bool
+}
+
+func (b *wireLogBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+	if n > 0 && b.buf.Len() < maxWireLogBody {
+		b.buf.Write(p[:n])
+	}
+	if err == io.EOF {
+		b.emit()
+	}
+	return n, err
+}
+
+func (b *wireLogBody) Close() error {
+	b.emit()
+	return b.body.Close()
+}
+
+func (b *wireLogBody) emit() {
+	if b.logged {
+		return
+	}
+	b.logged = true
+	b.log(truncateBody(b.buf.Bytes()))
+}
@@ -0,0 +1,73 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package core
+
+import (
+	"bytes"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWireLogTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"answer": 42}`)
+	}))
+	defer srv.Close()
+
+	var buf bytes.Buffer
+	sink := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	client := &http.Client{Transport: &WireLogTransport{Sink: sink}}
+
+	req, err := http.NewRequest("POST", srv.URL+"?key=querysecret&alt=json", strings.NewReader(`{"q": "hi"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer topsecret")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != `{"answer": 42}` {
+		t.Errorf("body = %q, transport corrupted the response", body)
+	}
+
+	logs := buf.String()
+	if strings.Contains(logs, "topsecret") {
+		t.Error("wire log contains an unredacted secret")
+	}
+	if !strings.Contains(logs, "REDACTED") {
+		t.Error("wire log did not redact the Authorization header")
+	}
+	if strings.Contains(logs, "querysecret") {
+		t.Error("wire log contains an unredacted query parameter secret")
+	}
+	if !strings.Contains(logs, "alt=json") {
+		t.Error("wire log dropped a non-secret query parameter")
+	}
+	for _, want := range []string{`{\"q\": \"hi\"}`, `{\"answer\": 42}`} {
+		if !strings.Contains(logs, want) {
+			t.Errorf("wire log does not contain %s:\n%s", want, logs)
+		}
+	}
+}
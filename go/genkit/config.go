@@ -0,0 +1,194 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genkit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"gopkg.in/yaml.v3"
+)
+
+// This file implements an optional configuration file, genkit.yaml (or
+// genkit.json), parsed at Init. It lets operators tune a deployment —
+// profile, server addresses, default model, prompt directory, plugin
+// and telemetry settings — without code changes. Values in the file
+// may reference environment variables as ${VAR}. Options passed in
+// code override the file.
+
+// A Config mirrors the genkit.yaml file.
+type Config struct {
+	// Profile names the configuration profile to apply; see
+	// [ProfileNamed].
+	Profile string `yaml:"profile" json:"profile"`
+	// FlowAddr is the flow server address ("-" for none).
+	FlowAddr string `yaml:"flowAddr" json:"flowAddr"`
+	// Flows lists the flows to serve; empty means all.
+	Flows []string `yaml:"flows" json:"flows"`
+	// ShutdownTimeout is the drain timeout on shutdown, as a Go
+	// duration string such as "30s".
+	ShutdownTimeout string `yaml:"shutdownTimeout" json:"shutdownTimeout"`
+	// DefaultModel names the default model as "provider/name"; see
+	// [Config.DefaultModelRef].
+	DefaultModel string `yaml:"defaultModel" json:"defaultModel"`
+	// PromptDir is the directory prompt files are loaded from.
+	PromptDir string `yaml:"promptDir" json:"promptDir"`
+	// Plugins holds per-plugin settings, keyed by plugin name. The
+	// settings are opaque to genkit; plugins read them with
+	// [Config.PluginSettings].
+	Plugins map[string]map[string]string `yaml:"plugins" json:"plugins"`
+	// Telemetry holds telemetry exporter settings, opaque to genkit.
+	Telemetry map[string]string `yaml:"telemetry" json:"telemetry"`
+	// RateLimits maps action keys to a maximum number of requests per
+	// minute, for deployments that enforce limits at the edge.
+	RateLimits map[string]int `yaml:"rateLimits" json:"rateLimits"`
+}
+
+// configFiles are the file names LoadConfig searches, in order.
+var configFiles = []string{"genkit.yaml", "genkit.yml", "genkit.json"}
+
+// currentConfig is the config applied by [InitWithConfig].
+var currentConfig *Config
+
+// LoadConfig reads a config file. If path is empty it is taken from
+// the GENKIT_CONFIG environment variable, then from genkit.yaml,
+// genkit.yml or genkit.json in the current directory; if none exists,
+// LoadConfig returns (nil, nil). Occurrences of ${VAR} anywhere in the
+// file are replaced with the value of the environment variable VAR
+// before parsing.
+func LoadConfig(path string) (*Config, error) {
+	if path == "" {
+		path = os.Getenv("GENKIT_CONFIG")
+	}
+	if path == "" {
+		for _, f := range configFiles {
+			if _, err := os.Stat(f); err == nil {
+				path = f
+				break
+			}
+		}
+	}
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("genkit: reading config: %w", err)
+	}
+	expanded := os.Expand(string(data), func(name string) string {
+		return os.Getenv(name)
+	})
+	var cfg Config
+	if strings.HasSuffix(path, ".json") {
+		err = json.Unmarshal([]byte(expanded), &cfg)
+	} else {
+		err = yaml.Unmarshal([]byte(expanded), &cfg)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("genkit: parsing config %s: %w", path, err)
+	}
+	return &cfg, nil
+}
+
+// InitWithConfig is [Init] driven by a config file. If cfg is nil it
+// is loaded with [LoadConfig]; a missing file behaves like an empty
+// one. The config's profile is applied first, then the config's
+// settings, then opts, so options set in code override the file.
+func InitWithConfig(ctx context.Context, cfg *Config, opts *Options) error {
+	if cfg == nil {
+		var err error
+		cfg, err = LoadConfig("")
+		if err != nil {
+			return err
+		}
+		if cfg == nil {
+			cfg = &Config{}
+		}
+	}
+	merged, err := cfg.apply(opts)
+	if err != nil {
+		return err
+	}
+	currentConfig = cfg
+	if cfg.Profile != "" {
+		profile, err := ProfileNamed(cfg.Profile)
+		if err != nil {
+			return err
+		}
+		return InitWithProfile(ctx, profile, merged)
+	}
+	return Init(ctx, merged)
+}
+
+// CurrentConfig returns the config applied by [InitWithConfig], or nil
+// if none was.
+func CurrentConfig() *Config {
+	return currentConfig
+}
+
+// PluginSettings returns the config file settings for the named
+// plugin, or nil if there are none.
+func (c *Config) PluginSettings(name string) map[string]string {
+	if c == nil {
+		return nil
+	}
+	return c.Plugins[name]
+}
+
+// DefaultModelRef resolves the config's default model against the
+// registry. It errors if no default model is configured or the named
+// model is not registered.
+func (c *Config) DefaultModelRef() (*ai.Model, error) {
+	if c == nil || c.DefaultModel == "" {
+		return nil, errors.New("genkit: no default model configured")
+	}
+	provider, name, ok := strings.Cut(c.DefaultModel, "/")
+	if !ok {
+		return nil, fmt.Errorf("genkit: default model %q is not of the form provider/name", c.DefaultModel)
+	}
+	m := ai.LookupModel(provider, name)
+	if m == nil {
+		return nil, fmt.Errorf("genkit: default model %q is not registered", c.DefaultModel)
+	}
+	return m, nil
+}
+
+// apply fills zero fields of opts from the config.
+func (c *Config) apply(opts *Options) (*Options, error) {
+	merged := Options{}
+	if opts != nil {
+		merged = *opts
+	}
+	if merged.FlowAddr == "" {
+		merged.FlowAddr = c.FlowAddr
+	}
+	if merged.Flows == nil {
+		merged.Flows = c.Flows
+	}
+	if merged.ShutdownTimeout == 0 && c.ShutdownTimeout != "" {
+		d, err := time.ParseDuration(c.ShutdownTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("genkit: config shutdownTimeout: %w", err)
+		}
+		merged.ShutdownTimeout = d
+	}
+	return &merged, nil
+}
@@ -0,0 +1,102 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genkit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const testConfig = `
+profile: staging
+flowAddr: ":4400"
+flows:
+  - greet
+shutdownTimeout: 45s
+defaultModel: googleai/gemini-1.5-flash
+promptDir: prompts
+plugins:
+  elasticsearch:
+    address: https://es.internal:9200
+    apiKey: ${TEST_ES_KEY}
+rateLimits:
+  /flow/greet: 120
+`
+
+func TestLoadConfig(t *testing.T) {
+	t.Setenv("TEST_ES_KEY", "sekrit")
+	path := filepath.Join(t.TempDir(), "genkit.yaml")
+	if err := os.WriteFile(path, []byte(testConfig), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Profile != "staging" || cfg.FlowAddr != ":4400" || cfg.PromptDir != "prompts" {
+		t.Errorf("cfg = %+v", cfg)
+	}
+	// Environment variables are interpolated.
+	if got := cfg.PluginSettings("elasticsearch")["apiKey"]; got != "sekrit" {
+		t.Errorf("apiKey = %q, want sekrit", got)
+	}
+	if cfg.RateLimits["/flow/greet"] != 120 {
+		t.Errorf("rate limits = %v", cfg.RateLimits)
+	}
+
+	opts, err := cfg.apply(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.ShutdownTimeout != 45*time.Second || len(opts.Flows) != 1 {
+		t.Errorf("opts = %+v", opts)
+	}
+	// Options set in code override the file.
+	opts, err = cfg.apply(&Options{FlowAddr: "-"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.FlowAddr != "-" {
+		t.Errorf("FlowAddr = %q, want -", opts.FlowAddr)
+	}
+}
+
+func TestLoadConfigMissing(t *testing.T) {
+	t.Setenv("GENKIT_CONFIG", "")
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	cfg, err := LoadConfig("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg != nil {
+		t.Errorf("cfg = %+v, want nil with no config file", cfg)
+	}
+}
+
+func TestConfigBadDuration(t *testing.T) {
+	cfg := &Config{ShutdownTimeout: "soon"}
+	if _, err := cfg.apply(nil); err == nil {
+		t.Error("expected an error for a bad duration")
+	}
+}
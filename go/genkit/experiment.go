@@ -0,0 +1,174 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genkit
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"sync"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/core/tracing"
+	"github.com/firebase/genkit/go/internal"
+)
+
+// This file implements experiments: deterministic traffic splitting
+// across variants (different prompts, models or flows) with the
+// assigned arm recorded on traces and hooks for logging outcome
+// metrics, so A/B results can be analyzed downstream.
+
+// An ExperimentArm is one variant of an [Experiment].
+type ExperimentArm[In, Out any] struct {
+	// Name identifies the arm, e.g. "control" or "gemini-1.5-pro".
+	Name string
+	// Weight is the arm's relative share of traffic. Zero counts as 1.
+	Weight int
+	// Run is the variant itself.
+	Run func(ctx context.Context, input In) (Out, error)
+}
+
+// An Experiment deterministically splits requests across its arms.
+type Experiment[In, Out any] struct {
+	name string
+	arms []*ExperimentArm[In, Out]
+
+	mu    sync.Mutex
+	hooks []func(ctx context.Context, arm, metric string, value float64)
+}
+
+// DefineExperiment creates an experiment splitting traffic across
+// arms. It panics if no arms are given or two arms share a name.
+func DefineExperiment[In, Out any](name string, arms ...*ExperimentArm[In, Out]) *Experiment[In, Out] {
+	if len(arms) == 0 {
+		panic(fmt.Sprintf("genkit.DefineExperiment: experiment %q has no arms", name))
+	}
+	seen := map[string]bool{}
+	for _, arm := range arms {
+		if seen[arm.Name] {
+			panic(fmt.Sprintf("genkit.DefineExperiment: experiment %q has two arms named %q", name, arm.Name))
+		}
+		seen[arm.Name] = true
+	}
+	return &Experiment[In, Out]{name: name, arms: arms}
+}
+
+// Name returns the experiment's name.
+func (e *Experiment[In, Out]) Name() string { return e.name }
+
+// OnOutcome registers fn to be called for every outcome logged with
+// [LogOutcome] by a request assigned to this experiment.
+func (e *Experiment[In, Out]) OnOutcome(fn func(ctx context.Context, arm, metric string, value float64)) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.hooks = append(e.hooks, fn)
+}
+
+// Run assigns the request to an arm and runs it. Assignment is
+// deterministic in the experiment key — set with [WithExperimentKey],
+// or the user ID from [ai.WithUserID] — so the same user or session
+// always sees the same variant; requests with no key are assigned at
+// random. The experiment and arm names are recorded on the trace.
+func (e *Experiment[In, Out]) Run(ctx context.Context, input In) (Out, error) {
+	arm := e.assign(experimentKeyFromContext(ctx))
+	tracing.SetCustomMetadataAttr(ctx, "experiment", e.name)
+	tracing.SetCustomMetadataAttr(ctx, "experimentArm", arm.Name)
+	ctx = assignmentKey.NewContext(ctx, &armAssignment{
+		experiment: e.name,
+		arm:        arm.Name,
+		log:        e.logOutcome,
+	})
+	return arm.Run(ctx, input)
+}
+
+// assign picks the arm for key: the weighted bucket of the key's hash,
+// or a weighted random bucket when key is empty.
+func (e *Experiment[In, Out]) assign(key string) *ExperimentArm[In, Out] {
+	total := 0
+	for _, arm := range e.arms {
+		total += max(arm.Weight, 1)
+	}
+	var bucket int
+	if key == "" {
+		bucket = rand.Intn(total)
+	} else {
+		h := fnv.New32a()
+		fmt.Fprintf(h, "%s|%s", e.name, key)
+		bucket = int(h.Sum32() % uint32(total))
+	}
+	for _, arm := range e.arms {
+		bucket -= max(arm.Weight, 1)
+		if bucket < 0 {
+			return arm
+		}
+	}
+	return e.arms[len(e.arms)-1]
+}
+
+func (e *Experiment[In, Out]) logOutcome(ctx context.Context, arm, metric string, value float64) {
+	e.mu.Lock()
+	hooks := e.hooks
+	e.mu.Unlock()
+	for _, fn := range hooks {
+		fn(ctx, arm, metric, value)
+	}
+}
+
+// An armAssignment records, in the context, which arm a request was
+// assigned, so [LogOutcome] needs no reference to the experiment.
+type armAssignment struct {
+	experiment, arm string
+	log             func(ctx context.Context, arm, metric string, value float64)
+}
+
+var (
+	assignmentKey    = internal.NewContextKey[*armAssignment]()
+	experimentCtxKey = internal.NewContextKey[string]()
+)
+
+// WithExperimentKey returns a context carrying the key experiments use
+// for deterministic arm assignment, typically a session ID. Without
+// it, experiments fall back to the user ID set with [ai.WithUserID].
+func WithExperimentKey(ctx context.Context, key string) context.Context {
+	return experimentCtxKey.NewContext(ctx, key)
+}
+
+func experimentKeyFromContext(ctx context.Context) string {
+	if key := experimentCtxKey.FromContext(ctx); key != "" {
+		return key
+	}
+	uid, _ := ai.UserIDFromContext(ctx)
+	return uid
+}
+
+// LogOutcome records an outcome metric (say, "thumbsUp" or
+// "latencyMs") for the experiment arm this request was assigned. The
+// outcome is added to the trace as an event and passed to hooks
+// registered with [Experiment.OnOutcome]. It does nothing if the
+// request is not part of an experiment.
+func LogOutcome(ctx context.Context, metric string, value float64) {
+	a := assignmentKey.FromContext(ctx)
+	if a == nil {
+		return
+	}
+	tracing.AddEvent(ctx, "experiment-outcome", map[string]string{
+		"experiment": a.experiment,
+		"arm":        a.arm,
+		"metric":     metric,
+		"value":      fmt.Sprintf("%g", value),
+	})
+	a.log(ctx, a.arm, metric, value)
+}
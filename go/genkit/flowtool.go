@@ -0,0 +1,107 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genkit
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/core"
+	"github.com/invopop/jsonschema"
+)
+
+// FlowAsTool registers a tool that runs the flow, so a model can
+// delegate to it. The tool reuses the flow's name and input/output
+// schemas, and the nested flow execution is traced under the tool's
+// span. Flows whose input is not a JSON object are wrapped in an
+// {"input": ...} object, since tool arguments are always objects.
+func FlowAsTool[In, Out, Stream any](flow *core.Flow[In, Out, Stream], description string) string {
+	inputSchema, wrapped := objectSchema(schemaMap(flow.InputSchema()))
+	ai.DefineTool(&ai.ToolDefinition{
+		Name:         flow.Name(),
+		Description:  description,
+		InputSchema:  inputSchema,
+		OutputSchema: schemaMap(flow.OutputSchema()),
+	}, map[string]any{
+		"flow": flow.Name(),
+	}, func(ctx context.Context, input map[string]any) (map[string]any, error) {
+		var raw any = input
+		if wrapped {
+			raw = input["input"]
+		}
+		data, err := json.Marshal(raw)
+		if err != nil {
+			return nil, err
+		}
+		var in In
+		if err := json.Unmarshal(data, &in); err != nil {
+			return nil, fmt.Errorf("invalid input for flow %q: %w", flow.Name(), err)
+		}
+		out, err := flow.Run(ctx, in)
+		if err != nil {
+			return nil, err
+		}
+		return toToolOutput(out)
+	})
+	return flow.Name()
+}
+
+// schemaMap converts a schema to the map form used by tool definitions.
+func schemaMap(s *jsonschema.Schema) map[string]any {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil
+	}
+	var m map[string]any
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil
+	}
+	return m
+}
+
+// objectSchema returns s if it describes an object, or s wrapped as the
+// "input" property of an object. The second result reports whether it
+// wrapped.
+func objectSchema(s map[string]any) (map[string]any, bool) {
+	if s["type"] == "object" {
+		return s, false
+	}
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"input": s,
+		},
+		"required": []any{"input"},
+	}, true
+}
+
+// toToolOutput converts a flow result to a tool result, wrapping
+// non-object values in {"result": ...}.
+func toToolOutput(out any) (map[string]any, error) {
+	data, err := json.Marshal(out)
+	if err != nil {
+		return nil, err
+	}
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return nil, err
+	}
+	if m, ok := v.(map[string]any); ok {
+		return m, nil
+	}
+	return map[string]any{"result": v}, nil
+}
@@ -0,0 +1,57 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genkit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+)
+
+func TestFlowAsTool(t *testing.T) {
+	type input struct {
+		A int `json:"a"`
+		B int `json:"b"`
+	}
+	flow := DefineFlow("add", func(ctx context.Context, in input) (int, error) {
+		return in.A + in.B, nil
+	})
+	name := FlowAsTool(flow, "Adds two numbers.")
+	if name != "add" {
+		t.Fatalf("tool name = %q, want add", name)
+	}
+	out, err := ai.RunTool(context.Background(), "add", map[string]any{"a": 2.0, "b": 3.0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := out["result"]; got != float64(5) {
+		t.Errorf("result = %v (%T), want 5", got, got)
+	}
+}
+
+func TestFlowAsToolWrapsScalarInput(t *testing.T) {
+	flow := DefineFlow("double", func(ctx context.Context, n int) (int, error) {
+		return 2 * n, nil
+	})
+	FlowAsTool(flow, "Doubles a number.")
+	out, err := ai.RunTool(context.Background(), "double", map[string]any{"input": 4.0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := out["result"]; got != float64(8) {
+		t.Errorf("result = %v, want 8", got)
+	}
+}
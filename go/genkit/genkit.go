@@ -18,8 +18,11 @@ package genkit
 import (
 	"context"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/firebase/genkit/go/core"
+	"github.com/firebase/genkit/go/core/tracing"
 )
 
 // Options are options to [Init].
@@ -31,6 +34,9 @@ type Options struct {
 	// The names of flows to serve.
 	// If empty, all registered flows are served.
 	Flows []string
+	// How long to allow in-flight requests to drain during shutdown
+	// before giving up. If zero, 5 seconds.
+	ShutdownTimeout time.Duration
 }
 
 // Init initializes Genkit.
@@ -53,6 +59,16 @@ func Init(ctx context.Context, opts *Options) error {
 	return core.InternalInit(ctx, (*core.Options)(opts))
 }
 
+// Shutdown gracefully shuts down the servers started by [Init]: they
+// stop accepting new requests, and in-flight flows are allowed to drain
+// up to the configured [Options.ShutdownTimeout]. Shutdown waits until
+// the shutdown completes, or until ctx is done, and then returns.
+// Use it to stop Genkit from a larger server's own shutdown path
+// instead of sending the process a signal.
+func Shutdown(ctx context.Context) error {
+	return core.InternalShutdown(ctx)
+}
+
 // DefineFlow creates a Flow that runs fn, and registers it as an action.
 //
 // fn takes an input of type In and returns an output of type Out.
@@ -93,6 +109,59 @@ func Run[Out any](ctx context.Context, name string, f func() (Out, error)) (Out,
 	return core.InternalRun(ctx, name, f)
 }
 
+// RunCached is like [Run], except that the step's result is memoized under
+// the given key rather than under the step's position in the flow.
+// If the flow is retried, a completed step with the same key is not executed
+// again, even if earlier steps took a different path. Use this for expensive
+// work such as embeddings or external API calls whose result is determined
+// by the key.
+// It returns an error if no flow is active.
+func RunCached[Out any](ctx context.Context, name, key string, f func() (Out, error)) (Out, error) {
+	return core.InternalRunCached(ctx, name, key, f)
+}
+
+// RegisterAtRuntime runs register, which may define new actions (models,
+// flows, tools, ...), after [Init] has already been called. Use it in
+// long-running servers to add a newly configured provider — say, a
+// customer bringing their own API key — without a restart. The new
+// actions appear in the registry and the Dev UI like any others.
+func RegisterAtRuntime(register func()) {
+	core.InternalRegisterAtRuntime(register)
+}
+
+// UnregisterProvider removes all of a provider's actions from the
+// registry, so a de-configured provider disappears from the Dev UI and
+// lookups for its actions fail. In-flight calls are unaffected.
+func UnregisterProvider(provider string) {
+	core.InternalUnregisterProvider(provider)
+}
+
+// Progress records a progress marker for the current step as a span
+// event, so long-running steps (downloading a large PDF, indexing a
+// corpus) show their progress in the trace viewer. msg describes what
+// is happening and pct is the estimated completion percentage, or -1
+// if unknown. Flows that stream can additionally forward progress to
+// the client through their own streaming callback.
+func Progress(ctx context.Context, msg string, pct float64) {
+	attrs := map[string]string{"message": msg}
+	if pct >= 0 {
+		attrs["percent"] = strconv.FormatFloat(pct, 'f', -1, 64)
+	}
+	tracing.AddEvent(ctx, "progress", attrs)
+}
+
+// WithStreamProtocol selects the wire framing that flow servers in
+// this process use for streaming responses: [core.StreamProtocolJSONL]
+// (the default) or [core.StreamProtocolSSE] for frontends built on an
+// AI SDK that consumes server-sent events. Call it before [Init] or
+// [NewFlowServeMux]. Flows whose Stream type is [core.StreamEvent]
+// emit the typed event vocabulary (text-delta, tool-status,
+// source-document, ui-component, error, done) directly; other stream
+// types are wrapped as text-delta events.
+func WithStreamProtocol(p core.StreamProtocol) {
+	core.SetStreamProtocol(p)
+}
+
 // NewFlowServeMux constructs a [net/http.ServeMux].
 // If flows is non-empty, the each of the named flows is registered as a route.
 // Otherwise, all defined flows are registered.
@@ -107,3 +176,13 @@ func Run[Out any](ctx context.Context, name string, f func() (Out, error)) (Out,
 func NewFlowServeMux(flows []string) *http.ServeMux {
 	return core.NewFlowServeMux(flows)
 }
+
+// NewInspectionServeMux constructs an [net/http.Handler] serving a
+// read-only, authenticated subset of the reflection API, so the Dev UI
+// and CLI can inspect a staging deployment (list actions, fetch traces,
+// run allowlisted flows) without an SSH tunnel. See
+// [core.InspectionOptions] for configuration; an auth token is
+// required.
+func NewInspectionServeMux(opts *core.InspectionOptions) (http.Handler, error) {
+	return core.NewInspectionServeMux(opts)
+}
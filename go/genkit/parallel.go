@@ -0,0 +1,105 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genkit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/firebase/genkit/go/core"
+)
+
+// runParallelOptions holds the options for [RunParallel].
+type runParallelOptions[Out any] struct {
+	concurrency int
+	callback    func(context.Context, Out) error
+}
+
+// A RunParallelOption configures a call to [RunParallel].
+type RunParallelOption[Out any] func(*runParallelOptions[Out])
+
+// WithConcurrency limits the number of inputs that [RunParallel] processes
+// at the same time. If n <= 0 or the option is not given, all inputs are
+// processed concurrently.
+func WithConcurrency[Out any](n int) RunParallelOption[Out] {
+	return func(opts *runParallelOptions[Out]) {
+		opts.concurrency = n
+	}
+}
+
+// WithStreamCallback arranges for each result of [RunParallel] to be passed
+// to cb as soon as it is available, in completion order. A flow that streams
+// can use this to forward partial results to its own streaming callback.
+func WithStreamCallback[Out any](cb func(context.Context, Out) error) RunParallelOption[Out] {
+	return func(opts *runParallelOptions[Out]) {
+		opts.callback = cb
+	}
+}
+
+// RunParallel runs fn on every element of inputs, each as its own flow step
+// in the manner of [Run]. The steps run concurrently, optionally bounded by
+// [WithConcurrency]. The step for inputs[i] is named "name/i", so results
+// are cached per element if the flow is restarted.
+//
+// RunParallel returns the outputs in the same order as the inputs. If any
+// step fails, it waits for the remaining steps and returns all errors,
+// joined with [errors.Join].
+// It returns an error if no flow is active.
+func RunParallel[In, Out any](ctx context.Context, name string, inputs []In, fn func(context.Context, In) (Out, error), opts ...RunParallelOption[Out]) ([]Out, error) {
+	var opt runParallelOptions[Out]
+	for _, o := range opts {
+		o(&opt)
+	}
+	var sem chan struct{}
+	if opt.concurrency > 0 {
+		sem = make(chan struct{}, opt.concurrency)
+	}
+	outs := make([]Out, len(inputs))
+	errs := make([]error, len(inputs))
+	var mu sync.Mutex // guards calls to opt.callback
+	var wg sync.WaitGroup
+	for i, in := range inputs {
+		wg.Add(1)
+		go func(i int, in In) {
+			defer wg.Done()
+			if sem != nil {
+				sem <- struct{}{}
+				defer func() { <-sem }()
+			}
+			out, err := core.InternalRun(ctx, fmt.Sprintf("%s/%d", name, i), func() (Out, error) {
+				return fn(ctx, in)
+			})
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			outs[i] = out
+			if opt.callback != nil {
+				mu.Lock()
+				defer mu.Unlock()
+				if err := opt.callback(ctx, out); err != nil {
+					errs[i] = err
+				}
+			}
+		}(i, in)
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return outs, nil
+}
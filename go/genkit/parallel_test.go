@@ -0,0 +1,93 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genkit
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestRunParallel(t *testing.T) {
+	var active, maxActive atomic.Int32
+	f := DefineFlow("parallel", func(ctx context.Context, n int) ([]int, error) {
+		inputs := make([]int, n)
+		for i := range inputs {
+			inputs[i] = i
+		}
+		return RunParallel(ctx, "square", inputs, func(ctx context.Context, i int) (int, error) {
+			a := active.Add(1)
+			defer active.Add(-1)
+			for {
+				m := maxActive.Load()
+				if a <= m || maxActive.CompareAndSwap(m, a) {
+					break
+				}
+			}
+			return i * i, nil
+		}, WithConcurrency[int](2))
+	})
+	got, err := f.Run(context.Background(), 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []int{0, 1, 4, 9, 16}
+	if !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if m := maxActive.Load(); m > 2 {
+		t.Errorf("got %d concurrent steps, want at most 2", m)
+	}
+}
+
+func TestRunParallelErrors(t *testing.T) {
+	f := DefineFlow("parallelErrors", func(ctx context.Context, n int) ([]int, error) {
+		return RunParallel(ctx, "fail", make([]int, n), func(ctx context.Context, i int) (int, error) {
+			return 0, fmt.Errorf("step failed")
+		})
+	})
+	if _, err := f.Run(context.Background(), 3); err == nil {
+		t.Error("got nil, want error")
+	}
+}
+
+func TestRunParallelStream(t *testing.T) {
+	var mu sync.Mutex
+	var streamed []int
+	f := DefineFlow("parallelStream", func(ctx context.Context, n int) ([]int, error) {
+		inputs := make([]int, n)
+		for i := range inputs {
+			inputs[i] = i
+		}
+		return RunParallel(ctx, "ident", inputs, func(ctx context.Context, i int) (int, error) {
+			return i, nil
+		}, WithStreamCallback(func(ctx context.Context, i int) error {
+			mu.Lock()
+			defer mu.Unlock()
+			streamed = append(streamed, i)
+			return nil
+		}))
+	})
+	if _, err := f.Run(context.Background(), 4); err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(streamed)
+	if want := []int{0, 1, 2, 3}; !slices.Equal(streamed, want) {
+		t.Errorf("got %v, want %v", streamed, want)
+	}
+}
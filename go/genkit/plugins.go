@@ -0,0 +1,176 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genkit
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+)
+
+// This file manages plugin initialization. Calling every plugin's Init
+// before [Init] means a slow or unreachable provider delays startup of
+// the whole app; registering initializers here instead gives each one a
+// timeout, lets rarely-used plugins initialize lazily on first use, and
+// reports which plugins degraded rather than failing the app.
+
+// A PluginInit describes how to initialize one plugin.
+type PluginInit struct {
+	// Name identifies the plugin, e.g. "vertexai".
+	Name string
+	// Init initializes the plugin.
+	Init func(context.Context) error
+	// Timeout bounds how long Init may take. Zero means no timeout.
+	Timeout time.Duration
+	// Lazy postpones Init until the first call to [EnsurePlugin] for
+	// this plugin, instead of running it in [InitPlugins].
+	Lazy bool
+}
+
+// PluginState is the initialization state of a plugin.
+type PluginState string
+
+const (
+	// PluginPending means the plugin has not been initialized yet.
+	PluginPending PluginState = "pending"
+	// PluginReady means the plugin initialized successfully.
+	PluginReady PluginState = "ready"
+	// PluginFailed means initialization failed; the app keeps running
+	// without the plugin.
+	PluginFailed PluginState = "failed"
+)
+
+// A PluginStatus reports the initialization outcome for one plugin.
+type PluginStatus struct {
+	Name  string
+	State PluginState
+	// Err is the initialization error, if State is [PluginFailed].
+	Err error
+	// InitTime is how long initialization took.
+	InitTime time.Duration
+}
+
+type pluginEntry struct {
+	init   PluginInit
+	once   sync.Once
+	status PluginStatus
+}
+
+var (
+	pluginsMu sync.Mutex
+	plugins   = map[string]*pluginEntry{}
+)
+
+// RegisterPluginInit registers a plugin initializer. It panics if a
+// plugin with the same name is already registered.
+func RegisterPluginInit(p PluginInit) {
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+	if _, ok := plugins[p.Name]; ok {
+		panic(fmt.Sprintf("genkit.RegisterPluginInit: plugin %q already registered", p.Name))
+	}
+	plugins[p.Name] = &pluginEntry{
+		init:   p,
+		status: PluginStatus{Name: p.Name, State: PluginPending},
+	}
+}
+
+// InitPlugins runs every registered non-lazy plugin initializer,
+// honoring each plugin's timeout, and returns their statuses. A failed
+// initialization is logged and reported as [PluginFailed] but does not
+// fail the app: actions backed by the plugin will error when used.
+// Call it before [Init].
+func InitPlugins(ctx context.Context) []PluginStatus {
+	pluginsMu.Lock()
+	var entries []*pluginEntry
+	for _, e := range plugins {
+		if !e.init.Lazy {
+			entries = append(entries, e)
+		}
+	}
+	pluginsMu.Unlock()
+	for _, e := range entries {
+		e.ensure(ctx)
+	}
+	return PluginStatuses()
+}
+
+// EnsurePlugin initializes the named plugin if it has not been
+// initialized yet, and reports the result. Use it before first use of
+// a plugin registered with Lazy set.
+func EnsurePlugin(ctx context.Context, name string) error {
+	pluginsMu.Lock()
+	e := plugins[name]
+	pluginsMu.Unlock()
+	if e == nil {
+		return fmt.Errorf("genkit.EnsurePlugin: no plugin %q registered", name)
+	}
+	e.ensure(ctx)
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+	return e.status.Err
+}
+
+// PluginStatuses returns the status of every registered plugin, sorted
+// by name.
+func PluginStatuses() []PluginStatus {
+	pluginsMu.Lock()
+	defer pluginsMu.Unlock()
+	statuses := make([]PluginStatus, 0, len(plugins))
+	for _, e := range plugins {
+		statuses = append(statuses, e.status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// ensure runs the plugin's initializer exactly once.
+func (e *pluginEntry) ensure(ctx context.Context) {
+	e.once.Do(func() {
+		if t := e.init.Timeout; t > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, t)
+			defer cancel()
+		}
+		start := time.Now()
+		// Run the initializer in a goroutine so a hung provider cannot
+		// stall startup past the timeout even if Init ignores ctx.
+		done := make(chan error, 1)
+		go func() { done <- e.init.Init(ctx) }()
+		var err error
+		select {
+		case err = <-done:
+		case <-ctx.Done():
+			err = fmt.Errorf("plugin %q initialization: %w", e.init.Name, ctx.Err())
+		}
+		elapsed := time.Since(start)
+
+		pluginsMu.Lock()
+		defer pluginsMu.Unlock()
+		e.status.InitTime = elapsed
+		if err != nil {
+			e.status.State = PluginFailed
+			e.status.Err = err
+			slog.Error("plugin initialization failed; continuing without it",
+				"plugin", e.init.Name, "err", err)
+			return
+		}
+		e.status.State = PluginReady
+		slog.Debug("plugin initialized", "plugin", e.init.Name, "elapsed", elapsed)
+	})
+}
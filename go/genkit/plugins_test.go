@@ -0,0 +1,84 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genkit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestInitPlugins(t *testing.T) {
+	ctx := context.Background()
+	okCalls := 0
+	RegisterPluginInit(PluginInit{
+		Name: "ok",
+		Init: func(ctx context.Context) error { okCalls++; return nil },
+	})
+	RegisterPluginInit(PluginInit{
+		Name: "broken",
+		Init: func(ctx context.Context) error { return errors.New("no credentials") },
+	})
+	RegisterPluginInit(PluginInit{
+		Name:    "slow",
+		Timeout: 10 * time.Millisecond,
+		Init: func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	})
+	lazyCalls := 0
+	RegisterPluginInit(PluginInit{
+		Name: "lazy",
+		Lazy: true,
+		Init: func(ctx context.Context) error { lazyCalls++; return nil },
+	})
+
+	statuses := InitPlugins(ctx)
+	byName := map[string]PluginStatus{}
+	for _, s := range statuses {
+		byName[s.Name] = s
+	}
+	if got := byName["ok"].State; got != PluginReady {
+		t.Errorf("ok: state = %s, want %s", got, PluginReady)
+	}
+	if got := byName["broken"].State; got != PluginFailed {
+		t.Errorf("broken: state = %s, want %s", got, PluginFailed)
+	}
+	if got := byName["slow"].State; got != PluginFailed {
+		t.Errorf("slow: state = %s, want %s", got, PluginFailed)
+	}
+	if got := byName["lazy"].State; got != PluginPending {
+		t.Errorf("lazy: state = %s, want %s", got, PluginPending)
+	}
+	if lazyCalls != 0 {
+		t.Errorf("lazy initialized eagerly")
+	}
+
+	// First use initializes a lazy plugin, exactly once.
+	if err := EnsurePlugin(ctx, "lazy"); err != nil {
+		t.Fatal(err)
+	}
+	if err := EnsurePlugin(ctx, "lazy"); err != nil {
+		t.Fatal(err)
+	}
+	if lazyCalls != 1 {
+		t.Errorf("lazy initialized %d times, want 1", lazyCalls)
+	}
+	if okCalls != 1 {
+		t.Errorf("ok initialized %d times, want 1", okCalls)
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genkit
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/firebase/genkit/go/core/logger"
+)
+
+// This file implements configuration profiles: named bundles of
+// per-environment defaults, so a dev and a prod main.go differ only in
+// which profile they pass to [InitWithProfile] instead of diverging
+// option by option.
+
+// A Profile bundles the defaults for one environment. Zero fields in
+// the [Options] passed to [InitWithProfile] are filled from the
+// profile; non-zero fields win.
+type Profile struct {
+	// Name identifies the profile, e.g. "dev", "staging", "prod".
+	Name string
+	// LogLevel is the default logging level.
+	LogLevel slog.Level
+	// DevFeatures enables development features such as the reflection
+	// server used by the Dev UI.
+	DevFeatures bool
+	// JSONLogs emits one-line JSON log records (see
+	// [logger.NewProductionHandler]) instead of the text handler.
+	JSONLogs bool
+	// FlowAddr is the default flow server address ("-" for none).
+	FlowAddr string
+	// ShutdownTimeout is the default drain timeout on shutdown.
+	ShutdownTimeout time.Duration
+}
+
+// DevProfile is the profile for local development: debug logging and
+// dev features on.
+func DevProfile() *Profile {
+	return &Profile{
+		Name:        "dev",
+		LogLevel:    slog.LevelDebug,
+		DevFeatures: true,
+	}
+}
+
+// StagingProfile is the profile for staging deployments: info logging,
+// dev features off, a longer drain timeout.
+func StagingProfile() *Profile {
+	return &Profile{
+		Name:            "staging",
+		LogLevel:        slog.LevelInfo,
+		JSONLogs:        true,
+		ShutdownTimeout: 10 * time.Second,
+	}
+}
+
+// ProdProfile is the profile for production: warnings and errors only,
+// dev features off, a longer drain timeout.
+func ProdProfile() *Profile {
+	return &Profile{
+		Name:            "prod",
+		LogLevel:        slog.LevelWarn,
+		JSONLogs:        true,
+		ShutdownTimeout: 30 * time.Second,
+	}
+}
+
+// ProfileNamed returns the built-in profile with the given name:
+// "dev", "staging" or "prod".
+func ProfileNamed(name string) (*Profile, error) {
+	switch name {
+	case "dev":
+		return DevProfile(), nil
+	case "staging":
+		return StagingProfile(), nil
+	case "prod":
+		return ProdProfile(), nil
+	}
+	return nil, fmt.Errorf("genkit: no profile named %q", name)
+}
+
+// InitWithProfile is [Init] with a profile's defaults applied first.
+// If profile is nil, it is chosen by the GENKIT_PROFILE environment
+// variable, falling back to [DevProfile] when GENKIT_ENV is "dev" and
+// [ProdProfile] otherwise. Fields set in opts override the profile.
+func InitWithProfile(ctx context.Context, profile *Profile, opts *Options) error {
+	if profile == nil {
+		var err error
+		profile, err = profileFromEnv()
+		if err != nil {
+			return err
+		}
+	}
+	if profile.JSONLogs {
+		slog.SetDefault(slog.New(logger.NewProductionHandler(&logger.ProductionHandlerOptions{
+			Level: profile.LogLevel,
+		})))
+	} else {
+		slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+			Level: profile.LogLevel,
+		})))
+	}
+	if profile.DevFeatures && os.Getenv("GENKIT_ENV") == "" {
+		// The reflection server and other dev features key off
+		// GENKIT_ENV.
+		os.Setenv("GENKIT_ENV", "dev")
+	}
+	return Init(ctx, profile.apply(opts))
+}
+
+// profileFromEnv chooses a profile from GENKIT_PROFILE, or from
+// GENKIT_ENV when GENKIT_PROFILE is unset.
+func profileFromEnv() (*Profile, error) {
+	if name := os.Getenv("GENKIT_PROFILE"); name != "" {
+		return ProfileNamed(name)
+	}
+	if os.Getenv("GENKIT_ENV") == "dev" {
+		return DevProfile(), nil
+	}
+	return ProdProfile(), nil
+}
+
+// apply fills zero fields of opts from the profile's defaults.
+func (p *Profile) apply(opts *Options) *Options {
+	merged := Options{}
+	if opts != nil {
+		merged = *opts
+	}
+	if merged.FlowAddr == "" {
+		merged.FlowAddr = p.FlowAddr
+	}
+	if merged.ShutdownTimeout == 0 {
+		merged.ShutdownTimeout = p.ShutdownTimeout
+	}
+	return &merged
+}
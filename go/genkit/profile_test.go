@@ -0,0 +1,73 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package genkit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestProfileNamed(t *testing.T) {
+	for _, name := range []string{"dev", "staging", "prod"} {
+		p, err := ProfileNamed(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.Name != name {
+			t.Errorf("ProfileNamed(%q).Name = %q", name, p.Name)
+		}
+	}
+	if _, err := ProfileNamed("nope"); err == nil {
+		t.Error("expected an error for an unknown profile")
+	}
+	if !DevProfile().DevFeatures || ProdProfile().DevFeatures {
+		t.Error("dev features should be on only in the dev profile")
+	}
+}
+
+func TestProfileApply(t *testing.T) {
+	p := ProdProfile()
+	// Zero options take the profile's defaults.
+	opts := p.apply(nil)
+	if opts.ShutdownTimeout != p.ShutdownTimeout {
+		t.Errorf("ShutdownTimeout = %v, want %v", opts.ShutdownTimeout, p.ShutdownTimeout)
+	}
+	// Explicit options win.
+	opts = p.apply(&Options{FlowAddr: "-", ShutdownTimeout: time.Second})
+	if opts.FlowAddr != "-" || opts.ShutdownTimeout != time.Second {
+		t.Errorf("explicit options were overridden: %+v", opts)
+	}
+}
+
+func TestProfileFromEnv(t *testing.T) {
+	t.Setenv("GENKIT_PROFILE", "staging")
+	p, err := profileFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name != "staging" {
+		t.Errorf("profile = %q, want staging", p.Name)
+	}
+
+	t.Setenv("GENKIT_PROFILE", "")
+	t.Setenv("GENKIT_ENV", "dev")
+	p, err = profileFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name != "dev" {
+		t.Errorf("profile = %q, want dev", p.Name)
+	}
+}
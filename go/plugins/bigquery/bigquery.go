@@ -0,0 +1,221 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bigquery provides a retriever over structured BigQuery data.
+// Instead of vector search, the retriever answers a query by running
+// SQL: either a fixed parameterized statement with the query text bound
+// to @query, or SQL written by a configured model from the query and a
+// list of tables. Each result row becomes a document carrying the
+// result schema in its metadata, for analytics Q&A flows over
+// enterprise data.
+package bigquery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/firebase/genkit/go/ai"
+	bq "google.golang.org/api/bigquery/v2"
+)
+
+const provider = "bigquery"
+
+// Config provides configuration options for [DefineRetriever].
+type Config struct {
+	// ProjectID is the Google Cloud project to run queries in. Required.
+	ProjectID string
+	// Name is the retriever name. Required.
+	Name string
+	// Query is a parameterized SQL statement; the retrieval query text
+	// is bound to the @query named parameter. Exactly one of Query and
+	// Model must be set.
+	Query string
+	// Model, if set, writes the SQL from the retrieval query text and
+	// Tables. Only SELECT statements it produces are executed.
+	Model *ai.Model
+	// Tables lists the fully qualified tables the model may query, e.g.
+	// "myproject.sales.orders". Required when Model is set.
+	Tables []string
+	// MaxResults caps the number of rows returned. The default is 50.
+	MaxResults int64
+	// Service is the BigQuery API client to use. If nil, one is created
+	// with default credentials.
+	Service *bq.Service
+}
+
+// DefineRetriever defines a Retriever that answers queries by running
+// BigQuery SQL.
+func DefineRetriever(ctx context.Context, cfg Config) (*ai.Retriever, error) {
+	if cfg.ProjectID == "" {
+		return nil, errors.New("bigquery.DefineRetriever: ProjectID required")
+	}
+	if cfg.Name == "" {
+		return nil, errors.New("bigquery.DefineRetriever: Name required")
+	}
+	if (cfg.Query == "") == (cfg.Model == nil) {
+		return nil, errors.New("bigquery.DefineRetriever: exactly one of Query and Model must be set")
+	}
+	if cfg.Model != nil && len(cfg.Tables) == 0 {
+		return nil, errors.New("bigquery.DefineRetriever: Tables required with Model")
+	}
+	if cfg.MaxResults == 0 {
+		cfg.MaxResults = 50
+	}
+	if cfg.Service == nil {
+		svc, err := bq.NewService(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("bigquery.DefineRetriever: %w", err)
+		}
+		cfg.Service = svc
+	}
+	r := &retriever{cfg: cfg}
+	return ai.DefineRetriever(provider, cfg.Name, r.retrieve), nil
+}
+
+type retriever struct {
+	cfg Config
+}
+
+func (r *retriever) retrieve(ctx context.Context, req *ai.RetrieverRequest) (*ai.RetrieverResponse, error) {
+	queryText := documentText(req.Document)
+	useLegacySQL := false
+	qr := &bq.QueryRequest{
+		UseLegacySql: &useLegacySQL,
+		MaxResults:   r.cfg.MaxResults,
+	}
+	if r.cfg.Query != "" {
+		qr.Query = r.cfg.Query
+		qr.ParameterMode = "NAMED"
+		qr.QueryParameters = []*bq.QueryParameter{{
+			Name:           "query",
+			ParameterType:  &bq.QueryParameterType{Type: "STRING"},
+			ParameterValue: &bq.QueryParameterValue{Value: queryText},
+		}}
+	} else {
+		sql, err := r.writeSQL(ctx, queryText)
+		if err != nil {
+			return nil, err
+		}
+		qr.Query = sql
+	}
+	resp, err := r.cfg.Service.Jobs.Query(r.cfg.ProjectID, qr).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("bigquery: running query: %w", err)
+	}
+	if !resp.JobComplete {
+		return nil, errors.New("bigquery: query did not complete")
+	}
+	return rowsToDocuments(resp, qr.Query)
+}
+
+// writeSQL asks the configured model for a SELECT statement answering
+// the query over the configured tables.
+func (r *retriever) writeSQL(ctx context.Context, queryText string) (string, error) {
+	prompt := fmt.Sprintf(
+		"Write a single BigQuery standard SQL SELECT statement answering the question below. Use only these tables: %s. Write only the SQL, no commentary or markdown.\n\nQuestion: %s",
+		strings.Join(r.cfg.Tables, ", "), queryText)
+	resp, err := r.cfg.Model.Generate(ctx, &ai.GenerateRequest{
+		Messages: []*ai.Message{ai.NewUserTextMessage(prompt)},
+	}, nil)
+	if err != nil {
+		return "", fmt.Errorf("bigquery: generating SQL: %w", err)
+	}
+	text, err := resp.Text()
+	if err != nil {
+		return "", err
+	}
+	sql := stripFences(text)
+	if err := validateSQL(sql); err != nil {
+		return "", err
+	}
+	return sql, nil
+}
+
+// validateSQL ensures generated SQL is a single read-only statement.
+func validateSQL(sql string) error {
+	trimmed := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(sql), ";"))
+	if trimmed == "" {
+		return errors.New("bigquery: model produced no SQL")
+	}
+	if strings.Contains(trimmed, ";") {
+		return errors.New("bigquery: generated SQL must be a single statement")
+	}
+	first := strings.ToLower(strings.Fields(trimmed)[0])
+	if first != "select" && first != "with" {
+		return fmt.Errorf("bigquery: generated SQL must be a SELECT, got %q statement", first)
+	}
+	return nil
+}
+
+// stripFences removes a markdown code fence around SQL, which models
+// add despite instructions.
+func stripFences(s string) string {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "```") {
+		s = strings.TrimPrefix(s, "```sql")
+		s = strings.TrimPrefix(s, "```")
+		s = strings.TrimSuffix(strings.TrimSpace(s), "```")
+	}
+	return strings.TrimSpace(s)
+}
+
+// rowsToDocuments converts a query response into one document per row.
+// Each document's text is the row as a JSON object keyed by column
+// name; its metadata records the result schema and the SQL that
+// produced it.
+func rowsToDocuments(resp *bq.QueryResponse, sql string) (*ai.RetrieverResponse, error) {
+	var cols []string
+	schema := map[string]any{}
+	if resp.Schema != nil {
+		for _, f := range resp.Schema.Fields {
+			cols = append(cols, f.Name)
+			schema[f.Name] = f.Type
+		}
+	}
+	out := &ai.RetrieverResponse{}
+	for _, row := range resp.Rows {
+		m := map[string]any{}
+		for i, cell := range row.F {
+			if i < len(cols) {
+				m[cols[i]] = cell.V
+			}
+		}
+		data, err := json.Marshal(m)
+		if err != nil {
+			return nil, err
+		}
+		out.Documents = append(out.Documents, ai.DocumentFromText(string(data), map[string]any{
+			"schema": schema,
+			"sql":    sql,
+		}))
+	}
+	return out, nil
+}
+
+// documentText concatenates the text parts of a document.
+func documentText(d *ai.Document) string {
+	if d == nil {
+		return ""
+	}
+	var b strings.Builder
+	for _, p := range d.Content {
+		if p.IsText() {
+			b.WriteString(p.Text)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,162 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bigquery
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+	bq "google.golang.org/api/bigquery/v2"
+	"google.golang.org/api/option"
+)
+
+// fakeBigQuery serves the jobs.query endpoint, capturing the request
+// and returning two rows.
+func fakeBigQuery(t *testing.T, gotReq *bq.QueryRequest) *bq.Service {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/queries") {
+			http.NotFound(w, r)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(gotReq); err != nil {
+			t.Error(err)
+		}
+		resp := &bq.QueryResponse{
+			JobComplete: true,
+			Schema: &bq.TableSchema{Fields: []*bq.TableFieldSchema{
+				{Name: "region", Type: "STRING"},
+				{Name: "total", Type: "INTEGER"},
+			}},
+			Rows: []*bq.TableRow{
+				{F: []*bq.TableCell{{V: "west"}, {V: "42"}}},
+				{F: []*bq.TableCell{{V: "east"}, {V: "17"}}},
+			},
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(srv.Close)
+	svc, err := bq.NewService(context.Background(),
+		option.WithEndpoint(srv.URL+"/"), option.WithoutAuthentication())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return svc
+}
+
+func TestParameterizedQuery(t *testing.T) {
+	var gotReq bq.QueryRequest
+	r, err := DefineRetriever(context.Background(), Config{
+		ProjectID: "proj",
+		Name:      "sales",
+		Query:     "SELECT region, total FROM t WHERE region = @query",
+		Service:   fakeBigQuery(t, &gotReq),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := r.Retrieve(context.Background(), &ai.RetrieverRequest{
+		Document: ai.DocumentFromText("west", nil),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Documents) != 2 {
+		t.Fatalf("got %d documents, want 2", len(resp.Documents))
+	}
+	var row map[string]any
+	if err := json.Unmarshal([]byte(resp.Documents[0].Content[0].Text), &row); err != nil {
+		t.Fatal(err)
+	}
+	if row["region"] != "west" || row["total"] != "42" {
+		t.Errorf("first row = %v", row)
+	}
+	schema := resp.Documents[0].Metadata["schema"].(map[string]any)
+	if schema["total"] != "INTEGER" {
+		t.Errorf("schema = %v", schema)
+	}
+	if len(gotReq.QueryParameters) != 1 || gotReq.QueryParameters[0].ParameterValue.Value != "west" {
+		t.Errorf("query parameters = %+v", gotReq.QueryParameters)
+	}
+	if gotReq.UseLegacySql == nil || *gotReq.UseLegacySql {
+		t.Error("UseLegacySql was not sent as false")
+	}
+}
+
+func TestModelWrittenSQL(t *testing.T) {
+	m := ai.DefineModel("test", "nl2sql", nil, func(ctx context.Context, req *ai.GenerateRequest, _ ai.ModelStreamingCallback) (*ai.GenerateResponse, error) {
+		return &ai.GenerateResponse{
+			Request: req,
+			Candidates: []*ai.Candidate{{
+				Message: &ai.Message{Role: ai.RoleModel, Content: []*ai.Part{
+					ai.NewTextPart("```sql\nSELECT region, total FROM `proj.sales.orders`\n```"),
+				}},
+			}},
+		}, nil
+	})
+	var gotReq bq.QueryRequest
+	r, err := DefineRetriever(context.Background(), Config{
+		ProjectID: "proj",
+		Name:      "salesNL",
+		Model:     m,
+		Tables:    []string{"proj.sales.orders"},
+		Service:   fakeBigQuery(t, &gotReq),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := r.Retrieve(context.Background(), &ai.RetrieverRequest{
+		Document: ai.DocumentFromText("totals by region", nil),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Documents) != 2 {
+		t.Fatalf("got %d documents, want 2", len(resp.Documents))
+	}
+	if gotReq.Query != "SELECT region, total FROM `proj.sales.orders`" {
+		t.Errorf("sent SQL %q", gotReq.Query)
+	}
+}
+
+func TestValidateSQL(t *testing.T) {
+	for _, test := range []struct {
+		sql string
+		ok  bool
+	}{
+		{"SELECT 1", true},
+		{"  with t as (select 1) select * from t;", true},
+		{"DROP TABLE users", false},
+		{"SELECT 1; DELETE FROM t", false},
+		{"", false},
+	} {
+		err := validateSQL(test.sql)
+		if (err == nil) != test.ok {
+			t.Errorf("validateSQL(%q) = %v, want ok=%t", test.sql, err, test.ok)
+		}
+	}
+}
+
+func TestConfigValidation(t *testing.T) {
+	_, err := DefineRetriever(context.Background(), Config{ProjectID: "p", Name: "both"})
+	if err == nil {
+		t.Error("expected an error with neither Query nor Model")
+	}
+}
@@ -0,0 +1,96 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dotprompt
+
+import (
+	"io/fs"
+	"sync"
+	"time"
+
+	"github.com/aymerick/raymond"
+)
+
+// This file caches the two expensive steps of using a prompt: parsing
+// the Handlebars template and reading + parsing the prompt file.
+// Compiled templates are cached by their text, so code-defined prompts
+// that are re-created per request compile once; opened prompt files
+// are cached by path and invalidated when the file changes. Use
+// [PrecompilePrompts] to pay all of these costs at startup.
+
+var (
+	templateCacheMu sync.Mutex
+	templateCache   = map[string]*raymond.Template{}
+)
+
+// compileTemplate returns the compiled template for templateText,
+// parsing and registering helpers only the first time a given text is
+// seen.
+func compileTemplate(templateText string) (*raymond.Template, error) {
+	templateCacheMu.Lock()
+	defer templateCacheMu.Unlock()
+	if t := templateCache[templateText]; t != nil {
+		return t, nil
+	}
+	t, err := raymond.Parse(templateText)
+	if err != nil {
+		return nil, err
+	}
+	t.RegisterHelpers(templateHelpers)
+	templateCache[templateText] = t
+	return t, nil
+}
+
+type cachedPrompt struct {
+	prompt  *Prompt
+	modTime time.Time
+	size    int64
+}
+
+var (
+	promptCacheMu sync.Mutex
+	promptCache   = map[string]*cachedPrompt{}
+)
+
+// cachedPromptFor returns the cached prompt for the file, or nil if it
+// is not cached or the file has changed since it was.
+func cachedPromptFor(fileName string, info fs.FileInfo) *Prompt {
+	promptCacheMu.Lock()
+	defer promptCacheMu.Unlock()
+	c := promptCache[fileName]
+	if c == nil || !c.modTime.Equal(info.ModTime()) || c.size != info.Size() {
+		return nil
+	}
+	// Return a shallow copy so callers that adjust fields do not
+	// affect each other. The compiled template is shared; it is
+	// read-only once parsed.
+	p := *c.prompt
+	return &p
+}
+
+// storeCachedPrompt caches the parsed prompt for the file.
+func storeCachedPrompt(fileName string, info fs.FileInfo, p *Prompt) {
+	promptCacheMu.Lock()
+	defer promptCacheMu.Unlock()
+	promptCache[fileName] = &cachedPrompt{prompt: p, modTime: info.ModTime(), size: info.Size()}
+}
+
+// PrecompilePrompts parses and compiles every prompt file in the
+// prompt directory, warming the caches so no request pays the parse
+// cost. Call it at startup, after [SetDirectory]; it returns an error
+// listing all the files that failed, or nil if all compiled. It also
+// subsumes [ValidatePrompts]: a nil error means every prompt parsed.
+func PrecompilePrompts() error {
+	return ValidatePrompts()
+}
@@ -0,0 +1,116 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dotprompt
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTemplateCache(t *testing.T) {
+	cfg := Config{ModelName: "test/model"}
+	p1, err := New("cache1", "hello {{name}}", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p2, err := New("cache2", "hello {{name}}", cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p1.Template != p2.Template {
+		t.Error("same template text compiled twice")
+	}
+	out, err := p1.RenderText(map[string]any{"name": "world"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != "hello world" {
+		t.Errorf("got %q, want %q", out, "hello world")
+	}
+}
+
+func TestPromptFileCache(t *testing.T) {
+	dir := t.TempDir()
+	fileName := filepath.Join(dir, "greet.prompt")
+	if err := os.WriteFile(fileName, []byte("hello {{name}}"), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	SetDirectory(dir)
+	defer SetDirectory("testdata")
+
+	p1, err := Open("greet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p2, err := Open("greet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p1.Template != p2.Template {
+		t.Error("unchanged file was re-parsed")
+	}
+
+	// A changed file invalidates the cache entry.
+	if err := os.WriteFile(fileName, []byte("goodbye {{name}}"), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	// Ensure the modification is visible even with coarse timestamps.
+	future := time.Now().Add(time.Second)
+	if err := os.Chtimes(fileName, future, future); err != nil {
+		t.Fatal(err)
+	}
+	p3, err := Open("greet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p3.TemplateText != "goodbye {{name}}" {
+		t.Errorf("got stale template %q", p3.TemplateText)
+	}
+}
+
+func TestPrecompilePrompts(t *testing.T) {
+	SetDirectory("testdata")
+	if err := PrecompilePrompts(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func BenchmarkOpenCached(b *testing.B) {
+	SetDirectory("testdata")
+	if _, err := Open("story"); err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Open("story"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkRenderText(b *testing.B) {
+	p, err := New("benchRender", "hello {{name}}, welcome to {{name}}'s page", Config{ModelName: "test/model"})
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := p.RenderText(map[string]any{"name": "world"}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -37,8 +37,12 @@ import (
 var promptDirectory string
 
 // SetDirectory sets the directory where dotprompt files are read from.
+// It also registers any schema files in the directory (see [LoadSchemas]).
 func SetDirectory(directory string) {
 	promptDirectory = directory
+	if err := LoadSchemas(); err != nil {
+		slog.Warn("dotprompt: loading schemas", "err", err)
+	}
 }
 
 // Prompt is a parsed dotprompt file.
@@ -64,11 +68,16 @@ type Prompt struct {
 	Config
 
 	// The parsed prompt template.
+	// This is nil if the prompt uses a template engine other than
+	// the default Handlebars (see [RegisterTemplateEngine]).
 	Template *raymond.Template
 
 	// The original prompt template text.
 	TemplateText string
 
+	// render executes the template, whichever engine it uses.
+	render func(variables map[string]any) (string, error)
+
 	// A hash of the prompt contents.
 	hash string
 
@@ -80,6 +89,10 @@ type Prompt struct {
 type Config struct {
 	// The prompt variant.
 	Variant string
+	// The name of the template engine the prompt is written for,
+	// from the "template" front-matter option.
+	// If empty, Handlebars is used.
+	TemplateFormat string
 	// The name of the model for which the prompt is input.
 	// If this is non-empty, Model should be nil.
 	ModelName string
@@ -117,6 +130,34 @@ func Open(name string) (*Prompt, error) {
 	return OpenVariant(name, "")
 }
 
+// MustOpen is like [Open] but panics if the prompt file is missing or
+// fails to parse. Use it for prompts the program cannot run without.
+func MustOpen(name string) *Prompt {
+	p, err := Open(name)
+	if err != nil {
+		panic(fmt.Sprintf("dotprompt.MustOpen(%q): %v", name, err))
+	}
+	return p
+}
+
+// ValidatePrompts parses every prompt file in the prompt directory and
+// returns an error listing all the files that failed, or nil if all
+// parsed. Call it at startup to fail fast instead of discovering a bad
+// prompt file on first use.
+func ValidatePrompts() error {
+	names, err := List("")
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, name := range names {
+		if _, err := Open(name); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
 // OpenVariant opens a parses a dotprompt file with a variant.
 // If the variant does not exist, the non-variant version is tried.
 func OpenVariant(name, variant string) (*Prompt, error) {
@@ -134,6 +175,15 @@ func OpenVariant(name, variant string) (*Prompt, error) {
 
 	fileName := filepath.Join(promptDirectory, vname+".prompt")
 
+	// Serve an unchanged file from the prompt cache rather than
+	// re-reading and re-parsing it.
+	info, statErr := os.Stat(fileName)
+	if statErr == nil {
+		if p := cachedPromptFor(fileName, info); p != nil {
+			return p, nil
+		}
+	}
+
 	data, err := os.ReadFile(fileName)
 	if err != nil {
 		if variant != "" && errors.Is(err, fs.ErrNotExist) {
@@ -144,7 +194,19 @@ func OpenVariant(name, variant string) (*Prompt, error) {
 		return nil, fmt.Errorf("failed to read dotprompt file %q: %w", name, err)
 	}
 
-	return Parse(name, variant, data)
+	p, err := Parse(name, variant, data)
+	if err != nil {
+		return nil, err
+	}
+	defaults, err := namespaceDefaultsFor(name)
+	if err != nil {
+		return nil, err
+	}
+	applyNamespaceDefaults(p, defaults)
+	if statErr == nil {
+		storeCachedPrompt(fileName, info, p)
+	}
+	return p, nil
 }
 
 // frontmatterYAML is the type we use to unpack the frontmatter.
@@ -155,6 +217,7 @@ func OpenVariant(name, variant string) (*Prompt, error) {
 type frontmatterYAML struct {
 	Name       string                     `yaml:"name,omitempty"`
 	Variant    string                     `yaml:"variant,omitempty"`
+	Template   string                     `yaml:"template,omitempty"`
 	Model      string                     `yaml:"model,omitempty"`
 	Tools      []*ai.ToolDefinition       `yaml:"tools,omitempty"`
 	Candidates int                        `yaml:"candidates,omitempty"`
@@ -194,18 +257,31 @@ func Parse(name, variant string, data []byte) (*Prompt, error) {
 // templateText should be a handlebars template.
 // hash is its SHA256 hash as a hex string.
 func newPrompt(name, templateText, hash string, config Config) (*Prompt, error) {
-	template, err := raymond.Parse(templateText)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse template: %w", err)
-	}
-	template.RegisterHelpers(templateHelpers)
-	return &Prompt{
+	p := &Prompt{
 		Name:         name,
 		Config:       config,
 		hash:         hash,
-		Template:     template,
 		TemplateText: templateText,
-	}, nil
+	}
+	if config.TemplateFormat == "" || config.TemplateFormat == "handlebars" {
+		template, err := compileTemplate(templateText)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse template: %w", err)
+		}
+		p.Template = template
+		p.render = func(variables map[string]any) (string, error) {
+			return template.Exec(variables)
+		}
+		return p, nil
+	}
+	engine, err := lookupTemplateEngine(config.TemplateFormat)
+	if err != nil {
+		return nil, err
+	}
+	if p.render, err = engine(templateText); err != nil {
+		return nil, err
+	}
+	return p, nil
 }
 
 // parseFrontmatter parses the initial YAML frontmatter of a dotprompt file.
@@ -224,6 +300,7 @@ func parseFrontmatter(data []byte) (name string, c Config, rest []byte, err erro
 
 	ret := Config{
 		Variant:          fy.Variant,
+		TemplateFormat:   fy.Template,
 		ModelName:        fy.Model,
 		Tools:            fy.Tools,
 		Candidates:       fy.Candidates,
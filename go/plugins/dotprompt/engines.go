@@ -0,0 +1,104 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dotprompt
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	texttemplate "text/template"
+)
+
+// This file supports template engines other than Handlebars, selected
+// per prompt with a "template: NAME" front-matter option. Handlebars
+// remains the default.
+
+// A TemplateEngine compiles prompt template text into a function that
+// renders it with a set of input variables. The rendered text is then
+// split into messages and parts the same way for every engine, so the
+// role and media markers must match the ones the built-in helpers emit.
+type TemplateEngine func(templateText string) (func(variables map[string]any) (string, error), error)
+
+var (
+	templateEnginesMu sync.RWMutex
+	templateEngines   = map[string]TemplateEngine{
+		"go": goTemplateEngine,
+	}
+)
+
+// RegisterTemplateEngine registers a template engine under a name that
+// prompt files can select with the "template" front-matter option.
+// It panics if the name is already taken. The names "handlebars" and
+// "go" are reserved for the built-in engines.
+func RegisterTemplateEngine(name string, engine TemplateEngine) {
+	templateEnginesMu.Lock()
+	defer templateEnginesMu.Unlock()
+	if name == "handlebars" {
+		panic("dotprompt.RegisterTemplateEngine: cannot replace the default handlebars engine")
+	}
+	if _, ok := templateEngines[name]; ok {
+		panic(fmt.Sprintf("dotprompt.RegisterTemplateEngine: engine %q already registered", name))
+	}
+	templateEngines[name] = engine
+}
+
+// lookupTemplateEngine returns the registered engine with the given name.
+func lookupTemplateEngine(name string) (TemplateEngine, error) {
+	templateEnginesMu.RLock()
+	defer templateEnginesMu.RUnlock()
+	engine, ok := templateEngines[name]
+	if !ok {
+		return nil, fmt.Errorf("dotprompt: unknown template engine %q", name)
+	}
+	return engine, nil
+}
+
+// goTemplateEngine renders prompts written in [text/template] syntax.
+// It provides the same helpers as the Handlebars engine:
+// {{role "system"}}, {{media "URL" "CONTENT_TYPE"}} (the content type is
+// optional), and {{json .V}}.
+func goTemplateEngine(templateText string) (func(variables map[string]any) (string, error), error) {
+	funcs := texttemplate.FuncMap{
+		"role": func(role string) string {
+			return rolePrefix + role + roleSuffix
+		},
+		"media": func(args ...string) (string, error) {
+			switch len(args) {
+			case 1:
+				return mediaPrefix + args[0] + mediaSuffix, nil
+			case 2:
+				return mediaPrefix + args[0] + " " + args[1] + mediaSuffix, nil
+			default:
+				return "", fmt.Errorf("media helper takes a URL and an optional content type, got %d arguments", len(args))
+			}
+		},
+		"json": func(v any) (string, error) {
+			data, err := json.Marshal(v)
+			return string(data), err
+		},
+	}
+	tmpl, err := texttemplate.New("prompt").Funcs(funcs).Parse(templateText)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+	return func(variables map[string]any) (string, error) {
+		var sb strings.Builder
+		if err := tmpl.Execute(&sb, variables); err != nil {
+			return "", err
+		}
+		return sb.String(), nil
+	}, nil
+}
@@ -0,0 +1,57 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dotprompt
+
+import (
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+)
+
+func TestGoTemplateEngine(t *testing.T) {
+	data := []byte(`---
+model: test/chat
+template: go
+---
+{{role "system"}}be helpful
+{{role "user"}}hello {{.name}}, look at {{media "https://example.com/a.png" "image/png"}}`)
+	p, err := Parse("goEngine", "", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msgs, err := p.RenderMessages(map[string]any{"name": "world"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msgs) != 2 {
+		t.Fatalf("got %d messages, want 2", len(msgs))
+	}
+	if got, want := msgs[0].Role, ai.RoleSystem; got != want {
+		t.Errorf("first message role = %q, want %q", got, want)
+	}
+	if got, want := msgs[1].Content[0].Text, "hello world, look at "; got != want {
+		t.Errorf("user text = %q, want %q", got, want)
+	}
+	media := msgs[1].Content[1]
+	if !media.IsMedia() || media.ContentType != "image/png" {
+		t.Errorf("media part = %+v, want image/png media", media)
+	}
+}
+
+func TestUnknownTemplateEngine(t *testing.T) {
+	if _, err := Parse("unknownEngine", "", []byte("---\ntemplate: jinja\n---\nhi")); err == nil {
+		t.Error("expected an error for an unregistered engine")
+	}
+}
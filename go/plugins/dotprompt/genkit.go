@@ -16,6 +16,7 @@ package dotprompt
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"reflect"
@@ -157,14 +158,64 @@ func (p *Prompt) Register() error {
 	return nil
 }
 
-// Generate executes a prompt. It does variable substitution and
-// passes the rendered template to the AI model specified by
-// the prompt.
-//
-// This implements the [ai.Prompt] interface.
-func (p *Prompt) Generate(ctx context.Context, pr *PromptRequest, cb func(context.Context, *ai.GenerateResponseChunk) error) (*ai.GenerateResponse, error) {
-	tracing.SetCustomMetadataAttr(ctx, "subtype", "prompt")
+// AsTool registers a tool that executes the prompt and returns the
+// model's reply as {"text": ...}, so an orchestrator model can delegate
+// to a specialized prompt template. The tool is named after the prompt
+// (with its variant, if any) and its input schema is the prompt's input
+// schema. It returns the tool name.
+func (p *Prompt) AsTool(description string) (string, error) {
+	name := p.Name
+	if name == "" {
+		return "", errors.New("dotprompt: cannot expose an unnamed prompt as a tool")
+	}
+	if p.Variant != "" {
+		name += "." + p.Variant
+	}
+	var inputSchema map[string]any
+	if p.InputSchema != nil {
+		data, err := json.Marshal(p.InputSchema)
+		if err != nil {
+			return "", err
+		}
+		if err := json.Unmarshal(data, &inputSchema); err != nil {
+			return "", err
+		}
+	}
+	ai.DefineTool(&ai.ToolDefinition{
+		Name:        name,
+		Description: description,
+		InputSchema: inputSchema,
+		OutputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"text": map[string]any{"type": "string"},
+			},
+		},
+	}, map[string]any{
+		"prompt": p.Name,
+	}, func(ctx context.Context, input map[string]any) (map[string]any, error) {
+		resp, err := p.Generate(ctx, &PromptRequest{Variables: input}, nil)
+		if err != nil {
+			return nil, err
+		}
+		text, err := resp.Text()
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"text": text}, nil
+	})
+	return name, nil
+}
 
+// Render renders the prompt without calling a model. It does variable
+// substitution and applies the overrides in pr the same way [Prompt.Generate]
+// does, returning the [ai.GenerateRequest] that would be sent to the model
+// (rendered messages, config, and output schema) along with the name of the
+// model that would receive it, in provider/name format. The model name is
+// empty if the prompt config holds an [ai.Model] rather than a name.
+//
+// Render lets tests and debugging tools assert template output cheaply.
+func (p *Prompt) Render(ctx context.Context, pr *PromptRequest) (*ai.GenerateRequest, string, error) {
 	var genReq *ai.GenerateRequest
 	var err error
 	if p.prompt != nil {
@@ -173,7 +224,7 @@ func (p *Prompt) Generate(ctx context.Context, pr *PromptRequest, cb func(contex
 		genReq, err = p.buildRequest(ctx, pr.Variables)
 	}
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	// Let some fields in pr override those in the prompt config.
@@ -187,21 +238,38 @@ func (p *Prompt) Generate(ctx context.Context, pr *PromptRequest, cb func(contex
 		genReq.Context = pr.Context
 	}
 
+	modelName := p.ModelName
+	if pr.Model != "" {
+		modelName = pr.Model
+	}
+	if p.Model == nil && modelName == "" {
+		return nil, "", errors.New("dotprompt execution: model not specified")
+	}
+
+	return genReq, modelName, nil
+}
+
+// Generate executes a prompt. It does variable substitution and
+// passes the rendered template to the AI model specified by
+// the prompt.
+//
+// This implements the [ai.Prompt] interface.
+func (p *Prompt) Generate(ctx context.Context, pr *PromptRequest, cb func(context.Context, *ai.GenerateResponseChunk) error) (*ai.GenerateResponse, error) {
+	tracing.SetCustomMetadataAttr(ctx, "subtype", "prompt")
+
+	genReq, modelName, err := p.Render(ctx, pr)
+	if err != nil {
+		return nil, err
+	}
+
 	model := p.Model
 	if model == nil {
-		modelName := p.ModelName
-		if pr.Model != "" {
-			modelName = pr.Model
-		}
-		if modelName == "" {
-			return nil, errors.New("dotprompt execution: model not specified")
-		}
 		provider, name, found := strings.Cut(modelName, "/")
 		if !found {
 			return nil, errors.New("dotprompt model not in provider/name format")
 		}
 
-		model := ai.LookupModel(provider, name)
+		model = ai.LookupModel(provider, name)
 		if model == nil {
 			return nil, fmt.Errorf("no model named %q for provider %q", name, provider)
 		}
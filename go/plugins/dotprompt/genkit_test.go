@@ -73,3 +73,52 @@ func TestExecute(t *testing.T) {
 		t.Errorf("fake model replied with %q, want %q", got, want)
 	}
 }
+
+func TestPromptAsTool(t *testing.T) {
+	testModel := ai.DefineModel("test", "asTool", nil, testGenerate)
+	p, err := New("TestPromptAsTool", "summarize {{topic}}", Config{Model: testModel})
+	if err != nil {
+		t.Fatal(err)
+	}
+	name, err := p.AsTool("Summarizes a topic.")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "TestPromptAsTool" {
+		t.Fatalf("tool name = %q", name)
+	}
+	out, err := ai.RunTool(context.Background(), name, map[string]any{"topic": "go"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := out["text"], `AI reply to "summarize go"`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestRenderOnly(t *testing.T) {
+	p, err := New("TestRenderOnly", "hello {{name}}", Config{
+		ModelName:        "test/chat",
+		GenerationConfig: &ai.GenerationCommonConfig{Temperature: 0.3},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, modelName, err := p.Render(context.Background(), &PromptRequest{
+		Variables: map[string]any{"name": "world"},
+		Model:     "test/override",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := modelName, "test/override"; got != want {
+		t.Errorf("model name = %q, want %q", got, want)
+	}
+	if got, want := req.Messages[0].Content[0].Text, "hello world"; got != want {
+		t.Errorf("rendered text = %q, want %q", got, want)
+	}
+	cfg, ok := req.Config.(*ai.GenerationCommonConfig)
+	if !ok || cfg.Temperature != 0.3 {
+		t.Errorf("config = %+v, want temperature 0.3", req.Config)
+	}
+}
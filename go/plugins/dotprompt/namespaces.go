@@ -0,0 +1,113 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dotprompt
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/firebase/genkit/go/ai"
+	"gopkg.in/yaml.v3"
+)
+
+// This file supports organizing large prompt libraries into namespaces.
+// A namespace is a subdirectory of the prompt directory; the prompt name
+// includes the subdirectory, so [Open]("billing/dispute") reads the file
+// billing/dispute.prompt. A namespace may contain a _defaults.yaml file
+// whose model, candidates, and config settings are inherited by the
+// prompts in that namespace that don't set their own.
+
+// List returns the names of the prompts in the given namespace, sorted.
+// A prompt's name is its path under the prompt directory with the
+// ".prompt" extension removed, using "/" as the separator.
+// The empty namespace lists every prompt, in any namespace or none.
+// Files whose base name starts with "_" (like schema and defaults files)
+// are not prompts and are skipped.
+func List(namespace string) ([]string, error) {
+	if promptDirectory == "" {
+		return nil, errors.New("missing call to dotprompt.SetDirectory")
+	}
+	root := filepath.Join(promptDirectory, filepath.FromSlash(namespace))
+	var names []string
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(d.Name(), ".prompt") || strings.HasPrefix(d.Name(), "_") {
+			return nil
+		}
+		rel, err := filepath.Rel(promptDirectory, p)
+		if err != nil {
+			return err
+		}
+		names = append(names, strings.TrimSuffix(filepath.ToSlash(rel), ".prompt"))
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("dotprompt: listing namespace %q: %w", namespace, err)
+	}
+	return names, nil
+}
+
+// namespaceDefaults is the contents of a namespace's _defaults.yaml file.
+type namespaceDefaults struct {
+	Model      string                     `yaml:"model,omitempty"`
+	Candidates int                        `yaml:"candidates,omitempty"`
+	Config     *ai.GenerationCommonConfig `yaml:"config,omitempty"`
+}
+
+// namespaceDefaultsFor reads the _defaults.yaml file of the namespace
+// containing the named prompt. It returns nil if there is none.
+func namespaceDefaultsFor(name string) (*namespaceDefaults, error) {
+	namespace := path.Dir(name)
+	if namespace == "." {
+		return nil, nil
+	}
+	fileName := filepath.Join(promptDirectory, filepath.FromSlash(namespace), "_defaults.yaml")
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("dotprompt: reading namespace defaults %q: %w", fileName, err)
+	}
+	var d namespaceDefaults
+	if err := yaml.Unmarshal(data, &d); err != nil {
+		return nil, fmt.Errorf("dotprompt: parsing namespace defaults %q: %w", fileName, err)
+	}
+	return &d, nil
+}
+
+// applyNamespaceDefaults fills in prompt config settings that the prompt
+// itself did not set from its namespace's defaults.
+func applyNamespaceDefaults(p *Prompt, d *namespaceDefaults) {
+	if d == nil {
+		return
+	}
+	if p.ModelName == "" && p.Model == nil {
+		p.ModelName = d.Model
+	}
+	if p.Candidates == 0 {
+		p.Candidates = d.Candidates
+	}
+	if p.GenerationConfig == nil {
+		p.GenerationConfig = d.Config
+	}
+}
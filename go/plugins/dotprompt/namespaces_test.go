@@ -0,0 +1,84 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dotprompt
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNamespaces(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "billing"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"greeting.prompt":        "hello",
+		"billing/dispute.prompt": "dispute for {{customer}}",
+		"billing/refund.prompt":  "---\nmodel: googleai/gemini-1.5-pro\n---\nrefund",
+		"billing/_defaults.yaml": "model: googleai/gemini-1.0-pro\nconfig:\n  temperature: 0.2\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	defer SetDirectory("")
+	SetDirectory(dir)
+
+	t.Run("List", func(t *testing.T) {
+		got, err := List("billing")
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []string{"billing/dispute", "billing/refund"}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("mismatch (-want, +got):\n%s", diff)
+		}
+		all, err := List("")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(all) != 3 {
+			t.Errorf("List(\"\") returned %v, want 3 prompts", all)
+		}
+	})
+
+	t.Run("InheritedDefaults", func(t *testing.T) {
+		p, err := Open("billing/dispute")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := p.ModelName, "googleai/gemini-1.0-pro"; got != want {
+			t.Errorf("ModelName = %q, want %q", got, want)
+		}
+		if p.GenerationConfig == nil || p.GenerationConfig.Temperature != 0.2 {
+			t.Errorf("GenerationConfig = %+v, want temperature 0.2", p.GenerationConfig)
+		}
+	})
+
+	t.Run("OwnSettingsWin", func(t *testing.T) {
+		p, err := Open("billing/refund")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := p.ModelName, "googleai/gemini-1.5-pro"; got != want {
+			t.Errorf("ModelName = %q, want %q", got, want)
+		}
+	})
+}
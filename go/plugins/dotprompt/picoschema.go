@@ -62,6 +62,16 @@ func parsePico(val any) (*jsonschema.Schema, error) {
 		switch typ {
 		case "string", "boolean", "null", "number", "integer":
 		default:
+			// Not a scalar type; it may be the name of a registered schema.
+			if s := LookupSchema(typ); s != nil {
+				if found {
+					// Copy so the description doesn't modify the registered schema.
+					c := *s
+					c.Description = strings.TrimSpace(desc)
+					return &c, nil
+				}
+				return s, nil
+			}
 			return nil, fmt.Errorf("picoschema: unsupported scalar type %q", typ)
 		}
 		ret := &jsonschema.Schema{
@@ -56,7 +56,7 @@ func (p *Prompt) RenderMessages(variables map[string]any) ([]*ai.Message, error)
 		maps.Copy(nv, variables)
 		variables = nv
 	}
-	str, err := p.Template.Exec(variables)
+	str, err := p.render(variables)
 	if err != nil {
 		return nil, err
 	}
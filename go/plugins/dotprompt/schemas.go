@@ -0,0 +1,145 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dotprompt
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/invopop/jsonschema"
+)
+
+// This file implements a registry of named JSON schemas that picoschema
+// type references in .prompt files can resolve against.
+
+var (
+	schemasMu sync.Mutex
+	schemas   = map[string]*jsonschema.Schema{}
+)
+
+// DefineSchema registers a named JSON schema.
+// Prompt files can then refer to the schema by name wherever picoschema
+// accepts a scalar type.
+// DefineSchema panics if a schema with the same name is already registered.
+func DefineSchema(name string, schema *jsonschema.Schema) {
+	schemasMu.Lock()
+	defer schemasMu.Unlock()
+	if _, ok := schemas[name]; ok {
+		panic(fmt.Sprintf("dotprompt: schema %q is already defined", name))
+	}
+	schemas[name] = schema
+}
+
+// DefineSchemaVersion registers a named JSON schema under an explicit
+// version, for example DefineSchemaVersion("Recipe", "v2", schema).
+// The schema is registered both as "name@version" and, as the most recent
+// registration, under the bare name. Prompt files that must not change
+// behavior when the schema evolves can pin a version by referring to
+// "name@version".
+//
+// If a previous version of the schema exists, DefineSchemaVersion verifies
+// that the new version is backward compatible with it: a field that the new
+// version requires but the previous one did not would silently break every
+// prompt still using the old shape, so it is reported as an error.
+func DefineSchemaVersion(name, version string, schema *jsonschema.Schema) error {
+	versioned := name + "@" + version
+	schemasMu.Lock()
+	defer schemasMu.Unlock()
+	if _, ok := schemas[versioned]; ok {
+		return fmt.Errorf("dotprompt: schema %q is already defined", versioned)
+	}
+	if prev, ok := schemas[name]; ok {
+		if err := checkSchemaCompatible(prev, schema); err != nil {
+			return fmt.Errorf("dotprompt: schema %q is not compatible with the previous version: %w", versioned, err)
+		}
+	}
+	schemas[versioned] = schema
+	schemas[name] = schema
+	return nil
+}
+
+// checkSchemaCompatible reports whether new is backward compatible with old.
+// It only checks the properties of top-level object schemas, which is where
+// incompatibilities between prompt schema versions show up in practice.
+func checkSchemaCompatible(old, new *jsonschema.Schema) error {
+	for _, req := range new.Required {
+		if !slices.Contains(old.Required, req) {
+			return fmt.Errorf("field %q is newly required", req)
+		}
+	}
+	if old.Properties != nil && new.Properties != nil {
+		for p := old.Properties.Oldest(); p != nil; p = p.Next() {
+			np, ok := new.Properties.Get(p.Key)
+			if !ok {
+				continue // removing an optional property is fine
+			}
+			if p.Value.Type != "" && np.Type != "" && p.Value.Type != np.Type {
+				return fmt.Errorf("field %q changed type from %q to %q", p.Key, p.Value.Type, np.Type)
+			}
+		}
+	}
+	return nil
+}
+
+// LookupSchema returns the schema registered under name,
+// either by [DefineSchema] or by [LoadSchemas].
+// It returns nil if there is none.
+func LookupSchema(name string) *jsonschema.Schema {
+	schemasMu.Lock()
+	defer schemasMu.Unlock()
+	return schemas[name]
+}
+
+// LoadSchemas reads every file named "_schema.NAME.json" in the prompt
+// directory and registers its contents as the JSON schema NAME, so that
+// prompt files can reference schemas that are stored alongside them
+// without a corresponding Go struct.
+// It is called automatically by [SetDirectory].
+func LoadSchemas() error {
+	if promptDirectory == "" {
+		return nil
+	}
+	entries, err := os.ReadDir(promptDirectory)
+	if err != nil {
+		return fmt.Errorf("dotprompt: failed to read prompt directory: %w", err)
+	}
+	for _, e := range entries {
+		name, ok := strings.CutPrefix(e.Name(), "_schema.")
+		if !ok {
+			continue
+		}
+		name, ok = strings.CutSuffix(name, ".json")
+		if !ok {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(promptDirectory, e.Name()))
+		if err != nil {
+			return fmt.Errorf("dotprompt: failed to read schema file %q: %w", e.Name(), err)
+		}
+		var schema jsonschema.Schema
+		if err := json.Unmarshal(data, &schema); err != nil {
+			return fmt.Errorf("dotprompt: failed to parse schema file %q: %w", e.Name(), err)
+		}
+		schemasMu.Lock()
+		schemas[name] = &schema
+		schemasMu.Unlock()
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dotprompt
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/invopop/jsonschema"
+)
+
+func TestDefineSchemaVersion(t *testing.T) {
+	v1 := &jsonschema.Schema{Type: "object", Required: []string{"name"}}
+	if err := DefineSchemaVersion("TestRecipe", "v1", v1); err != nil {
+		t.Fatal(err)
+	}
+	if LookupSchema("TestRecipe@v1") == nil {
+		t.Error("versioned schema not registered")
+	}
+	if LookupSchema("TestRecipe") != v1 {
+		t.Error("bare name does not resolve to latest version")
+	}
+
+	// A newly required field is a breaking change.
+	v2 := &jsonschema.Schema{Type: "object", Required: []string{"name", "servings"}}
+	if err := DefineSchemaVersion("TestRecipe", "v2", v2); err == nil {
+		t.Error("got nil, want compatibility error")
+	}
+
+	// Relaxing a requirement is fine.
+	v3 := &jsonschema.Schema{Type: "object"}
+	if err := DefineSchemaVersion("TestRecipe", "v3", v3); err != nil {
+		t.Error(err)
+	}
+	if LookupSchema("TestRecipe") != v3 {
+		t.Error("bare name not updated to latest version")
+	}
+}
+
+func TestLoadSchemas(t *testing.T) {
+	dir := t.TempDir()
+	data := `{"type": "object", "properties": {"name": {"type": "string"}}, "required": ["name"]}`
+	if err := os.WriteFile(filepath.Join(dir, "_schema.Person.json"), []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	defer SetDirectory("")
+	SetDirectory(dir)
+
+	s := LookupSchema("Person")
+	if s == nil {
+		t.Fatal("schema Person not registered")
+	}
+	if s.Type != "object" {
+		t.Errorf("got type %q, want %q", s.Type, "object")
+	}
+
+	// A picoschema scalar type can reference the schema by name.
+	got, err := picoschemaToJSONSchema("Person, the person")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Type != "object" || got.Description != "the person" {
+		t.Errorf("got (%q, %q), want (%q, %q)", got.Type, got.Description, "object", "the person")
+	}
+}
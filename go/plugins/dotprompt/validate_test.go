@@ -0,0 +1,52 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dotprompt
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestValidatePrompts(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"good.prompt": "hello {{name}}",
+		"bad.prompt":  "---\noutput:\n  format: nonsense\n---\nhello",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	defer SetDirectory("")
+	SetDirectory(dir)
+
+	err := ValidatePrompts()
+	if err == nil {
+		t.Fatal("expected an error for the bad prompt file")
+	}
+	if !strings.Contains(err.Error(), "nonsense") {
+		t.Errorf("error %q does not mention the parse failure", err)
+	}
+
+	if err := os.Remove(filepath.Join(dir, "bad.prompt")); err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidatePrompts(); err != nil {
+		t.Errorf("ValidatePrompts() = %v, want nil", err)
+	}
+}
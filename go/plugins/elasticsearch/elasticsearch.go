@@ -0,0 +1,231 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package elasticsearch implements a genkit plugin for Elasticsearch
+// and OpenSearch. This defines an indexer and a retriever.
+//
+// Documents are stored with their text in a "content" field, their
+// embedding in an "embedding" dense_vector field, and their metadata
+// under "metadata". Retrieval runs a hybrid query: kNN over the
+// embedding combined with a BM25 match on the text, with optional
+// metadata equality filters via [RetrieverOptions].
+//
+// The server address is passed to [Init]; if empty it is read from the
+// ELASTICSEARCH_URL environment variable. An optional API key is sent
+// as an "ApiKey" Authorization header.
+package elasticsearch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// addressEnv is the environment variable to use for the server address.
+const addressEnv = "ELASTICSEARCH_URL"
+
+// httpClient is the client used for requests to the server,
+// http.DefaultClient unless overridden with [SetHTTPClient].
+var httpClient = http.DefaultClient
+
+// SetHTTPClient sets the HTTP client used for all requests to the
+// server. Set it to route through a proxy or to use mTLS or a custom
+// CA. Call it before [Init].
+func SetHTTPClient(c *http.Client) {
+	httpClient = c
+}
+
+// A client performs Elasticsearch operations.
+type client struct {
+	address string
+	apiKey  string
+}
+
+// newClient builds a client. If address is empty it is read from the
+// ELASTICSEARCH_URL environment variable.
+func newClient(address, apiKey string) (*client, error) {
+	if address == "" {
+		address = os.Getenv(addressEnv)
+	}
+	if address == "" {
+		return nil, fmt.Errorf("elasticsearch: no server address; pass one to Init or set %s", addressEnv)
+	}
+	return &client{
+		address: strings.TrimSuffix(address, "/"),
+		apiKey:  apiKey,
+	}, nil
+}
+
+// do sends one request, decoding a JSON response into result if result
+// is non-nil.
+func (c *client) do(ctx context.Context, method, path string, body, result any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	return c.doRaw(ctx, method, path, "application/json", reader, result)
+}
+
+// doRaw is do without JSON-encoding the request body, for the bulk
+// endpoint's newline-delimited format.
+func (c *client) doRaw(ctx context.Context, method, path, contentType string, body io.Reader, result any) error {
+	req, err := http.NewRequestWithContext(ctx, method, c.address+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "ApiKey "+c.apiKey)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("elasticsearch: %s %s: %s: %s", method, path, resp.Status, data)
+	}
+	if result != nil {
+		return json.Unmarshal(data, result)
+	}
+	return nil
+}
+
+// createIndex creates an index with the mappings the plugin expects.
+// It is a no-op if the index already exists.
+func (c *client) createIndex(ctx context.Context, name string, dimensions int) error {
+	body := map[string]any{
+		"mappings": map[string]any{
+			"properties": map[string]any{
+				"content": map[string]any{"type": "text"},
+				"embedding": map[string]any{
+					"type":       "dense_vector",
+					"dims":       dimensions,
+					"index":      true,
+					"similarity": "cosine",
+				},
+				"metadata": map[string]any{"type": "object", "dynamic": true},
+			},
+		},
+	}
+	err := c.do(ctx, "PUT", "/"+name, body, nil)
+	if err != nil && strings.Contains(err.Error(), "resource_already_exists_exception") {
+		return nil
+	}
+	return err
+}
+
+// deleteIndex deletes an index.
+func (c *client) deleteIndex(ctx context.Context, name string) error {
+	return c.do(ctx, "DELETE", "/"+name, nil, nil)
+}
+
+// An esDocument is the stored form of one document.
+type esDocument struct {
+	Content   string         `json:"content"`
+	Embedding []float32      `json:"embedding"`
+	Metadata  map[string]any `json:"metadata,omitempty"`
+}
+
+// bulkIndex writes documents keyed by ID with the bulk endpoint and
+// refreshes the index so they are immediately searchable.
+func (c *client) bulkIndex(ctx context.Context, index string, docs map[string]*esDocument) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for id, doc := range docs {
+		if err := enc.Encode(map[string]any{"index": map[string]any{"_index": index, "_id": id}}); err != nil {
+			return err
+		}
+		if err := enc.Encode(doc); err != nil {
+			return err
+		}
+	}
+	var result struct {
+		Errors bool `json:"errors"`
+	}
+	if err := c.doRaw(ctx, "POST", "/_bulk?refresh=true", "application/x-ndjson", &buf, &result); err != nil {
+		return err
+	}
+	if result.Errors {
+		return fmt.Errorf("elasticsearch: bulk indexing into %q reported item errors", index)
+	}
+	return nil
+}
+
+// A hit is one search result.
+type hit struct {
+	ID     string     `json:"_id"`
+	Score  float64    `json:"_score"`
+	Source esDocument `json:"_source"`
+}
+
+// search runs a hybrid kNN + BM25 query. filter holds metadata
+// equality constraints; either queryText or vector may be empty to run
+// a pure vector or pure BM25 search.
+func (c *client) search(ctx context.Context, index, queryText string, vector []float32, count int, filter map[string]any, vectorWeight, bm25Weight float64) ([]hit, error) {
+	var filters []map[string]any
+	for field, value := range filter {
+		filters = append(filters, map[string]any{
+			"term": map[string]any{"metadata." + field: value},
+		})
+	}
+	body := map[string]any{"size": count}
+	if len(vector) > 0 {
+		knn := map[string]any{
+			"field":          "embedding",
+			"query_vector":   vector,
+			"k":              count,
+			"num_candidates": 5 * count,
+			"boost":          vectorWeight,
+		}
+		if filters != nil {
+			knn["filter"] = filters
+		}
+		body["knn"] = knn
+	}
+	if queryText != "" {
+		match := map[string]any{
+			"match": map[string]any{
+				"content": map[string]any{"query": queryText, "boost": bm25Weight},
+			},
+		}
+		query := map[string]any{"bool": map[string]any{"must": match}}
+		if filters != nil {
+			query["bool"].(map[string]any)["filter"] = filters
+		}
+		body["query"] = query
+	}
+	var result struct {
+		Hits struct {
+			Hits []hit `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := c.do(ctx, "POST", "/"+index+"/_search", body, &result); err != nil {
+		return nil, err
+	}
+	return result.Hits.Hits, nil
+}
@@ -0,0 +1,172 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elasticsearch
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+)
+
+// fakeES implements just enough of the Elasticsearch REST API for the
+// tests: index creation, bulk indexing and search. Search returns all
+// stored documents that satisfy the metadata filters, in insertion
+// order.
+type fakeES struct {
+	t          *testing.T
+	created    []string
+	docs       map[string]esDocument // by ID
+	order      []string
+	lastSearch map[string]any
+}
+
+func (f *fakeES) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == "PUT":
+		f.created = append(f.created, strings.TrimPrefix(r.URL.Path, "/"))
+		json.NewEncoder(w).Encode(map[string]any{"acknowledged": true})
+	case r.URL.Path == "/_bulk":
+		scanner := bufio.NewScanner(r.Body)
+		for scanner.Scan() {
+			var action struct {
+				Index struct {
+					ID string `json:"_id"`
+				} `json:"index"`
+			}
+			if err := json.Unmarshal(scanner.Bytes(), &action); err != nil || !scanner.Scan() {
+				f.t.Error("malformed bulk body")
+				return
+			}
+			var doc esDocument
+			if err := json.Unmarshal(scanner.Bytes(), &doc); err != nil {
+				f.t.Error(err)
+				return
+			}
+			if _, ok := f.docs[action.Index.ID]; !ok {
+				f.order = append(f.order, action.Index.ID)
+			}
+			f.docs[action.Index.ID] = doc
+		}
+		json.NewEncoder(w).Encode(map[string]any{"errors": false})
+	case strings.HasSuffix(r.URL.Path, "/_search"):
+		if err := json.NewDecoder(r.Body).Decode(&f.lastSearch); err != nil {
+			f.t.Error(err)
+			return
+		}
+		var hits []map[string]any
+		for _, id := range f.order {
+			doc := f.docs[id]
+			if !f.matches(doc) {
+				continue
+			}
+			hits = append(hits, map[string]any{
+				"_id": id, "_score": 1.5, "_source": doc,
+			})
+		}
+		json.NewEncoder(w).Encode(map[string]any{
+			"hits": map[string]any{"hits": hits},
+		})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// matches applies the term filters of the last search to a document.
+func (f *fakeES) matches(doc esDocument) bool {
+	knn, _ := f.lastSearch["knn"].(map[string]any)
+	filters, _ := knn["filter"].([]any)
+	for _, fl := range filters {
+		term := fl.(map[string]any)["term"].(map[string]any)
+		for field, want := range term {
+			got := doc.Metadata[strings.TrimPrefix(field, "metadata.")]
+			if got != want {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func TestIndexAndRetrieve(t *testing.T) {
+	fake := &fakeES{t: t, docs: map[string]esDocument{}}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+	if err := Init(context.Background(), srv.URL, "testkey"); err != nil {
+		t.Fatal(err)
+	}
+
+	embedder := ai.DefineEmbedder("fake", "esEmbedder", func(ctx context.Context, req *ai.EmbedRequest) ([]float32, error) {
+		return []float32{float32(len(req.Document.Content[0].Text)), 1}, nil
+	})
+	cfg := Config{Index: "docs", Embedder: embedder}
+	indexer, err := DefineIndexer(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	retriever, err := DefineRetriever(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := CreateIndex(context.Background(), "docs", 2); err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.created) != 1 || fake.created[0] != "docs" {
+		t.Errorf("created indexes = %v, want [docs]", fake.created)
+	}
+
+	err = indexer.Index(context.Background(), &ai.IndexerRequest{Documents: []*ai.Document{
+		ai.DocumentFromText("hello world", map[string]any{"lang": "en"}),
+		ai.DocumentFromText("bonjour", map[string]any{"lang": "fr"}),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.docs) != 2 {
+		t.Fatalf("stored %d documents, want 2", len(fake.docs))
+	}
+
+	resp, err := retriever.Retrieve(context.Background(), &ai.RetrieverRequest{
+		Document: ai.DocumentFromText("hello", nil),
+		Options:  &RetrieverOptions{Count: 5, Filter: map[string]any{"lang": "en"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Documents) != 1 {
+		t.Fatalf("got %d documents, want 1 after filtering", len(resp.Documents))
+	}
+	d := resp.Documents[0]
+	if d.Content[0].Text != "hello world" {
+		t.Errorf("text = %q", d.Content[0].Text)
+	}
+	if d.Metadata["score"] != 1.5 {
+		t.Errorf("score = %v, want 1.5", d.Metadata["score"])
+	}
+
+	// The search request is a hybrid query: both halves present.
+	if _, ok := fake.lastSearch["knn"]; !ok {
+		t.Error("search body has no knn clause")
+	}
+	if _, ok := fake.lastSearch["query"]; !ok {
+		t.Error("search body has no BM25 query clause")
+	}
+}
@@ -0,0 +1,250 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elasticsearch
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/firebase/genkit/go/ai"
+)
+
+const provider = "elasticsearch"
+
+var state struct {
+	mu      sync.Mutex
+	initted bool
+	client  *client
+}
+
+// Init initializes the Elasticsearch plugin.
+// If address is the empty string, it is read from the
+// ELASTICSEARCH_URL environment variable. apiKey may be empty for
+// unauthenticated servers.
+func Init(ctx context.Context, address, apiKey string) (err error) {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	if state.initted {
+		panic("elasticsearch.Init already called")
+	}
+	defer func() {
+		if err != nil {
+			err = fmt.Errorf("elasticsearch.Init: %w", err)
+		}
+	}()
+
+	client, err := newClient(address, apiKey)
+	if err != nil {
+		return err
+	}
+	state.client = client
+	state.initted = true
+	return nil
+}
+
+// Config provides configuration options for [DefineIndexer] and
+// [DefineRetriever].
+type Config struct {
+	// The index to use. Required.
+	Index string
+	// Embedder to use. Required.
+	Embedder        *ai.Embedder
+	EmbedderOptions any
+	// Weights for the two halves of the hybrid query; both default
+	// to 1.
+	VectorWeight float64
+	BM25Weight   float64
+}
+
+// CreateIndex creates the named index with the field mappings the
+// plugin expects, with dense vectors of the given dimensionality. It
+// is a no-op if the index already exists.
+func CreateIndex(ctx context.Context, name string, dimensions int) error {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	if !state.initted {
+		panic("elasticsearch.Init not called")
+	}
+	return state.client.createIndex(ctx, name, dimensions)
+}
+
+// DeleteIndex deletes the named index.
+func DeleteIndex(ctx context.Context, name string) error {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	if !state.initted {
+		panic("elasticsearch.Init not called")
+	}
+	return state.client.deleteIndex(ctx, name)
+}
+
+// DefineIndexer defines an Indexer with the given configuration.
+func DefineIndexer(ctx context.Context, cfg Config) (*ai.Indexer, error) {
+	ds, err := newDocStore(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return ai.DefineIndexer(provider, cfg.Index, ds.Index), nil
+}
+
+// DefineRetriever defines a Retriever with the given configuration.
+func DefineRetriever(ctx context.Context, cfg Config) (*ai.Retriever, error) {
+	ds, err := newDocStore(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return ai.DefineRetriever(provider, cfg.Index, ds.Retrieve), nil
+}
+
+// Indexer returns the indexer with the given index name.
+func Indexer(name string) *ai.Indexer {
+	return ai.LookupIndexer(provider, name)
+}
+
+// Retriever returns the retriever with the given index name.
+func Retriever(name string) *ai.Retriever {
+	return ai.LookupRetriever(provider, name)
+}
+
+func newDocStore(cfg Config) (*docStore, error) {
+	state.mu.Lock()
+	defer state.mu.Unlock()
+	if !state.initted {
+		panic("elasticsearch.Init not called")
+	}
+	if cfg.Index == "" {
+		return nil, errors.New("Index required")
+	}
+	if cfg.Embedder == nil {
+		return nil, errors.New("Embedder required")
+	}
+	if cfg.VectorWeight == 0 {
+		cfg.VectorWeight = 1
+	}
+	if cfg.BM25Weight == 0 {
+		cfg.BM25Weight = 1
+	}
+	return &docStore{
+		client: state.client,
+		cfg:    cfg,
+	}, nil
+}
+
+// RetrieverOptions may be passed in the Options field
+// of [ai.RetrieverRequest] to pass options to Elasticsearch.
+// The Options field should be either nil or a value of type *RetrieverOptions.
+type RetrieverOptions struct {
+	// Count is the maximum number of documents to retrieve;
+	// the default is 10.
+	Count int `json:"count,omitempty"`
+	// Filter holds metadata fields a document must match exactly,
+	// e.g. {"lang": "en"}.
+	Filter map[string]any `json:"filter,omitempty"`
+}
+
+// docStore implements indexing and retrieval against one index.
+type docStore struct {
+	client *client
+	cfg    Config
+}
+
+// Index implements the genkit Retriever.Index method.
+func (ds *docStore) Index(ctx context.Context, req *ai.IndexerRequest) error {
+	if len(req.Documents) == 0 {
+		return nil
+	}
+	docs := make(map[string]*esDocument, len(req.Documents))
+	for _, doc := range req.Documents {
+		vals, err := ds.cfg.Embedder.Embed(ctx, &ai.EmbedRequest{
+			Document: doc,
+			Options:  ds.cfg.EmbedderOptions,
+		})
+		if err != nil {
+			return fmt.Errorf("elasticsearch index embedding failed: %v", err)
+		}
+		id, err := docID(doc)
+		if err != nil {
+			return err
+		}
+		var sb strings.Builder
+		for _, p := range doc.Content {
+			sb.WriteString(p.Text)
+		}
+		docs[id] = &esDocument{
+			Content:   sb.String(),
+			Embedding: vals,
+			Metadata:  doc.Metadata,
+		}
+	}
+	return ds.client.bulkIndex(ctx, ds.cfg.Index, docs)
+}
+
+// Retrieve implements the genkit Retriever.Retrieve method.
+func (ds *docStore) Retrieve(ctx context.Context, req *ai.RetrieverRequest) (*ai.RetrieverResponse, error) {
+	count := 10
+	var filter map[string]any
+	if req.Options != nil {
+		ropt, ok := req.Options.(*RetrieverOptions)
+		if !ok {
+			return nil, fmt.Errorf("elasticsearch.Retrieve options have type %T, want %T", req.Options, &RetrieverOptions{})
+		}
+		if ropt.Count > 0 {
+			count = ropt.Count
+		}
+		filter = ropt.Filter
+	}
+
+	vals, err := ds.cfg.Embedder.Embed(ctx, &ai.EmbedRequest{
+		Document: req.Document,
+		Options:  ds.cfg.EmbedderOptions,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("elasticsearch retrieve embedding failed: %v", err)
+	}
+	var sb strings.Builder
+	for _, p := range req.Document.Content {
+		sb.WriteString(p.Text)
+	}
+
+	hits, err := ds.client.search(ctx, ds.cfg.Index, sb.String(), vals, count, filter, ds.cfg.VectorWeight, ds.cfg.BM25Weight)
+	if err != nil {
+		return nil, err
+	}
+	var docs []*ai.Document
+	for _, h := range hits {
+		metadata := h.Source.Metadata
+		if metadata == nil {
+			metadata = map[string]any{}
+		}
+		metadata["score"] = h.Score
+		docs = append(docs, ai.DocumentFromText(h.Source.Content, metadata))
+	}
+	return &ai.RetrieverResponse{Documents: docs}, nil
+}
+
+// docID returns the ID to use for a Document.
+// This is intended to be the same as the genkit Typescript computation.
+func docID(doc *ai.Document) (string, error) {
+	b, err := json.Marshal(doc)
+	if err != nil {
+		return "", fmt.Errorf("elasticsearch: error marshaling document: %v", err)
+	}
+	return fmt.Sprintf("%02x", md5.Sum(b)), nil
+}
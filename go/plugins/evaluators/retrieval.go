@@ -0,0 +1,152 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package evaluators provides metrics for evaluating Genkit components.
+// It currently covers retrieval quality: context recall, precision and
+// MRR scored against ground-truth relevant documents, and a helper to
+// run a retriever over a dataset and aggregate the scores per
+// configuration.
+package evaluators
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/firebase/genkit/go/ai"
+)
+
+// A RetrievalSample is one dataset entry: a query and the documents
+// that a correct retrieval should return. Ground truth entries match a
+// retrieved document either by its "id" metadata or by its exact
+// (whitespace-trimmed) text.
+type RetrievalSample struct {
+	Query       string   `json:"query"`
+	GroundTruth []string `json:"groundTruth"`
+}
+
+// RetrievalScores are the metrics for one retrieval or, averaged, for a
+// dataset.
+type RetrievalScores struct {
+	// Recall is the fraction of ground-truth documents that were
+	// retrieved.
+	Recall float64 `json:"recall"`
+	// Precision is the fraction of retrieved documents that are in the
+	// ground truth.
+	Precision float64 `json:"precision"`
+	// MRR is the reciprocal rank of the first relevant retrieved
+	// document (mean reciprocal rank when averaged).
+	MRR float64 `json:"mrr"`
+}
+
+// ScoreRetrieval scores one retrieval against its ground truth.
+func ScoreRetrieval(retrieved []*ai.Document, groundTruth []string) RetrievalScores {
+	truth := map[string]bool{}
+	for _, g := range groundTruth {
+		truth[strings.TrimSpace(g)] = true
+	}
+	var scores RetrievalScores
+	found := map[string]bool{}
+	relevantRetrieved := 0
+	for rank, d := range retrieved {
+		key := documentKey(d)
+		if !truth[key] {
+			continue
+		}
+		relevantRetrieved++
+		if scores.MRR == 0 {
+			scores.MRR = 1 / float64(rank+1)
+		}
+		found[key] = true
+	}
+	if len(truth) > 0 {
+		scores.Recall = float64(len(found)) / float64(len(truth))
+	}
+	if len(retrieved) > 0 {
+		scores.Precision = float64(relevantRetrieved) / float64(len(retrieved))
+	}
+	return scores
+}
+
+// A RetrievalReport aggregates scores over a dataset for one retriever
+// configuration.
+type RetrievalReport struct {
+	// Samples is the number of dataset entries scored.
+	Samples int `json:"samples"`
+	// Mean holds the metric means over the dataset.
+	Mean RetrievalScores `json:"mean"`
+	// PerSample holds the individual scores, in dataset order.
+	PerSample []RetrievalScores `json:"perSample"`
+}
+
+// EvaluateRetriever runs the retriever over each dataset entry, passing
+// options as the retriever options, and aggregates the scores. It is
+// intended to be called from an evaluation flow.
+func EvaluateRetriever(ctx context.Context, r *ai.Retriever, dataset []RetrievalSample, options any) (*RetrievalReport, error) {
+	report := &RetrievalReport{}
+	for _, sample := range dataset {
+		resp, err := r.Retrieve(ctx, &ai.RetrieverRequest{
+			Document: ai.DocumentFromText(sample.Query, nil),
+			Options:  options,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("evaluators.EvaluateRetriever: query %q: %w", sample.Query, err)
+		}
+		scores := ScoreRetrieval(resp.Documents, sample.GroundTruth)
+		report.PerSample = append(report.PerSample, scores)
+		report.Mean.Recall += scores.Recall
+		report.Mean.Precision += scores.Precision
+		report.Mean.MRR += scores.MRR
+		report.Samples++
+	}
+	if report.Samples > 0 {
+		n := float64(report.Samples)
+		report.Mean.Recall /= n
+		report.Mean.Precision /= n
+		report.Mean.MRR /= n
+	}
+	return report, nil
+}
+
+// EvaluateConfigs runs EvaluateRetriever once per named retriever
+// configuration and returns the reports keyed by configuration name,
+// for comparing, say, different top-k or filter settings over the same
+// dataset.
+func EvaluateConfigs(ctx context.Context, r *ai.Retriever, dataset []RetrievalSample, configs map[string]any) (map[string]*RetrievalReport, error) {
+	reports := make(map[string]*RetrievalReport, len(configs))
+	for name, options := range configs {
+		report, err := EvaluateRetriever(ctx, r, dataset, options)
+		if err != nil {
+			return nil, fmt.Errorf("evaluators.EvaluateConfigs: config %q: %w", name, err)
+		}
+		reports[name] = report
+	}
+	return reports, nil
+}
+
+// documentKey is the identity used to match a retrieved document
+// against ground truth: its "id" metadata if present, else its trimmed
+// text.
+func documentKey(d *ai.Document) string {
+	if id, ok := d.Metadata["id"].(string); ok && id != "" {
+		return id
+	}
+	var b strings.Builder
+	for _, p := range d.Content {
+		if p.IsText() {
+			b.WriteString(p.Text)
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
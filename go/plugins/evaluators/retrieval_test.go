@@ -0,0 +1,100 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package evaluators
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+)
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestScoreRetrieval(t *testing.T) {
+	retrieved := []*ai.Document{
+		ai.DocumentFromText("irrelevant", nil),
+		ai.DocumentFromText("relevant one", nil),
+		ai.DocumentFromText("relevant two", nil),
+		ai.DocumentFromText("also irrelevant", nil),
+	}
+	scores := ScoreRetrieval(retrieved, []string{"relevant one", "relevant two", "missed"})
+	if !almostEqual(scores.Recall, 2.0/3) {
+		t.Errorf("recall = %v, want 2/3", scores.Recall)
+	}
+	if !almostEqual(scores.Precision, 0.5) {
+		t.Errorf("precision = %v, want 0.5", scores.Precision)
+	}
+	if !almostEqual(scores.MRR, 0.5) {
+		t.Errorf("mrr = %v, want 0.5 (first relevant at rank 2)", scores.MRR)
+	}
+}
+
+func TestScoreRetrievalByID(t *testing.T) {
+	retrieved := []*ai.Document{
+		ai.DocumentFromText("some text", map[string]any{"id": "d7"}),
+	}
+	scores := ScoreRetrieval(retrieved, []string{"d7"})
+	if scores.Recall != 1 || scores.Precision != 1 || scores.MRR != 1 {
+		t.Errorf("scores = %+v, want all 1", scores)
+	}
+}
+
+func TestEvaluateConfigs(t *testing.T) {
+	// The retriever's options select how many documents it returns.
+	corpus := []string{"alpha", "beta", "gamma"}
+	r := ai.DefineRetriever("test", "eval", func(ctx context.Context, req *ai.RetrieverRequest) (*ai.RetrieverResponse, error) {
+		k := req.Options.(int)
+		var docs []*ai.Document
+		for _, text := range corpus[:k] {
+			docs = append(docs, ai.DocumentFromText(text, nil))
+		}
+		return &ai.RetrieverResponse{Documents: docs}, nil
+	})
+	dataset := []RetrievalSample{
+		{Query: "q1", GroundTruth: []string{"alpha"}},
+		{Query: "q2", GroundTruth: []string{"beta"}},
+	}
+	reports, err := EvaluateConfigs(context.Background(), r, dataset, map[string]any{
+		"top1": 1,
+		"top3": 3,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := reports["top1"].Mean.Recall; !almostEqual(got, 0.5) {
+		t.Errorf("top1 recall = %v, want 0.5", got)
+	}
+	if got := reports["top3"].Mean.Recall; !almostEqual(got, 1) {
+		t.Errorf("top3 recall = %v, want 1", got)
+	}
+	if got := reports["top3"].Mean.Precision; !almostEqual(got, 1.0/3) {
+		t.Errorf("top3 precision = %v, want 1/3", got)
+	}
+	if reports["top1"].Samples != len(dataset) {
+		t.Errorf("samples = %d, want %d", reports["top1"].Samples, len(dataset))
+	}
+}
+
+func TestDocumentKeyTrimsText(t *testing.T) {
+	d := ai.DocumentFromText("  padded  ", nil)
+	if got := documentKey(d); got != strings.TrimSpace("  padded  ") {
+		t.Errorf("documentKey = %q", got)
+	}
+}
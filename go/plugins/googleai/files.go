@@ -0,0 +1,117 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package googleai
+
+import (
+	"context"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/plugins/internal/uri"
+	"github.com/google/generative-ai-go/genai"
+)
+
+// This file implements support for the Gemini Files API, which lets large
+// media inputs be referenced by URI instead of being sent inline with every
+// request. Uploaded files are kept by the service for a limited time and
+// then deleted automatically; call [DeleteFile] to remove one earlier.
+
+// fileUploadThreshold is the inline media size, in bytes, above which
+// requests automatically upload the media via the Files API.
+// Zero means automatic uploading is disabled.
+var fileUploadThreshold int
+
+// SetFileUploadThreshold enables automatic uploading of inline media larger
+// than n bytes. Before a request is sent, any such media part is uploaded
+// via the Files API and replaced with a reference to the uploaded file's
+// URI. Set n to zero to disable automatic uploading again.
+// SetFileUploadThreshold is not safe to call concurrently with generation.
+func SetFileUploadThreshold(n int) {
+	fileUploadThreshold = n
+}
+
+// UploadFile uploads media via the Files API and returns a media [ai.Part]
+// referencing the uploaded file by URI. The part can be used in requests to
+// models that support media.
+func UploadFile(ctx context.Context, r io.Reader, contentType string) (*ai.Part, error) {
+	if !state.initted {
+		panic("googleai.Init not called")
+	}
+	f, err := state.client.UploadFile(ctx, "", r, &genai.UploadFileOptions{MIMEType: contentType})
+	if err != nil {
+		return nil, err
+	}
+	return ai.NewMediaPart(f.MIMEType, f.URI), nil
+}
+
+// UploadFilePath is like [UploadFile], reading the media from a local file.
+func UploadFilePath(ctx context.Context, path, contentType string) (*ai.Part, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return UploadFile(ctx, f, contentType)
+}
+
+// DeleteFile deletes a file previously uploaded with [UploadFile],
+// given the URI from the returned part.
+func DeleteFile(ctx context.Context, fileURI string) error {
+	if !state.initted {
+		panic("googleai.Init not called")
+	}
+	// The file name is the final segment of the URI, prefixed with "files/".
+	name := fileURI
+	if i := strings.LastIndexByte(name, '/'); i >= 0 {
+		name = name[i+1:]
+	}
+	return state.client.DeleteFile(ctx, "files/"+name)
+}
+
+// isFileURI reports whether a media part references a file uploaded via the
+// Files API rather than carrying inline data.
+func isFileURI(s string) bool {
+	return strings.HasPrefix(s, "https://generativelanguage.googleapis.com/")
+}
+
+// uploadLargeMedia replaces inline media parts larger than the configured
+// threshold with Files API references. It modifies the messages in place.
+func uploadLargeMedia(ctx context.Context, messages []*ai.Message) error {
+	if fileUploadThreshold <= 0 {
+		return nil
+	}
+	for _, m := range messages {
+		for _, p := range m.Content {
+			if !p.IsMedia() || !strings.HasPrefix(p.Text, "data:") {
+				continue
+			}
+			contentType, data, err := uri.Data(p)
+			if err != nil {
+				return err
+			}
+			if len(data) <= fileUploadThreshold {
+				continue
+			}
+			up, err := UploadFile(ctx, strings.NewReader(string(data)), contentType)
+			if err != nil {
+				return err
+			}
+			*p = *up
+		}
+	}
+	return nil
+}
@@ -16,15 +16,20 @@ package googleai
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"os"
+	"net/http"
 	"sync"
 
 	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/core"
 	"github.com/firebase/genkit/go/plugins/internal/uri"
 	"github.com/google/generative-ai-go/genai"
+	"google.golang.org/api/googleapi"
 	"google.golang.org/api/iterator"
 	"google.golang.org/api/option"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 const provider = "googleai"
@@ -35,6 +40,27 @@ var state struct {
 	client  *genai.Client
 }
 
+// httpClient, if set, is used for all requests to the Google AI API.
+var httpClient *http.Client
+
+// SetHTTPClient sets the HTTP client used for all requests to the
+// Google AI API. Set it to route through a proxy or to use mTLS or a
+// custom CA; the client is responsible for attaching credentials.
+// Call it before [Init].
+func SetHTTPClient(c *http.Client) {
+	httpClient = c
+}
+
+// clientOptions returns the options to create clients with, honoring
+// [SetHTTPClient].
+func clientOptions(apiKey string) []option.ClientOption {
+	opts := []option.ClientOption{option.WithAPIKey(apiKey)}
+	if httpClient != nil {
+		opts = append(opts, option.WithHTTPClient(httpClient))
+	}
+	return opts
+}
+
 var (
 	basicText = ai.ModelCapabilities{
 		Multiturn:  true,
@@ -75,16 +101,18 @@ func Init(ctx context.Context, apiKey string) (err error) {
 	}()
 
 	if apiKey == "" {
-		apiKey = os.Getenv("GOOGLE_GENAI_API_KEY")
+		// Consult the secret resolver, which by default reads the
+		// environment.
+		apiKey, _ = core.ResolveSecret(ctx, "GOOGLE_GENAI_API_KEY")
 		if apiKey == "" {
-			apiKey = os.Getenv("GOOGLE_API_KEY")
+			apiKey, _ = core.ResolveSecret(ctx, "GOOGLE_API_KEY")
 		}
 		if apiKey == "" {
 			return fmt.Errorf("googleai.Init: Google AI requires setting GOOGLE_GENAI_API_KEY or GOOGLE_API_KEY in the environment. You can get an API key at https://ai.google.dev")
 		}
 	}
 
-	client, err := genai.NewClient(ctx, option.WithAPIKey(apiKey))
+	client, err := genai.NewClient(ctx, clientOptions(apiKey)...)
 	if err != nil {
 		return err
 	}
@@ -163,7 +191,12 @@ func DefineEmbedder(name string) *ai.Embedder {
 // requires state.mu
 func defineEmbedder(name string) *ai.Embedder {
 	return ai.DefineEmbedder(provider, name, func(ctx context.Context, input *ai.EmbedRequest) ([]float32, error) {
-		em := state.client.EmbeddingModel(name)
+		client, closeClient, err := clientForRequest(ctx, state.client)
+		if err != nil {
+			return nil, err
+		}
+		defer closeClient()
+		em := client.EmbeddingModel(name)
 		parts, err := convertParts(input.Document.Content)
 		if err != nil {
 			return nil, err
@@ -194,12 +227,40 @@ type generator struct {
 	//session *genai.ChatSession // non-nil if we're in the middle of a chat
 }
 
+// clientForRequest returns the client to use for a request: a one-off
+// client if the context carries per-request credentials (see
+// [ai.WithCredentials]), and the plugin's shared client otherwise.
+// The caller must call the returned close function when done.
+func clientForRequest(ctx context.Context, shared *genai.Client) (*genai.Client, func(), error) {
+	apiKey, ok := ai.CredentialsFromContext(ctx, provider)
+	if !ok {
+		return shared, func() {}, nil
+	}
+	client, err := genai.NewClient(ctx, clientOptions(apiKey)...)
+	if err != nil {
+		return nil, nil, err
+	}
+	return client, func() { client.Close() }, nil
+}
+
 func (g *generator) generate(ctx context.Context, input *ai.GenerateRequest, cb func(context.Context, *ai.GenerateResponseChunk) error) (*ai.GenerateResponse, error) {
-	gm := g.client.GenerativeModel(g.model)
+	client, closeClient, err := clientForRequest(ctx, g.client)
+	if err != nil {
+		return nil, err
+	}
+	defer closeClient()
+	gm := client.GenerativeModel(g.model)
+
+	// Upload any oversized inline media before translating the request.
+	if err := uploadLargeMedia(ctx, input.Messages); err != nil {
+		return nil, err
+	}
 
 	// Translate from a ai.GenerateRequest to a genai request.
 	gm.SetCandidateCount(int32(input.Candidates))
 	if c, ok := input.Config.(*ai.GenerationCommonConfig); ok && c != nil {
+		// c.FrequencyPenalty, c.PresencePenalty and c.Seed are not
+		// supported by the Gemini API; ignore them.
 		if c.MaxOutputTokens != 0 {
 			gm.SetMaxOutputTokens(int32(c.MaxOutputTokens))
 		}
@@ -225,14 +286,11 @@ func (g *generator) generate(ctx context.Context, input *ai.GenerateRequest, cb
 	for len(messages) > 1 {
 		m := messages[0]
 		messages = messages[1:]
-		parts, err := convertParts(m.Content)
+		c, err := convertContent(m)
 		if err != nil {
 			return nil, err
 		}
-		cs.History = append(cs.History, &genai.Content{
-			Parts: parts,
-			Role:  string(m.Role),
-		})
+		cs.History = append(cs.History, c)
 	}
 	// The last message gets added to the parts slice.
 	var parts []genai.Part
@@ -278,7 +336,7 @@ func (g *generator) generate(ctx context.Context, input *ai.GenerateRequest, cb
 	if cb == nil {
 		resp, err := cs.SendMessage(ctx, parts...)
 		if err != nil {
-			return nil, err
+			return nil, normalizeError(err)
 		}
 		r := translateResponse(resp)
 		r.Request = input
@@ -295,7 +353,7 @@ func (g *generator) generate(ctx context.Context, input *ai.GenerateRequest, cb
 			break
 		}
 		if err != nil {
-			return nil, err
+			return nil, normalizeError(err)
 		}
 		// Send candidates to the callback.
 		for _, c := range chunk.Candidates {
@@ -318,6 +376,67 @@ func (g *generator) generate(ctx context.Context, input *ai.GenerateRequest, cb
 	return r, nil
 }
 
+// normalizeError converts an error from the Gemini SDK into a
+// [core.GenkitError], so callers can branch on [core.StatusOf] and
+// retry middleware can honor [core.IsRetryable] and [core.RetryAfter]
+// instead of matching provider error strings.
+func normalizeError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var gerr *googleapi.Error
+	if errors.As(err, &gerr) {
+		return core.NormalizeHTTPError(provider, gerr.Code, gerr.Header.Get("Retry-After"), err)
+	}
+	if s, ok := status.FromError(err); ok && s.Code() != codes.OK {
+		return normalizeGRPCError(s, err)
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return &core.GenkitError{
+			Status:    core.StatusDeadlineExceeded,
+			Provider:  provider,
+			Retryable: true,
+			Err:       err,
+		}
+	}
+	return err
+}
+
+// normalizeGRPCError maps a gRPC status error into a [core.GenkitError].
+func normalizeGRPCError(s *status.Status, err error) error {
+	ge := &core.GenkitError{
+		Provider: provider,
+		RawCode:  s.Code().String(),
+		Err:      err,
+	}
+	switch s.Code() {
+	case codes.InvalidArgument:
+		ge.Status = core.StatusInvalidArgument
+	case codes.DeadlineExceeded:
+		ge.Status = core.StatusDeadlineExceeded
+		ge.Retryable = true
+	case codes.NotFound:
+		ge.Status = core.StatusNotFound
+	case codes.PermissionDenied:
+		ge.Status = core.StatusPermissionDenied
+	case codes.Unauthenticated:
+		ge.Status = core.StatusUnauthenticated
+	case codes.ResourceExhausted:
+		ge.Status = core.StatusResourceExhausted
+		ge.Retryable = true
+	case codes.FailedPrecondition:
+		ge.Status = core.StatusFailedPrecondition
+	case codes.Aborted:
+		ge.Status = core.StatusAborted
+	case codes.Unavailable:
+		ge.Status = core.StatusUnavailable
+		ge.Retryable = true
+	default:
+		ge.Status = core.StatusInternal
+	}
+	return ge
+}
+
 // translateCandidate translates from a genai.GenerateContentResponse to an ai.GenerateResponse.
 func translateCandidate(cand *genai.Candidate) *ai.Candidate {
 	c := &ai.Candidate{}
@@ -336,6 +455,22 @@ func translateCandidate(cand *genai.Candidate) *ai.Candidate {
 	default: // Unspecified
 		c.FinishReason = ai.FinishReasonUnknown
 	}
+	if c.FinishReason == ai.FinishReasonBlocked && len(cand.SafetyRatings) > 0 {
+		// Surface which safety categories triggered the block.
+		details := &ai.FinishDetails{}
+		for _, sr := range cand.SafetyRatings {
+			if sr.Blocked {
+				details.BlockedReasons = append(details.BlockedReasons, ai.BlockedReason{
+					Category: sr.Category.String(),
+					Score:    float64(sr.Probability),
+					Blocked:  true,
+				})
+			}
+		}
+		if len(details.BlockedReasons) > 0 {
+			c.FinishDetails = details
+		}
+	}
 	m := &ai.Message{}
 	m.Role = ai.Role(cand.Content.Role)
 	for _, part := range cand.Content.Parts {
@@ -393,6 +528,9 @@ func convertPart(p *ai.Part) (genai.Part, error) {
 	case p.IsText():
 		return genai.Text(p.Text), nil
 	case p.IsMedia():
+		if isFileURI(p.Text) {
+			return genai.FileData{MIMEType: p.ContentType, URI: p.Text}, nil
+		}
 		contentType, data, err := uri.Data(p)
 		if err != nil {
 			return nil, err
@@ -0,0 +1,100 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package googleai
+
+import (
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/google/generative-ai-go/genai"
+)
+
+// This file converts conversation messages to the provider format
+// without reconverting the whole history every turn. Converted
+// messages are memoized by content hash, so a chat that resends its
+// history each request pays conversion for new messages only, and a
+// [HistoryBuilder] converts an append-only conversation incrementally.
+
+const contentCacheLimit = 4096
+
+var (
+	contentCacheMu sync.Mutex
+	contentCache   = map[string]*genai.Content{}
+)
+
+// convertContent converts one message, memoized by the message's
+// content. The returned content is shared and must not be modified.
+func convertContent(m *ai.Message) (*genai.Content, error) {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	key := fmt.Sprintf("%02x", md5.Sum(data))
+	contentCacheMu.Lock()
+	c, ok := contentCache[key]
+	contentCacheMu.Unlock()
+	if ok {
+		return c, nil
+	}
+	parts, err := convertParts(m.Content)
+	if err != nil {
+		return nil, err
+	}
+	c = &genai.Content{
+		Parts: parts,
+		Role:  string(m.Role),
+	}
+	contentCacheMu.Lock()
+	if len(contentCache) >= contentCacheLimit {
+		clear(contentCache)
+	}
+	contentCache[key] = c
+	contentCacheMu.Unlock()
+	return c, nil
+}
+
+// A HistoryBuilder incrementally converts an append-only conversation
+// to the provider format, so a long-running chat does not rebuild the
+// full provider history each turn. Use one builder per conversation
+// and pass it the conversation's messages every turn; only the
+// messages appended since the previous call are converted. If the
+// conversation shrinks — say, after truncation — the builder starts
+// over.
+type HistoryBuilder struct {
+	mu      sync.Mutex
+	history []*genai.Content
+}
+
+// Convert returns the provider contents for msgs, converting only the
+// messages not seen on a previous call. The returned slice and its
+// contents must not be modified.
+func (b *HistoryBuilder) Convert(msgs []*ai.Message) ([]*genai.Content, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(msgs) < len(b.history) {
+		b.history = b.history[:0]
+	}
+	for _, m := range msgs[len(b.history):] {
+		c, err := convertContent(m)
+		if err != nil {
+			return nil, err
+		}
+		b.history = append(b.history, c)
+	}
+	return b.history[:len(b.history):len(b.history)], nil
+}
@@ -0,0 +1,90 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package googleai
+
+import (
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+)
+
+func TestConvertContentMemoized(t *testing.T) {
+	m := ai.NewUserTextMessage("hello")
+	c1, err := convertContent(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c2, err := convertContent(ai.NewUserTextMessage("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c1 != c2 {
+		t.Error("identical message converted twice")
+	}
+	if c1.Role != string(ai.RoleUser) || len(c1.Parts) != 1 {
+		t.Errorf("unexpected conversion: %+v", c1)
+	}
+}
+
+func TestHistoryBuilder(t *testing.T) {
+	msgs := []*ai.Message{
+		ai.NewUserTextMessage("one"),
+		ai.NewUserTextMessage("two"),
+	}
+	var b HistoryBuilder
+	h1, err := b.Convert(msgs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(h1) != 2 {
+		t.Fatalf("got %d contents, want 2", len(h1))
+	}
+
+	// The next turn appends; earlier conversions are reused.
+	msgs = append(msgs, ai.NewUserTextMessage("three"))
+	h2, err := b.Convert(msgs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(h2) != 3 {
+		t.Fatalf("got %d contents, want 3", len(h2))
+	}
+	if h2[0] != h1[0] || h2[1] != h1[1] {
+		t.Error("earlier messages were reconverted")
+	}
+
+	// A shorter conversation resets the builder.
+	h3, err := b.Convert(msgs[:1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(h3) != 1 {
+		t.Fatalf("got %d contents after truncation, want 1", len(h3))
+	}
+}
+
+func BenchmarkHistoryBuilder(b *testing.B) {
+	var msgs []*ai.Message
+	for i := 0; i < 200; i++ {
+		msgs = append(msgs, ai.NewUserTextMessage("message in a long conversation"))
+	}
+	var builder HistoryBuilder
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := builder.Convert(msgs); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
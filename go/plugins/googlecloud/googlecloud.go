@@ -135,6 +135,6 @@ func setLogHandler(projectID string, level slog.Leveler) error {
 		return err
 	}
 	logger := c.Logger("genkit_log")
-	slog.SetDefault(slog.New(newHandler(level, logger.Log)))
+	slog.SetDefault(slog.New(newHandler(projectID, level, logger.Log)))
 	return nil
 }
@@ -22,19 +22,22 @@ import (
 
 	"cloud.google.com/go/logging"
 	"github.com/jba/slog/withsupport"
+	"go.opentelemetry.io/otel/trace"
 )
 
-func newHandler(level slog.Leveler, f func(logging.Entry)) *handler {
+func newHandler(projectID string, level slog.Leveler, f func(logging.Entry)) *handler {
 	if level == nil {
 		level = slog.LevelInfo
 	}
 	return &handler{
+		projectID:   projectID,
 		level:       level,
 		handleEntry: f,
 	}
 }
 
 type handler struct {
+	projectID   string
 	level       slog.Leveler
 	handleEntry func(logging.Entry)
 	goa         *withsupport.GroupOrAttrs
@@ -62,18 +65,22 @@ func (h *handler) Handle(ctx context.Context, r slog.Record) error {
 }
 
 func (h *handler) recordToEntry(ctx context.Context, r slog.Record) logging.Entry {
-	return logging.Entry{
+	e := logging.Entry{
 		Timestamp: r.Time,
 		Severity:  levelToSeverity(r.Level),
 		Payload:   recordToMap(r, h.goa.Collect()),
 		Labels:    map[string]string{"module": "genkit"},
 		// TODO: add a monitored resource
 		// Resource:       &monitoredres.MonitoredResource{},
-		// TODO: add trace information from the context.
-		// Trace:        "",
-		// SpanID:       "",
-		// TraceSampled: false,
 	}
+	// Map the active span to Cloud Logging's trace fields, so logs and
+	// traces are joinable in the console.
+	if sc := trace.SpanContextFromContext(ctx); sc.HasTraceID() {
+		e.Trace = "projects/" + h.projectID + "/traces/" + sc.TraceID().String()
+		e.SpanID = sc.SpanID().String()
+		e.TraceSampled = sc.IsSampled()
+	}
+	return e
 }
 
 func levelToSeverity(l slog.Level) logging.Severity {
@@ -17,11 +17,14 @@
 package googlecloud
 
 import (
+	"context"
 	"log/slog"
 	"testing"
 	"testing/slogtest"
+	"time"
 
 	"cloud.google.com/go/logging"
+	"go.opentelemetry.io/otel/trace"
 )
 
 func TestHandler(t *testing.T) {
@@ -31,11 +34,39 @@ func TestHandler(t *testing.T) {
 		results = append(results, entryToMap(e))
 	}
 
-	if err := slogtest.TestHandler(newHandler(slog.LevelInfo, f), func() []map[string]any { return results }); err != nil {
+	if err := slogtest.TestHandler(newHandler("test-project", slog.LevelInfo, f), func() []map[string]any { return results }); err != nil {
 		t.Fatal(err)
 	}
 }
 
+func TestHandlerTraceFields(t *testing.T) {
+	var entries []logging.Entry
+	h := newHandler("test-project", slog.LevelInfo, func(e logging.Entry) {
+		entries = append(entries, e)
+	})
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    trace.TraceID{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+		SpanID:     trace.SpanID{1, 2, 3, 4, 5, 6, 7, 8},
+		TraceFlags: trace.FlagsSampled,
+	})
+	ctx := trace.ContextWithSpanContext(context.Background(), sc)
+	r := slog.NewRecord(time.Now(), slog.LevelInfo, "hello", 0)
+	if err := h.Handle(ctx, r); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+	e := entries[0]
+	want := "projects/test-project/traces/" + sc.TraceID().String()
+	if e.Trace != want {
+		t.Errorf("Trace = %q, want %q", e.Trace, want)
+	}
+	if e.SpanID != sc.SpanID().String() || !e.TraceSampled {
+		t.Errorf("SpanID = %q, TraceSampled = %t", e.SpanID, e.TraceSampled)
+	}
+}
+
 func entryToMap(e logging.Entry) map[string]any {
 	m := map[string]any{}
 	if !e.Timestamp.IsZero() {
@@ -0,0 +1,164 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package conformance is a reusable test battery for plugin
+// implementations. Plugin tests call [RunModel], [RunEmbedder] or
+// [RunRetriever] against their implementation to verify the behavior
+// the rest of Genkit relies on: streaming semantics, response
+// normalization, usage reporting, and retrieval contracts.
+package conformance
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+)
+
+// RunModel runs the model conformance battery as subtests of t.
+// The model must be able to answer a trivial text prompt.
+func RunModel(t *testing.T, m *ai.Model) {
+	ctx := context.Background()
+	req := &ai.GenerateRequest{
+		Messages: []*ai.Message{{
+			Content: []*ai.Part{ai.NewTextPart("Just say the word hello.")},
+			Role:    ai.RoleUser,
+		}},
+	}
+
+	t.Run("basic", func(t *testing.T) {
+		resp, err := m.Generate(ctx, req, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(resp.Candidates) == 0 {
+			t.Fatal("no candidates")
+		}
+		if resp.Request == nil {
+			t.Error("response does not echo the request")
+		}
+		text, err := resp.Text()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if text == "" {
+			t.Error("empty response text")
+		}
+		if resp.Candidates[0].Message.Role != ai.RoleModel {
+			t.Errorf("candidate role = %q, want %q", resp.Candidates[0].Message.Role, ai.RoleModel)
+		}
+	})
+
+	t.Run("streaming", func(t *testing.T) {
+		var sb strings.Builder
+		resp, err := m.Generate(ctx, req, func(ctx context.Context, c *ai.GenerateResponseChunk) error {
+			text, err := c.Text()
+			if err != nil {
+				return err
+			}
+			sb.WriteString(text)
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sb.Len() == 0 {
+			t.Error("streaming callback never received content")
+		}
+		final, err := resp.Text()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := sb.String(); got != final {
+			t.Errorf("streamed chunks = %q, want the final text %q", got, final)
+		}
+	})
+
+	t.Run("usage", func(t *testing.T) {
+		resp, err := m.Generate(ctx, req, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Usage == nil {
+			t.Error("response has no usage")
+		}
+	})
+}
+
+// RunEmbedder runs the embedder conformance battery as subtests of t.
+func RunEmbedder(t *testing.T, e *ai.Embedder, options any) {
+	ctx := context.Background()
+	embed := func(t *testing.T, text string) []float32 {
+		v, err := e.Embed(ctx, &ai.EmbedRequest{
+			Document: ai.DocumentFromText(text, nil),
+			Options:  options,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+
+	t.Run("dimensions", func(t *testing.T) {
+		v1 := embed(t, "a short document")
+		if len(v1) == 0 {
+			t.Fatal("empty embedding")
+		}
+		v2 := embed(t, "an entirely different document about something else")
+		if len(v1) != len(v2) {
+			t.Errorf("inconsistent dimensions: %d vs. %d", len(v1), len(v2))
+		}
+	})
+
+	t.Run("deterministic-shape", func(t *testing.T) {
+		// Embedding the same text twice must at least agree on shape.
+		v1 := embed(t, "the same document")
+		v2 := embed(t, "the same document")
+		if len(v1) != len(v2) {
+			t.Errorf("same input, different dimensions: %d vs. %d", len(v1), len(v2))
+		}
+	})
+}
+
+// RunRetriever indexes the given documents with the indexer, then runs
+// the retriever conformance battery as subtests of t. query must be
+// answerable from docs.
+func RunRetriever(t *testing.T, ix *ai.Indexer, r *ai.Retriever, docs []*ai.Document, query string, options any) {
+	ctx := context.Background()
+	if err := ix.Index(ctx, &ai.IndexerRequest{Documents: docs}); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("retrieve", func(t *testing.T) {
+		resp, err := r.Retrieve(ctx, &ai.RetrieverRequest{
+			Document: ai.DocumentFromText(query, nil),
+			Options:  options,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(resp.Documents) == 0 {
+			t.Fatal("no documents retrieved")
+		}
+		if len(resp.Documents) > len(docs) {
+			t.Errorf("retrieved %d documents, more than the %d indexed", len(resp.Documents), len(docs))
+		}
+		for i, d := range resp.Documents {
+			if len(d.Content) == 0 {
+				t.Errorf("document %d has no content", i)
+			}
+		}
+	})
+}
@@ -0,0 +1,46 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conformance
+
+import (
+	"context"
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+)
+
+// The battery should pass against a minimal well-behaved model.
+func TestRunModel(t *testing.T) {
+	model := ai.DefineModel("conformance", "wellBehaved", nil,
+		func(ctx context.Context, req *ai.GenerateRequest, cb ai.ModelStreamingCallback) (*ai.GenerateResponse, error) {
+			parts := []*ai.Part{ai.NewTextPart("hel"), ai.NewTextPart("lo")}
+			if cb != nil {
+				for _, p := range parts {
+					if err := cb(ctx, &ai.GenerateResponseChunk{Content: []*ai.Part{p}}); err != nil {
+						return nil, err
+					}
+				}
+			}
+			return &ai.GenerateResponse{
+				Request: req,
+				Usage:   &ai.GenerationUsage{TotalTokens: 2},
+				Candidates: []*ai.Candidate{{
+					Message:      &ai.Message{Role: ai.RoleModel, Content: parts},
+					FinishReason: ai.FinishReasonStop,
+				}},
+			}, nil
+		})
+	RunModel(t, model)
+}
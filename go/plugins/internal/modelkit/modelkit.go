@@ -0,0 +1,133 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package modelkit reduces a model plugin to the part that is actually
+// provider-specific. It wraps a minimal provider client with the
+// request validation, streaming plumbing, error wrapping and response
+// normalization that every conformant [ai.Model] needs, so a new
+// provider plugin is tens of lines instead of hundreds.
+package modelkit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/core"
+)
+
+// A Generator is the minimal surface a provider client implements.
+type Generator interface {
+	// Generate runs the model on the request and returns the complete
+	// response.
+	Generate(ctx context.Context, req *ai.GenerateRequest) (*ai.GenerateResponse, error)
+}
+
+// A StreamingGenerator is a [Generator] whose provider supports
+// streaming. Clients that do not implement it still work with
+// streaming callers: the complete response is delivered as one chunk.
+type StreamingGenerator interface {
+	Generator
+	// GenerateStream runs the model on the request, delivering chunks
+	// to send as they arrive, and returns the complete response.
+	GenerateStream(ctx context.Context, req *ai.GenerateRequest, send ai.ModelStreamingCallback) (*ai.GenerateResponse, error)
+}
+
+// DefineModel registers a conformant [ai.Model] backed by the provider
+// client g. It validates requests before they reach the client, adapts
+// non-streaming clients to streaming callers, normalizes responses
+// (request echo, usage, candidate indexes) and wraps client errors in
+// a [core.GenkitError] carrying the provider name.
+func DefineModel(provider, name string, metadata *ai.ModelMetadata, g Generator) *ai.Model {
+	return ai.DefineModel(provider, name, metadata,
+		func(ctx context.Context, req *ai.GenerateRequest, cb ai.ModelStreamingCallback) (*ai.GenerateResponse, error) {
+			if err := validateRequest(req); err != nil {
+				return nil, &core.GenkitError{Status: core.StatusInvalidArgument, Provider: provider, Err: err}
+			}
+			var resp *ai.GenerateResponse
+			var err error
+			sg, streams := g.(StreamingGenerator)
+			switch {
+			case cb != nil && streams:
+				resp, err = sg.GenerateStream(ctx, req, cb)
+			default:
+				resp, err = g.Generate(ctx, req)
+				if err == nil && cb != nil {
+					// The client cannot stream; deliver each candidate's
+					// message as a single chunk.
+					for _, c := range resp.Candidates {
+						if c.Message == nil {
+							continue
+						}
+						err = cb(ctx, &ai.GenerateResponseChunk{
+							Index:   c.Index,
+							Content: c.Message.Content,
+						})
+						if err != nil {
+							break
+						}
+					}
+				}
+			}
+			if err != nil {
+				return nil, wrapError(provider, err)
+			}
+			normalizeResponse(resp, req)
+			return resp, nil
+		})
+}
+
+// validateRequest rejects requests no provider can meaningfully serve,
+// so each client does not have to.
+func validateRequest(req *ai.GenerateRequest) error {
+	if len(req.Messages) == 0 {
+		return errors.New("request has no messages")
+	}
+	for i, m := range req.Messages {
+		if len(m.Content) == 0 {
+			return fmt.Errorf("message %d has no content", i)
+		}
+	}
+	return nil
+}
+
+// wrapError ensures the error callers see is a [core.GenkitError]
+// naming the provider, preserving one the client already built.
+func wrapError(provider string, err error) error {
+	var ge *core.GenkitError
+	if errors.As(err, &ge) {
+		return err
+	}
+	return &core.GenkitError{Status: core.StatusInternal, Provider: provider, Err: err}
+}
+
+// normalizeResponse fills in the response fields the rest of Genkit
+// relies on but providers routinely omit.
+func normalizeResponse(resp *ai.GenerateResponse, req *ai.GenerateRequest) {
+	if resp.Request == nil {
+		resp.Request = req
+	}
+	if resp.Usage == nil {
+		resp.Usage = &ai.GenerationUsage{}
+	}
+	for i, c := range resp.Candidates {
+		if c.Index == 0 {
+			c.Index = i
+		}
+		if c.FinishReason == "" {
+			c.FinishReason = ai.FinishReasonUnknown
+		}
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package modelkit
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/core"
+)
+
+// echoClient is a minimal non-streaming Generator.
+type echoClient struct {
+	err error
+}
+
+func (c *echoClient) Generate(ctx context.Context, req *ai.GenerateRequest) (*ai.GenerateResponse, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	return &ai.GenerateResponse{
+		Candidates: []*ai.Candidate{{
+			Message: &ai.Message{Role: ai.RoleModel, Content: []*ai.Part{ai.NewTextPart("echo")}},
+		}},
+	}, nil
+}
+
+func TestDefineModel(t *testing.T) {
+	client := &echoClient{}
+	model := DefineModel("testprovider", "echo", nil, client)
+	ctx := context.Background()
+
+	// An empty request is rejected before it reaches the client.
+	_, err := model.Generate(ctx, &ai.GenerateRequest{}, nil)
+	if core.StatusOf(err) != core.StatusInvalidArgument {
+		t.Errorf("empty request: status = %s, want %s", core.StatusOf(err), core.StatusInvalidArgument)
+	}
+
+	// A non-streaming client serves streaming callers with one chunk.
+	req := &ai.GenerateRequest{
+		Messages: []*ai.Message{{Content: []*ai.Part{ai.NewTextPart("hi")}, Role: ai.RoleUser}},
+	}
+	chunks := 0
+	resp, err := model.Generate(ctx, req, func(ctx context.Context, c *ai.GenerateResponseChunk) error {
+		chunks++
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if chunks != 1 {
+		t.Errorf("got %d chunks, want 1", chunks)
+	}
+	// The response is normalized.
+	if resp.Request == nil || resp.Usage == nil {
+		t.Error("response not normalized")
+	}
+	if resp.Candidates[0].FinishReason != ai.FinishReasonUnknown {
+		t.Errorf("FinishReason = %q, want %q", resp.Candidates[0].FinishReason, ai.FinishReasonUnknown)
+	}
+
+	// Client errors come back as GenkitErrors naming the provider.
+	client.err = errors.New("boom")
+	_, err = model.Generate(ctx, req, nil)
+	var ge *core.GenkitError
+	if !errors.As(err, &ge) || ge.Provider != "testprovider" {
+		t.Errorf("got %v, want a GenkitError for testprovider", err)
+	}
+}
@@ -27,6 +27,7 @@ import (
 	"time"
 
 	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/core"
 )
 
 const provider = "ollama"
@@ -37,7 +38,7 @@ var roleMapping = map[ai.Role]string{
 	ai.RoleSystem: "system",
 }
 
-func defineModel(model ModelDefinition, serverAddress string) {
+func defineModel(model ModelDefinition, serverAddress string, httpClient *http.Client) {
 	meta := &ai.ModelMetadata{
 		Label: "Ollama - " + model.Name,
 		Supports: ai.ModelCapabilities{
@@ -45,7 +46,7 @@ func defineModel(model ModelDefinition, serverAddress string) {
 			SystemRole: model.Type != "chat",
 		},
 	}
-	g := &generator{model: model, serverAddress: serverAddress}
+	g := &generator{model: model, serverAddress: serverAddress, httpClient: httpClient}
 	ai.DefineModel(provider, model.Name, meta, g.generate)
 }
 
@@ -67,12 +68,16 @@ type Config struct {
 	ServerAddress string
 	// Generative models to provide.
 	Models []ModelDefinition
+	// HTTPClient is the client to use for requests to the server.
+	// Set it to route through a proxy or to use mTLS or a custom CA.
+	// If nil, a default client with a 30-second timeout is used.
+	HTTPClient *http.Client
 }
 
 // Init registers all the actions in this package with ai.
 func Init(ctx context.Context, cfg Config) error {
 	for _, model := range cfg.Models {
-		defineModel(model, cfg.ServerAddress)
+		defineModel(model, cfg.ServerAddress, cfg.HTTPClient)
 	}
 	return nil
 }
@@ -80,6 +85,7 @@ func Init(ctx context.Context, cfg Config) error {
 type generator struct {
 	model         ModelDefinition
 	serverAddress string
+	httpClient    *http.Client
 }
 
 type ollamaMessage struct {
@@ -105,13 +111,53 @@ type ollamaChatRequest struct {
 	Messages []*ollamaMessage `json:"messages"`
 	Model    string           `json:"model"`
 	Stream   bool             `json:"stream"`
+	Options  map[string]any   `json:"options,omitempty"`
 }
 
 type ollamaGenerateRequest struct {
-	System string `json:"system,omitempty"` // Optional System field
-	Model  string `json:"model"`
-	Prompt string `json:"prompt"`
-	Stream bool   `json:"stream"`
+	System  string         `json:"system,omitempty"` // Optional System field
+	Model   string         `json:"model"`
+	Prompt  string         `json:"prompt"`
+	Stream  bool           `json:"stream"`
+	Options map[string]any `json:"options,omitempty"`
+}
+
+// convertOptions converts an [ai.GenerationCommonConfig] into the Ollama
+// model parameters documented for the Modelfile.
+func convertOptions(config any) map[string]any {
+	c, ok := config.(*ai.GenerationCommonConfig)
+	if !ok || c == nil {
+		return nil
+	}
+	options := map[string]any{}
+	if c.FrequencyPenalty != 0 {
+		options["frequency_penalty"] = c.FrequencyPenalty
+	}
+	if c.MaxOutputTokens != 0 {
+		options["num_predict"] = c.MaxOutputTokens
+	}
+	if c.PresencePenalty != 0 {
+		options["presence_penalty"] = c.PresencePenalty
+	}
+	if c.Seed != 0 {
+		options["seed"] = c.Seed
+	}
+	if len(c.StopSequences) > 0 {
+		options["stop"] = c.StopSequences
+	}
+	if c.Temperature != 0 {
+		options["temperature"] = c.Temperature
+	}
+	if c.TopK != 0 {
+		options["top_k"] = c.TopK
+	}
+	if c.TopP != 0 {
+		options["top_p"] = c.TopP
+	}
+	if len(options) == 0 {
+		return nil
+	}
+	return options
 }
 
 // TODO: Add optional parameters (images, format, options, etc.) based on your use case
@@ -138,10 +184,11 @@ func (g *generator) generate(ctx context.Context, input *ai.GenerateRequest, cb
 	isChatModel := g.model.Type == "chat"
 	if !isChatModel {
 		payload = ollamaGenerateRequest{
-			Model:  g.model.Name,
-			Prompt: concatMessages(input, []ai.Role{ai.Role("user"), ai.Role("model"), ai.Role("tool")}),
-			System: concatMessages(input, []ai.Role{ai.Role("system")}),
-			Stream: stream,
+			Model:   g.model.Name,
+			Prompt:  concatMessages(input, []ai.Role{ai.Role("user"), ai.Role("model"), ai.Role("tool")}),
+			System:  concatMessages(input, []ai.Role{ai.Role("system")}),
+			Stream:  stream,
+			Options: convertOptions(input.Config),
 		}
 	} else {
 		var messages []*ollamaMessage
@@ -157,10 +204,14 @@ func (g *generator) generate(ctx context.Context, input *ai.GenerateRequest, cb
 			Messages: messages,
 			Model:    g.model.Name,
 			Stream:   stream,
+			Options:  convertOptions(input.Config),
 		}
 	}
-	client := &http.Client{
-		Timeout: time.Second * 30,
+	client := g.httpClient
+	if client == nil {
+		client = &http.Client{
+			Timeout: time.Second * 30,
+		}
 	}
 	payloadBytes, err := json.Marshal(payload)
 	// Determine the correct endpoint
@@ -179,6 +230,13 @@ func (g *generator) generate(ctx context.Context, input *ai.GenerateRequest, cb
 		return nil, fmt.Errorf("failed to send request: %v", err)
 	}
 	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		// Normalize the error so callers can branch on its class; Ollama
+		// returns 429 and 503 when a model is loading or overloaded.
+		return nil, core.NormalizeHTTPError(provider, resp.StatusCode, resp.Header.Get("Retry-After"),
+			fmt.Errorf("server returned non-200 status: %d, body: %s", resp.StatusCode, body))
+	}
 	if cb == nil {
 		// Existing behavior for non-streaming responses
 		var err error
@@ -186,9 +244,6 @@ func (g *generator) generate(ctx context.Context, input *ai.GenerateRequest, cb
 		if err != nil {
 			return nil, fmt.Errorf("failed to read response body: %v", err)
 		}
-		if resp.StatusCode != http.StatusOK {
-			return nil, fmt.Errorf("server returned non-200 status: %d, body: %s", resp.StatusCode, body)
-		}
 		var response *ai.GenerateResponse
 		if isChatModel {
 			response, err = translateResponse(body)
@@ -36,7 +36,8 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"os"
+
+	"github.com/firebase/genkit/go/core"
 )
 
 // Set pineconeDebug to true to dump data sent to and received from the server.
@@ -48,6 +49,17 @@ const apiServer = "api.pinecone.io"
 // apiKeyEnv is the environment variable to use for the API key.
 const apiKeyEnv = "PINECONE_API_KEY"
 
+// httpClient is the client used for requests to Pinecone,
+// http.DefaultClient unless overridden with [SetHTTPClient].
+var httpClient = http.DefaultClient
+
+// SetHTTPClient sets the HTTP client used for all requests to
+// Pinecone. Set it to route through a proxy or to use mTLS or a custom
+// CA. Call it before [Init].
+func SetHTTPClient(c *http.Client) {
+	httpClient = c
+}
+
 // A client is used to perform database operations.
 type client struct {
 	apiKey string
@@ -59,7 +71,7 @@ type client struct {
 // If it is the empty string, it is read from the PINECONE_API_KEY
 // environment variable.
 func newClient(ctx context.Context, apiKey string) (*client, error) {
-	key, err := resolveAPIKey(apiKey)
+	key, err := resolveAPIKey(ctx, apiKey)
 	if err != nil {
 		return nil, err
 	}
@@ -338,7 +350,7 @@ func (c *client) fetchData(ctx context.Context, url string, result any) error {
 	}
 	req.Header.Add("Api-Key", c.apiKey)
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := httpClient.Do(req)
 	if err != nil {
 		return fmt.Errorf("pinecone request to %s failed: %v", url, err)
 	}
@@ -406,7 +418,7 @@ func (c *client) postData(ctx context.Context, url string, post, result any) err
 
 	// Passing req to Do promises that it will close the body,
 	// in this case bodyReader. That will lead the goroutine to exit.
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := httpClient.Do(req)
 	if err != nil {
 		return fmt.Errorf("pinecone post to %s failed: %v", url, err)
 	}
@@ -468,14 +480,16 @@ func (c *client) serverError(r io.Reader) error {
 	return fmt.Errorf("pinecone error %d: %s", msg.Code, msg.Message)
 }
 
-// resolveAPIKey reads the API key from the environment if necessary.
-func resolveAPIKey(apiKey string) (string, error) {
+// resolveAPIKey resolves the API key through the configured secret
+// resolver if necessary; by default that reads the PINECONE_API_KEY
+// environment variable.
+func resolveAPIKey(ctx context.Context, apiKey string) (string, error) {
 	if apiKey != "" {
 		return apiKey, nil
 	}
-	key := os.Getenv(apiKeyEnv)
-	if key == "" {
-		return "", fmt.Errorf("pinecone API key not set; try setting %s", apiKeyEnv)
+	key, err := core.ResolveSecret(ctx, apiKeyEnv)
+	if err != nil {
+		return "", fmt.Errorf("pinecone API key not set; try setting %s: %w", apiKeyEnv, err)
 	}
 	return key, nil
 }
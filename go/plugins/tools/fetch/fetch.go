@@ -0,0 +1,216 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fetch registers a fetchURL tool that retrieves a web page for
+// the model, with the security checks hand-rolled fetch tools tend to
+// skip: host allow/deny lists, private-address blocking enforced at
+// dial time (so DNS rebinding cannot bypass it), response size and time
+// limits, and HTML-to-text conversion.
+package fetch
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/core"
+)
+
+// Config configures [DefineTool].
+type Config struct {
+	// AllowedHosts, if non-empty, restricts fetches to these hosts. An
+	// entry matches the host exactly or any of its subdomains (entry
+	// "example.com" matches "docs.example.com").
+	AllowedHosts []string
+	// BlockedHosts are rejected even if allowed by AllowedHosts,
+	// matched the same way.
+	BlockedHosts []string
+	// AllowPrivate permits fetching private, loopback and link-local
+	// addresses. Leave it false except in tests.
+	AllowPrivate bool
+	// MaxBodySize bounds the response body, in bytes. If zero, 1 MiB.
+	MaxBodySize int64
+	// Timeout bounds the whole fetch. If zero, 20 seconds.
+	Timeout time.Duration
+}
+
+// DefineTool registers the fetchURL tool and returns its name. The tool
+// takes {"url": "..."} and returns the final URL, status, and the page
+// text, with HTML converted to plain text.
+func DefineTool(cfg Config) string {
+	maxBody := cfg.MaxBodySize
+	if maxBody == 0 {
+		maxBody = 1 << 20
+	}
+	timeout := cfg.Timeout
+	if timeout == 0 {
+		timeout = 20 * time.Second
+	}
+	client := cfg.newClient()
+	ai.DefineTool(&ai.ToolDefinition{
+		Name:        "fetchURL",
+		Description: "Fetches a web page over HTTP(S) and returns its text content.",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"url": map[string]any{"type": "string"},
+			},
+			"required": []any{"url"},
+		},
+		OutputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"url":       map[string]any{"type": "string"},
+				"status":    map[string]any{"type": "integer"},
+				"text":      map[string]any{"type": "string"},
+				"truncated": map[string]any{"type": "boolean"},
+			},
+		},
+	}, nil, func(ctx context.Context, input map[string]any) (map[string]any, error) {
+		rawURL, _ := input["url"].(string)
+		u, err := url.Parse(rawURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return nil, core.NewError(core.StatusInvalidArgument, fmt.Errorf("invalid URL %q", rawURL))
+		}
+		if err := cfg.checkHost(u.Hostname()); err != nil {
+			return nil, core.NewError(core.StatusPermissionDenied, err)
+		}
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxBody+1))
+		if err != nil {
+			return nil, err
+		}
+		truncated := int64(len(body)) > maxBody
+		if truncated {
+			body = body[:maxBody]
+		}
+		text := string(body)
+		if strings.Contains(resp.Header.Get("Content-Type"), "text/html") {
+			text = htmlToText(text)
+		}
+		return map[string]any{
+			"url":       resp.Request.URL.String(),
+			"status":    resp.StatusCode,
+			"text":      text,
+			"truncated": truncated,
+		}, nil
+	})
+	return "fetchURL"
+}
+
+// newClient builds an HTTP client whose dialer re-checks every
+// connection: redirect targets go through checkHost again, and resolved
+// addresses are rejected if private, so a DNS name that later resolves
+// to an internal address cannot reach it.
+func (cfg Config) newClient() *http.Client {
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		ips, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		for _, ip := range ips {
+			if !cfg.AllowPrivate && isPrivate(ip.IP) {
+				continue
+			}
+			conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(ip.IP.String(), port))
+			if err == nil {
+				return conn, nil
+			}
+		}
+		return nil, fmt.Errorf("no permitted address for host %q", host)
+	}
+	return &http.Client{
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 5 {
+				return fmt.Errorf("too many redirects")
+			}
+			return cfg.checkHost(req.URL.Hostname())
+		},
+	}
+}
+
+// checkHost applies the allow and deny lists.
+func (cfg Config) checkHost(host string) error {
+	for _, b := range cfg.BlockedHosts {
+		if hostMatches(host, b) {
+			return fmt.Errorf("host %q is blocked", host)
+		}
+	}
+	if len(cfg.AllowedHosts) == 0 {
+		return nil
+	}
+	for _, a := range cfg.AllowedHosts {
+		if hostMatches(host, a) {
+			return nil
+		}
+	}
+	return fmt.Errorf("host %q is not in the allowlist", host)
+}
+
+func hostMatches(host, pattern string) bool {
+	return host == pattern || strings.HasSuffix(host, "."+pattern)
+}
+
+// isPrivate reports whether the address is not publicly routable.
+func isPrivate(ip net.IP) bool {
+	return ip.IsPrivate() ||
+		ip.IsLoopback() ||
+		ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() ||
+		ip.IsUnspecified()
+}
+
+var (
+	scriptRE = regexp.MustCompile(`(?is)<(script|style|head)\b.*?</(script|style|head)>`)
+	tagRE    = regexp.MustCompile(`(?s)<[^>]*>`)
+	spaceRE  = regexp.MustCompile(`[ \t]*\n[ \t\n]*`)
+	blankRE  = regexp.MustCompile(`[ \t]+`)
+)
+
+// htmlToText strips markup from an HTML document, leaving readable
+// text. It is deliberately simple; pages needing real rendering should
+// be handled by a dedicated extraction service.
+func htmlToText(s string) string {
+	s = scriptRE.ReplaceAllString(s, " ")
+	s = tagRE.ReplaceAllString(s, "\n")
+	s = html.UnescapeString(s)
+	s = blankRE.ReplaceAllString(s, " ")
+	s = spaceRE.ReplaceAllString(s, "\n")
+	return strings.TrimSpace(s)
+}
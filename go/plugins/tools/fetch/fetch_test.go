@@ -0,0 +1,103 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fetch
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+)
+
+func TestFetchURL(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		io.WriteString(w, `<html><head><title>t</title></head><body><h1>Hello</h1><script>evil()</script><p>world &amp; peace</p></body></html>`)
+	}))
+	defer srv.Close()
+
+	// The test server is on a loopback address, so private fetches
+	// must be allowed here; the SSRF checks are tested separately.
+	DefineTool(Config{AllowPrivate: true})
+
+	out, err := ai.RunTool(context.Background(), "fetchURL", map[string]any{"url": srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, _ := out["text"].(string)
+	if !strings.Contains(text, "Hello") || !strings.Contains(text, "world & peace") {
+		t.Errorf("text = %q", text)
+	}
+	if strings.Contains(text, "evil") {
+		t.Errorf("script content leaked into text: %q", text)
+	}
+
+	t.Run("invalid URL", func(t *testing.T) {
+		if _, err := ai.RunTool(context.Background(), "fetchURL", map[string]any{"url": "ftp://x"}); err == nil {
+			t.Error("expected an error for a non-HTTP URL")
+		}
+	})
+}
+
+func TestCheckHost(t *testing.T) {
+	cfg := Config{
+		AllowedHosts: []string{"example.com"},
+		BlockedHosts: []string{"internal.example.com"},
+	}
+	for _, test := range []struct {
+		host string
+		ok   bool
+	}{
+		{"example.com", true},
+		{"docs.example.com", true},
+		{"internal.example.com", false},
+		{"api.internal.example.com", false},
+		{"evil.com", false},
+		{"notexample.com", false},
+	} {
+		err := cfg.checkHost(test.host)
+		if test.ok && err != nil {
+			t.Errorf("%s: unexpected error %v", test.host, err)
+		}
+		if !test.ok && err == nil {
+			t.Errorf("%s: expected an error", test.host)
+		}
+	}
+}
+
+func TestIsPrivate(t *testing.T) {
+	for _, test := range []struct {
+		ip      string
+		private bool
+	}{
+		{"127.0.0.1", true},
+		{"10.1.2.3", true},
+		{"192.168.0.1", true},
+		{"169.254.169.254", true},
+		{"0.0.0.0", true},
+		{"::1", true},
+		{"8.8.8.8", false},
+		{"2001:4860:4860::8888", false},
+	} {
+		if got := isPrivate(net.ParseIP(test.ip)); got != test.private {
+			t.Errorf("isPrivate(%s) = %t, want %t", test.ip, got, test.private)
+		}
+	}
+}
@@ -0,0 +1,220 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package files registers file tools — readFile, writeFile, listDir and
+// glob — confined to a configured root directory. Paths are resolved
+// inside the root with traversal and symlink-escape protection, so a
+// coding agent or document assistant can work on a project directory
+// without reaching the rest of the filesystem.
+package files
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/core"
+)
+
+// Config configures [DefineTools].
+type Config struct {
+	// Root is the directory the tools are confined to. Required; it
+	// must exist.
+	Root string
+	// AllowWrite also registers the writeFile tool. Reads, listing
+	// and globbing are always registered.
+	AllowWrite bool
+	// MaxFileSize bounds readFile results, in bytes. If zero, 1 MiB.
+	MaxFileSize int64
+}
+
+// DefineTools registers the file tools and returns their names. All
+// paths in tool inputs are interpreted relative to cfg.Root; attempts
+// to escape it fail with PERMISSION_DENIED.
+func DefineTools(cfg Config) ([]string, error) {
+	root, err := filepath.EvalSymlinks(cfg.Root)
+	if err != nil {
+		return nil, fmt.Errorf("files.DefineTools: %w", err)
+	}
+	maxSize := cfg.MaxFileSize
+	if maxSize == 0 {
+		maxSize = 1 << 20
+	}
+	j := jail{root: root}
+
+	pathInput := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"path": map[string]any{"type": "string"},
+		},
+		"required": []any{"path"},
+	}
+
+	ai.DefineTool(&ai.ToolDefinition{
+		Name:        "readFile",
+		Description: "Reads a text file and returns its content.",
+		InputSchema: pathInput,
+	}, nil, func(ctx context.Context, input map[string]any) (map[string]any, error) {
+		full, err := j.resolve(str(input, "path"))
+		if err != nil {
+			return nil, err
+		}
+		info, err := os.Stat(full)
+		if err != nil {
+			return nil, err
+		}
+		if info.Size() > maxSize {
+			return nil, core.NewError(core.StatusInvalidArgument,
+				fmt.Errorf("file is %d bytes, larger than the %d byte limit", info.Size(), maxSize))
+		}
+		data, err := os.ReadFile(full)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"content": string(data)}, nil
+	})
+
+	ai.DefineTool(&ai.ToolDefinition{
+		Name:        "listDir",
+		Description: "Lists the entries of a directory. Directories end with a slash.",
+		InputSchema: pathInput,
+	}, nil, func(ctx context.Context, input map[string]any) (map[string]any, error) {
+		full, err := j.resolve(str(input, "path"))
+		if err != nil {
+			return nil, err
+		}
+		entries, err := os.ReadDir(full)
+		if err != nil {
+			return nil, err
+		}
+		var names []string
+		for _, e := range entries {
+			name := e.Name()
+			if e.IsDir() {
+				name += "/"
+			}
+			names = append(names, name)
+		}
+		return map[string]any{"entries": names}, nil
+	})
+
+	ai.DefineTool(&ai.ToolDefinition{
+		Name:        "glob",
+		Description: "Returns the paths matching a glob pattern, e.g. \"src/*.go\".",
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"pattern": map[string]any{"type": "string"},
+			},
+			"required": []any{"pattern"},
+		},
+	}, nil, func(ctx context.Context, input map[string]any) (map[string]any, error) {
+		// fs.Glob over a rooted FS cannot escape the jail.
+		matches, err := fs.Glob(os.DirFS(root), str(input, "pattern"))
+		if err != nil {
+			return nil, core.NewError(core.StatusInvalidArgument, err)
+		}
+		return map[string]any{"matches": matches}, nil
+	})
+
+	names := []string{"readFile", "listDir", "glob"}
+	if cfg.AllowWrite {
+		ai.DefineTool(&ai.ToolDefinition{
+			Name:        "writeFile",
+			Description: "Writes content to a file, creating it and any parent directories.",
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"path":    map[string]any{"type": "string"},
+					"content": map[string]any{"type": "string"},
+				},
+				"required": []any{"path", "content"},
+			},
+		}, nil, func(ctx context.Context, input map[string]any) (map[string]any, error) {
+			full, err := j.resolve(str(input, "path"))
+			if err != nil {
+				return nil, err
+			}
+			if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+				return nil, err
+			}
+			content := str(input, "content")
+			if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+				return nil, err
+			}
+			return map[string]any{"written": len(content)}, nil
+		})
+		names = append(names, "writeFile")
+	}
+	return names, nil
+}
+
+func str(m map[string]any, key string) string {
+	s, _ := m[key].(string)
+	return s
+}
+
+// A jail maps tool-supplied paths into a root directory and rejects
+// escapes.
+type jail struct {
+	root string // symlink-resolved absolute root
+}
+
+// resolve returns the absolute path for p, which is interpreted
+// relative to the root. It fails if the cleaned path or any symlinks in
+// it lead outside the root.
+func (j jail) resolve(p string) (string, error) {
+	full := filepath.Join(j.root, filepath.Clean("/"+p))
+	if !j.contains(full) {
+		return "", core.NewError(core.StatusPermissionDenied, fmt.Errorf("path %q is outside the root directory", p))
+	}
+	// Resolve symlinks on the longest existing prefix, so a symlink
+	// inside the jail cannot point out of it.
+	resolved, err := resolveExisting(full)
+	if err != nil {
+		return "", err
+	}
+	if !j.contains(resolved) {
+		return "", core.NewError(core.StatusPermissionDenied, fmt.Errorf("path %q resolves outside the root directory", p))
+	}
+	return full, nil
+}
+
+func (j jail) contains(full string) bool {
+	rel, err := filepath.Rel(j.root, full)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// resolveExisting evaluates symlinks on the longest prefix of full that
+// exists, and rejoins the remainder.
+func resolveExisting(full string) (string, error) {
+	rest := ""
+	for p := full; ; p = filepath.Dir(p) {
+		resolved, err := filepath.EvalSymlinks(p)
+		if err == nil {
+			return filepath.Join(resolved, rest), nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+		rest = filepath.Join(filepath.Base(p), rest)
+		if p == filepath.Dir(p) {
+			return full, nil
+		}
+	}
+}
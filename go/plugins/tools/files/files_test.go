@@ -0,0 +1,106 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package files
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+)
+
+func TestFileTools(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "src"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "src", "main.go"), []byte("package main"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// A symlink pointing outside the root must not be readable.
+	if err := os.Symlink("/etc/hostname", filepath.Join(root, "escape")); err != nil {
+		t.Fatal(err)
+	}
+
+	names, err := DefineTools(Config{Root: root, AllowWrite: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"readFile", "listDir", "glob", "writeFile"}
+	if !slices.Equal(names, want) {
+		t.Fatalf("got tools %v, want %v", names, want)
+	}
+
+	ctx := context.Background()
+	t.Run("read", func(t *testing.T) {
+		out, err := ai.RunTool(ctx, "readFile", map[string]any{"path": "src/main.go"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out["content"] != "package main" {
+			t.Errorf("got %v", out)
+		}
+	})
+	t.Run("traversal blocked", func(t *testing.T) {
+		if _, err := ai.RunTool(ctx, "readFile", map[string]any{"path": "../../../etc/passwd"}); err == nil {
+			t.Error("path traversal was not blocked")
+		}
+	})
+	t.Run("symlink escape blocked", func(t *testing.T) {
+		if _, err := ai.RunTool(ctx, "readFile", map[string]any{"path": "escape"}); err == nil {
+			t.Error("symlink escape was not blocked")
+		}
+	})
+	t.Run("list", func(t *testing.T) {
+		out, err := ai.RunTool(ctx, "listDir", map[string]any{"path": "."})
+		if err != nil {
+			t.Fatal(err)
+		}
+		entries, _ := out["entries"].([]string)
+		if !slices.Contains(entries, "src/") {
+			t.Errorf("got %v, want src/ listed", out["entries"])
+		}
+	})
+	t.Run("glob", func(t *testing.T) {
+		out, err := ai.RunTool(ctx, "glob", map[string]any{"pattern": "src/*.go"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		matches, _ := out["matches"].([]string)
+		if !slices.Equal(matches, []string{"src/main.go"}) {
+			t.Errorf("got %v", out["matches"])
+		}
+	})
+	t.Run("write", func(t *testing.T) {
+		if _, err := ai.RunTool(ctx, "writeFile", map[string]any{"path": "out/new.txt", "content": "hi"}); err != nil {
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile(filepath.Join(root, "out", "new.txt"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != "hi" {
+			t.Errorf("file content = %q", data)
+		}
+	})
+	t.Run("write through symlink blocked", func(t *testing.T) {
+		if _, err := ai.RunTool(ctx, "writeFile", map[string]any{"path": "escape", "content": "x"}); err == nil {
+			t.Error("write through an escaping symlink was not blocked")
+		}
+	})
+}
@@ -0,0 +1,257 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package grpctools turns the unary methods of a gRPC server into
+// Genkit tools. It introspects the server through the gRPC reflection
+// service, derives JSON schemas from the protobuf message descriptors,
+// and maps each tool call to a unary RPC with a deadline and metadata
+// propagation. The server must have reflection enabled
+// (google.golang.org/grpc/reflection).
+package grpctools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	reflectpb "google.golang.org/grpc/reflection/grpc_reflection_v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// Config configures [DefineTools].
+type Config struct {
+	// Conn is an established connection to the server. Required.
+	Conn *grpc.ClientConn
+	// Methods names the methods to expose, fully qualified as
+	// "package.Service/Method". If empty, every unary method of every
+	// service is exposed, except the reflection service itself.
+	Methods []string
+	// Timeout is the per-call deadline. If zero, 30 seconds.
+	Timeout time.Duration
+	// Metadata is added to the outgoing metadata of every call, along
+	// with any metadata on the tool call's incoming context.
+	Metadata map[string]string
+}
+
+// DefineTools introspects cfg.Conn's server via reflection and
+// registers a tool per selected unary method. Tool names replace the
+// method separators with underscores, e.g. "pkg.Service/Get" becomes
+// "pkg_Service_Get". It returns the tool names.
+func DefineTools(ctx context.Context, cfg Config) ([]string, error) {
+	if cfg.Conn == nil {
+		return nil, fmt.Errorf("grpctools.DefineTools: a Conn is required")
+	}
+	files, err := fetchDescriptors(ctx, cfg.Conn)
+	if err != nil {
+		return nil, fmt.Errorf("grpctools.DefineTools: %w", err)
+	}
+	wanted := map[string]bool{}
+	for _, m := range cfg.Methods {
+		wanted[m] = true
+	}
+	timeout := cfg.Timeout
+	if timeout == 0 {
+		timeout = 30 * time.Second
+	}
+	var names []string
+	files.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		svcs := fd.Services()
+		for i := 0; i < svcs.Len(); i++ {
+			svc := svcs.Get(i)
+			if strings.HasPrefix(string(svc.FullName()), "grpc.reflection.") {
+				continue
+			}
+			methods := svc.Methods()
+			for j := 0; j < methods.Len(); j++ {
+				m := methods.Get(j)
+				if m.IsStreamingClient() || m.IsStreamingServer() {
+					continue
+				}
+				full := fmt.Sprintf("%s/%s", svc.FullName(), m.Name())
+				if len(wanted) > 0 && !wanted[full] {
+					continue
+				}
+				name := strings.NewReplacer(".", "_", "/", "_").Replace(full)
+				ai.DefineTool(&ai.ToolDefinition{
+					Name:         name,
+					Description:  fmt.Sprintf("Calls the %s RPC.", full),
+					InputSchema:  messageSchema(m.Input(), 0),
+					OutputSchema: messageSchema(m.Output(), 0),
+				}, map[string]any{
+					"grpc:method": full,
+				}, invoker(cfg, "/"+full, m))
+				names = append(names, name)
+			}
+		}
+		return true
+	})
+	return names, nil
+}
+
+// fetchDescriptors retrieves the file descriptors for every service the
+// server advertises through the reflection API.
+func fetchDescriptors(ctx context.Context, conn *grpc.ClientConn) (*protoregistry.Files, error) {
+	stream, err := reflectpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.CloseSend()
+	if err := stream.Send(&reflectpb.ServerReflectionRequest{
+		MessageRequest: &reflectpb.ServerReflectionRequest_ListServices{},
+	}); err != nil {
+		return nil, err
+	}
+	resp, err := stream.Recv()
+	if err != nil {
+		return nil, err
+	}
+	list := resp.GetListServicesResponse()
+	if list == nil {
+		return nil, fmt.Errorf("reflection: unexpected response %T", resp.MessageResponse)
+	}
+	fdSet := &descriptorpb.FileDescriptorSet{}
+	seen := map[string]bool{}
+	for _, svc := range list.Service {
+		if err := stream.Send(&reflectpb.ServerReflectionRequest{
+			MessageRequest: &reflectpb.ServerReflectionRequest_FileContainingSymbol{
+				FileContainingSymbol: svc.Name,
+			},
+		}); err != nil {
+			return nil, err
+		}
+		resp, err := stream.Recv()
+		if err != nil {
+			return nil, err
+		}
+		for _, raw := range resp.GetFileDescriptorResponse().GetFileDescriptorProto() {
+			fd := &descriptorpb.FileDescriptorProto{}
+			if err := proto.Unmarshal(raw, fd); err != nil {
+				return nil, err
+			}
+			if seen[fd.GetName()] {
+				continue
+			}
+			seen[fd.GetName()] = true
+			fdSet.File = append(fdSet.File, fd)
+		}
+	}
+	return protodesc.NewFiles(fdSet)
+}
+
+// invoker returns the tool function for one method.
+func invoker(cfg Config, fullMethod string, m protoreflect.MethodDescriptor) func(context.Context, map[string]any) (map[string]any, error) {
+	timeout := cfg.Timeout
+	if timeout == 0 {
+		timeout = 30 * time.Second
+	}
+	return func(ctx context.Context, input map[string]any) (map[string]any, error) {
+		data, err := json.Marshal(input)
+		if err != nil {
+			return nil, err
+		}
+		in := dynamicpb.NewMessage(m.Input())
+		if err := protojson.Unmarshal(data, in); err != nil {
+			return nil, fmt.Errorf("invalid input for %s: %w", fullMethod, err)
+		}
+		out := dynamicpb.NewMessage(m.Output())
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		md, _ := metadata.FromIncomingContext(ctx)
+		md = metadata.Join(md, metadata.New(cfg.Metadata))
+		if len(md) > 0 {
+			ctx = metadata.NewOutgoingContext(ctx, md)
+		}
+		if err := cfg.Conn.Invoke(ctx, fullMethod, in, out); err != nil {
+			return nil, err
+		}
+		data, err = protojson.Marshal(out)
+		if err != nil {
+			return nil, err
+		}
+		result := map[string]any{}
+		if err := json.Unmarshal(data, &result); err != nil {
+			return nil, err
+		}
+		return result, nil
+	}
+}
+
+// messageSchema derives a JSON schema from a protobuf message
+// descriptor, following protojson's field naming. Recursion through
+// nested messages is capped to keep schemas bounded for self-referential
+// types.
+func messageSchema(md protoreflect.MessageDescriptor, depth int) map[string]any {
+	if depth > 3 {
+		return map[string]any{"type": "object"}
+	}
+	props := map[string]any{}
+	fields := md.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		f := fields.Get(i)
+		props[f.JSONName()] = fieldSchema(f, depth)
+	}
+	return map[string]any{
+		"type":       "object",
+		"properties": props,
+	}
+}
+
+func fieldSchema(f protoreflect.FieldDescriptor, depth int) map[string]any {
+	if f.IsMap() {
+		return map[string]any{
+			"type":                 "object",
+			"additionalProperties": fieldSchema(f.MapValue(), depth),
+		}
+	}
+	var schema map[string]any
+	switch f.Kind() {
+	case protoreflect.BoolKind:
+		schema = map[string]any{"type": "boolean"}
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		schema = map[string]any{"type": "integer"}
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		// protojson renders 64-bit integers as strings.
+		schema = map[string]any{"type": "string"}
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		schema = map[string]any{"type": "number"}
+	case protoreflect.EnumKind:
+		vals := f.Enum().Values()
+		var names []any
+		for i := 0; i < vals.Len(); i++ {
+			names = append(names, string(vals.Get(i).Name()))
+		}
+		schema = map[string]any{"type": "string", "enum": names}
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		schema = messageSchema(f.Message(), depth+1)
+	default: // strings, bytes
+		schema = map[string]any{"type": "string"}
+	}
+	if f.IsList() {
+		return map[string]any{"type": "array", "items": schema}
+	}
+	return schema
+}
@@ -0,0 +1,69 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpctools
+
+import (
+	"context"
+	"net"
+	"slices"
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+)
+
+func TestDefineTools(t *testing.T) {
+	// Serve the standard health service with reflection enabled; its
+	// Check method is the unary RPC under test.
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := grpc.NewServer()
+	healthpb.RegisterHealthServer(srv, health.NewServer())
+	reflection.Register(srv)
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	ctx := context.Background()
+	names, err := DefineTools(ctx, Config{
+		Conn:    conn,
+		Methods: []string{"grpc.health.v1.Health/Check"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"grpc_health_v1_Health_Check"}; !slices.Equal(names, want) {
+		t.Fatalf("got tools %v, want %v", names, want)
+	}
+
+	out, err := ai.RunTool(ctx, names[0], map[string]any{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out["status"] != "SERVING" {
+		t.Errorf("got %v, want status SERVING", out)
+	}
+}
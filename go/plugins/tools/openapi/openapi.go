@@ -0,0 +1,238 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package openapi turns the operations of an OpenAPI 3 document into
+// Genkit tools, so models can call existing REST APIs without one
+// hand-written DefineTool per endpoint. Each operation with an
+// operationId becomes a tool whose input schema is derived from the
+// operation's parameters and request body and whose execution is an
+// HTTP call.
+package openapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/core"
+)
+
+const provider = "openapi"
+
+// Config configures [DefineTools].
+type Config struct {
+	// Spec is the OpenAPI 3 document, as JSON. Required.
+	Spec []byte
+	// BaseURL is the server to call. If empty, the first URL in the
+	// spec's "servers" list is used.
+	BaseURL string
+	// Headers are added to every request, e.g. an Authorization
+	// header for the API.
+	Headers map[string]string
+	// Filter selects which operations become tools. It receives the
+	// operationId, HTTP method (upper case) and path template. If
+	// nil, every operation with an operationId becomes a tool.
+	Filter func(operationID, method, path string) bool
+	// HTTPClient makes the requests. If nil, http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// spec mirrors the parts of an OpenAPI 3 document we consume.
+type spec struct {
+	Servers []struct {
+		URL string `json:"url"`
+	} `json:"servers"`
+	Paths map[string]map[string]*operation `json:"paths"`
+}
+
+type operation struct {
+	OperationID string      `json:"operationId"`
+	Summary     string      `json:"summary"`
+	Description string      `json:"description"`
+	Parameters  []parameter `json:"parameters"`
+	RequestBody *struct {
+		Content map[string]struct {
+			Schema map[string]any `json:"schema"`
+		} `json:"content"`
+	} `json:"requestBody"`
+}
+
+type parameter struct {
+	Name     string         `json:"name"`
+	In       string         `json:"in"` // "path" or "query"; others are ignored
+	Required bool           `json:"required"`
+	Schema   map[string]any `json:"schema"`
+}
+
+// DefineTools registers a tool for each operation selected by
+// cfg.Filter and returns the tool names. Path and query parameters
+// become top-level input properties; a JSON request body becomes the
+// "body" property. Non-2xx responses fail the tool with a
+// [core.GenkitError] normalized from the HTTP status.
+func DefineTools(cfg Config) ([]string, error) {
+	var doc spec
+	if err := json.Unmarshal(cfg.Spec, &doc); err != nil {
+		return nil, fmt.Errorf("openapi.DefineTools: parsing spec: %w", err)
+	}
+	base := cfg.BaseURL
+	if base == "" {
+		if len(doc.Servers) == 0 {
+			return nil, fmt.Errorf("openapi.DefineTools: no BaseURL and the spec has no servers")
+		}
+		base = doc.Servers[0].URL
+	}
+	base = strings.TrimSuffix(base, "/")
+	client := cfg.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	var names []string
+	for path, ops := range doc.Paths {
+		for method, op := range ops {
+			method := strings.ToUpper(method)
+			if op.OperationID == "" {
+				continue
+			}
+			if cfg.Filter != nil && !cfg.Filter(op.OperationID, method, path) {
+				continue
+			}
+			ai.DefineTool(toolDefinition(op), map[string]any{
+				"openapi:method": method,
+				"openapi:path":   path,
+			}, runner(client, cfg.Headers, base, method, path, op))
+			names = append(names, op.OperationID)
+		}
+	}
+	return names, nil
+}
+
+// toolDefinition derives a tool definition from an operation.
+func toolDefinition(op *operation) *ai.ToolDefinition {
+	desc := op.Description
+	if desc == "" {
+		desc = op.Summary
+	}
+	props := map[string]any{}
+	var required []any
+	for _, p := range op.Parameters {
+		if p.In != "path" && p.In != "query" {
+			continue
+		}
+		schema := p.Schema
+		if schema == nil {
+			schema = map[string]any{"type": "string"}
+		}
+		props[p.Name] = schema
+		if p.Required || p.In == "path" {
+			required = append(required, p.Name)
+		}
+	}
+	if body := bodySchema(op); body != nil {
+		props["body"] = body
+		required = append(required, "body")
+	}
+	inputSchema := map[string]any{
+		"type":       "object",
+		"properties": props,
+	}
+	if len(required) > 0 {
+		inputSchema["required"] = required
+	}
+	return &ai.ToolDefinition{
+		Name:        op.OperationID,
+		Description: desc,
+		InputSchema: inputSchema,
+	}
+}
+
+func bodySchema(op *operation) map[string]any {
+	if op.RequestBody == nil {
+		return nil
+	}
+	if c, ok := op.RequestBody.Content["application/json"]; ok {
+		return c.Schema
+	}
+	return nil
+}
+
+// runner returns the tool function for one operation.
+func runner(client *http.Client, headers map[string]string, base, method, path string, op *operation) func(context.Context, map[string]any) (map[string]any, error) {
+	return func(ctx context.Context, input map[string]any) (map[string]any, error) {
+		p := path
+		query := url.Values{}
+		for _, param := range op.Parameters {
+			v, ok := input[param.Name]
+			if !ok {
+				continue
+			}
+			switch param.In {
+			case "path":
+				p = strings.ReplaceAll(p, "{"+param.Name+"}", url.PathEscape(fmt.Sprint(v)))
+			case "query":
+				query.Set(param.Name, fmt.Sprint(v))
+			}
+		}
+		u := base + p
+		if len(query) > 0 {
+			u += "?" + query.Encode()
+		}
+		var body io.Reader
+		if b, ok := input["body"]; ok {
+			data, err := json.Marshal(b)
+			if err != nil {
+				return nil, err
+			}
+			body = bytes.NewReader(data)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, u, body)
+		if err != nil {
+			return nil, err
+		}
+		if body != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return nil, core.NormalizeHTTPError(provider, resp.StatusCode, resp.Header.Get("Retry-After"),
+				fmt.Errorf("%s %s: %s: %s", method, p, resp.Status, data))
+		}
+		var out any
+		if len(data) > 0 {
+			if err := json.Unmarshal(data, &out); err != nil {
+				out = string(data)
+			}
+		}
+		if m, ok := out.(map[string]any); ok {
+			return m, nil
+		}
+		return map[string]any{"result": out}, nil
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package openapi
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/firebase/genkit/go/ai"
+)
+
+const testSpec = `{
+	"openapi": "3.0.0",
+	"paths": {
+		"/widgets/{id}": {
+			"get": {
+				"operationId": "getWidget",
+				"summary": "Get a widget by ID.",
+				"parameters": [
+					{"name": "id", "in": "path", "required": true, "schema": {"type": "string"}},
+					{"name": "verbose", "in": "query", "schema": {"type": "boolean"}}
+				]
+			}
+		},
+		"/widgets": {
+			"post": {
+				"operationId": "createWidget",
+				"requestBody": {
+					"content": {
+						"application/json": {
+							"schema": {"type": "object", "properties": {"name": {"type": "string"}}}
+						}
+					}
+				}
+			},
+			"get": {"summary": "no operationId, skipped"}
+		}
+	}
+}`
+
+func TestDefineTools(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/widgets/w1":
+			fmt.Fprintf(w, `{"id": "w1", "verbose": %q}`, r.URL.Query().Get("verbose"))
+		case r.Method == "POST" && r.URL.Path == "/widgets":
+			body, _ := io.ReadAll(r.Body)
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprintf(w, `{"created": %s}`, body)
+		default:
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	names, err := DefineTools(Config{
+		Spec:    []byte(testSpec),
+		BaseURL: srv.URL,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(names)
+	if want := []string{"createWidget", "getWidget"}; !slices.Equal(names, want) {
+		t.Fatalf("got tools %v, want %v", names, want)
+	}
+
+	ctx := context.Background()
+	t.Run("path and query params", func(t *testing.T) {
+		out, err := ai.RunTool(ctx, "getWidget", map[string]any{"id": "w1", "verbose": true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out["id"] != "w1" || out["verbose"] != "true" {
+			t.Errorf("got %v", out)
+		}
+	})
+	t.Run("request body", func(t *testing.T) {
+		out, err := ai.RunTool(ctx, "createWidget", map[string]any{
+			"body": map[string]any{"name": "sprocket"},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		created, ok := out["created"].(map[string]any)
+		if !ok || created["name"] != "sprocket" {
+			t.Errorf("got %v", out)
+		}
+	})
+	t.Run("http error", func(t *testing.T) {
+		_, err := ai.RunTool(ctx, "getWidget", map[string]any{"id": "missing"})
+		if err == nil {
+			t.Fatal("expected an error for a 404 response")
+		}
+	})
+}
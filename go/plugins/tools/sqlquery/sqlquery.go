@@ -0,0 +1,262 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sqlquery registers a tool that lets a model run read-only SQL
+// queries against a [database/sql] database. The database schema ships
+// in the tool description so the model can write queries against it,
+// and execution enforces statement-type validation, a row limit, and a
+// timeout. The caller supplies the *sql.DB, so any driver works.
+package sqlquery
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/core"
+)
+
+// Config configures [DefineTool].
+type Config struct {
+	// DB is the database to query. Required. Consider a connection
+	// with a read-only role, as defense in depth beyond the statement
+	// validation here.
+	DB *sql.DB
+	// Name is the tool name. If empty, "sqlQuery".
+	Name string
+	// Schema describes the tables for the model, typically DDL or a
+	// table/column listing. If empty, it is introspected from
+	// information_schema where the database supports that.
+	Schema string
+	// MaxRows bounds the result size. If zero, 100.
+	MaxRows int
+	// Timeout bounds each query. If zero, 10 seconds.
+	Timeout time.Duration
+}
+
+// DefineTool registers the query tool and returns its name. The tool
+// takes {"query": "..."} and returns the column names and rows. Only a
+// single SELECT (or WITH ... SELECT) statement is accepted.
+func DefineTool(ctx context.Context, cfg Config) (string, error) {
+	if cfg.DB == nil {
+		return "", fmt.Errorf("sqlquery.DefineTool: a DB is required")
+	}
+	name := cfg.Name
+	if name == "" {
+		name = "sqlQuery"
+	}
+	schema := cfg.Schema
+	if schema == "" {
+		schema, _ = introspectSchema(ctx, cfg.DB)
+	}
+	desc := "Runs a single read-only SQL SELECT query against the database and returns the rows."
+	if schema != "" {
+		desc += " The database schema is:\n" + schema
+	}
+	maxRows := cfg.MaxRows
+	if maxRows == 0 {
+		maxRows = 100
+	}
+	timeout := cfg.Timeout
+	if timeout == 0 {
+		timeout = 10 * time.Second
+	}
+	ai.DefineTool(&ai.ToolDefinition{
+		Name:        name,
+		Description: desc,
+		InputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"query": map[string]any{"type": "string"},
+			},
+			"required": []any{"query"},
+		},
+		OutputSchema: map[string]any{
+			"type": "object",
+			"properties": map[string]any{
+				"columns":   map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+				"rows":      map[string]any{"type": "array"},
+				"truncated": map[string]any{"type": "boolean"},
+			},
+		},
+	}, nil, func(ctx context.Context, input map[string]any) (map[string]any, error) {
+		query, _ := input["query"].(string)
+		if err := validateQuery(query); err != nil {
+			return nil, core.NewError(core.StatusInvalidArgument, err)
+		}
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		return runQuery(ctx, cfg.DB, query, maxRows)
+	})
+	return name, nil
+}
+
+// validateQuery rejects anything but a single SELECT statement. This is
+// a guard against the model issuing mutations, not a substitute for
+// read-only database credentials.
+func validateQuery(query string) error {
+	q := strings.TrimSpace(query)
+	q = strings.TrimSuffix(q, ";")
+	if strings.Contains(q, ";") {
+		return fmt.Errorf("only a single statement is allowed")
+	}
+	switch first := strings.ToLower(firstWord(q)); first {
+	case "select":
+		return nil
+	case "with":
+		// PostgreSQL allows data-modifying statements inside a CTE
+		// (WITH d AS (DELETE FROM t RETURNING *) SELECT ...), so a
+		// leading WITH does not make the statement read-only.
+		if kw := dmlKeyword(q); kw != "" {
+			return fmt.Errorf("WITH queries may not contain %s", strings.ToUpper(kw))
+		}
+		return nil
+	default:
+		return fmt.Errorf("only SELECT queries are allowed, got %q", first)
+	}
+}
+
+// dmlKeyword returns the first data-modifying keyword that appears in q
+// outside string literals and quoted identifiers, or "" if there is
+// none.
+func dmlKeyword(q string) string {
+	var word []byte
+	check := func() string {
+		switch w := strings.ToLower(string(word)); w {
+		case "insert", "update", "delete", "merge", "truncate":
+			return w
+		}
+		return ""
+	}
+	var quote byte
+	for i := 0; i < len(q); i++ {
+		b := q[i]
+		if quote != 0 {
+			if b == quote {
+				if i+1 < len(q) && q[i+1] == quote {
+					// A doubled quote is an escape, not the end.
+					i++
+					continue
+				}
+				quote = 0
+			}
+			continue
+		}
+		isWordByte := b == '_' || b >= '0' && b <= '9' ||
+			b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+		if isWordByte {
+			word = append(word, b)
+			continue
+		}
+		if kw := check(); kw != "" {
+			return kw
+		}
+		word = word[:0]
+		if b == '\'' || b == '"' {
+			quote = b
+		}
+	}
+	return check()
+}
+
+func firstWord(s string) string {
+	if i := strings.IndexAny(s, " \t\r\n("); i > 0 {
+		return s[:i]
+	}
+	return s
+}
+
+// runQuery executes the query and marshals up to maxRows rows.
+func runQuery(ctx context.Context, db *sql.DB, query string, maxRows int) (map[string]any, error) {
+	rows, err := db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	cols, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	var result []map[string]any
+	truncated := false
+	for rows.Next() {
+		if len(result) == maxRows {
+			truncated = true
+			break
+		}
+		vals := make([]any, len(cols))
+		ptrs := make([]any, len(cols))
+		for i := range vals {
+			ptrs[i] = &vals[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, err
+		}
+		row := map[string]any{}
+		for i, c := range cols {
+			v := vals[i]
+			// Drivers commonly return []byte for text columns.
+			if b, ok := v.([]byte); ok {
+				v = string(b)
+			}
+			row[c] = v
+		}
+		result = append(result, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return map[string]any{
+		"columns":   cols,
+		"rows":      result,
+		"truncated": truncated,
+	}, nil
+}
+
+// introspectSchema summarizes the tables from information_schema, which
+// PostgreSQL, MySQL and most cloud warehouses provide.
+func introspectSchema(ctx context.Context, db *sql.DB) (string, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT table_name, column_name, data_type
+		FROM information_schema.columns
+		WHERE table_schema NOT IN ('pg_catalog', 'information_schema')
+		ORDER BY table_name, ordinal_position`)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+	var b strings.Builder
+	last := ""
+	for rows.Next() {
+		var table, col, typ string
+		if err := rows.Scan(&table, &col, &typ); err != nil {
+			return "", err
+		}
+		if table != last {
+			if last != "" {
+				b.WriteString("\n")
+			}
+			fmt.Fprintf(&b, "%s:", table)
+			last = table
+		}
+		fmt.Fprintf(&b, " %s %s;", col, typ)
+	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
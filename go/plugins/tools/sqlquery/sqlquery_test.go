@@ -0,0 +1,46 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlquery
+
+import "testing"
+
+func TestValidateQuery(t *testing.T) {
+	for _, test := range []struct {
+		query string
+		ok    bool
+	}{
+		{"SELECT * FROM users", true},
+		{"select count(*) from orders;", true},
+		{"  WITH t AS (SELECT 1) SELECT * FROM t", true},
+		{"WITH t AS (SELECT 'delete me' AS note) SELECT * FROM t", true},
+		{"WITH d AS (DELETE FROM users RETURNING *) SELECT * FROM d", false},
+		{"with u as (update users set name = 'x' returning *) select * from u", false},
+		{"WITH i AS (INSERT INTO logs VALUES (1) RETURNING *) SELECT 1", false},
+		{"DELETE FROM users", false},
+		{"UPDATE users SET name = 'x'", false},
+		{"DROP TABLE users", false},
+		{"INSERT INTO users VALUES (1)", false},
+		{"SELECT 1; DELETE FROM users", false},
+		{"", false},
+	} {
+		err := validateQuery(test.query)
+		if test.ok && err != nil {
+			t.Errorf("%q: unexpected error %v", test.query, err)
+		}
+		if !test.ok && err == nil {
+			t.Errorf("%q: expected an error", test.query)
+		}
+	}
+}
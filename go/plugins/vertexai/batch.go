@@ -0,0 +1,127 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vertexai
+
+import (
+	"context"
+	"fmt"
+
+	aiplatform "cloud.google.com/go/aiplatform/apiv1"
+	"cloud.google.com/go/aiplatform/apiv1/aiplatformpb"
+	"google.golang.org/api/option"
+)
+
+// This file implements Vertex AI batch prediction, for offline generation
+// workloads that are too large for the online API.
+
+// A BatchPredictionJob describes a submitted batch prediction job.
+type BatchPredictionJob struct {
+	// The fully qualified resource name of the job.
+	Name string
+	// The job's state, like "JOB_STATE_RUNNING" or "JOB_STATE_SUCCEEDED".
+	State string
+	// Done reports whether the job finished, successfully or not.
+	Done bool
+	// The error message, if the job failed.
+	Error string
+	// The GCS directory holding the results, once the job has succeeded.
+	OutputDirectory string
+}
+
+// SubmitBatchPrediction submits a Vertex AI batch prediction job for the
+// given model. The inputs are GCS URIs of JSONL files, each line holding a
+// request in the model's request format; results are written under
+// gcsOutputPrefix. Batch jobs run offline at lower cost than the online
+// API, so use them for large workloads that don't need interactive latency.
+//
+// The returned job is not yet complete; poll it with [BatchPredictionState].
+func SubmitBatchPrediction(ctx context.Context, model string, gcsInputURIs []string, gcsOutputPrefix string) (*BatchPredictionJob, error) {
+	if !state.initted {
+		panic("vertexai.Init not called")
+	}
+	client, err := newJobClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+	req := &aiplatformpb.CreateBatchPredictionJobRequest{
+		Parent: fmt.Sprintf("projects/%s/locations/%s", state.projectID, state.location),
+		BatchPredictionJob: &aiplatformpb.BatchPredictionJob{
+			DisplayName: "genkit-batch-" + model,
+			Model:       fmt.Sprintf("publishers/google/models/%s", model),
+			InputConfig: &aiplatformpb.BatchPredictionJob_InputConfig{
+				InstancesFormat: "jsonl",
+				Source: &aiplatformpb.BatchPredictionJob_InputConfig_GcsSource{
+					GcsSource: &aiplatformpb.GcsSource{Uris: gcsInputURIs},
+				},
+			},
+			OutputConfig: &aiplatformpb.BatchPredictionJob_OutputConfig{
+				PredictionsFormat: "jsonl",
+				Destination: &aiplatformpb.BatchPredictionJob_OutputConfig_GcsDestination{
+					GcsDestination: &aiplatformpb.GcsDestination{OutputUriPrefix: gcsOutputPrefix},
+				},
+			},
+		},
+	}
+	job, err := client.CreateBatchPredictionJob(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("vertexai.SubmitBatchPrediction: %w", err)
+	}
+	return translateBatchJob(job), nil
+}
+
+// BatchPredictionState returns the current state of a batch prediction job
+// submitted with [SubmitBatchPrediction], given its resource name.
+func BatchPredictionState(ctx context.Context, jobName string) (*BatchPredictionJob, error) {
+	if !state.initted {
+		panic("vertexai.Init not called")
+	}
+	client, err := newJobClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+	job, err := client.GetBatchPredictionJob(ctx, &aiplatformpb.GetBatchPredictionJobRequest{Name: jobName})
+	if err != nil {
+		return nil, fmt.Errorf("vertexai.BatchPredictionState: %w", err)
+	}
+	return translateBatchJob(job), nil
+}
+
+func newJobClient(ctx context.Context) (*aiplatform.JobClient, error) {
+	endpoint := fmt.Sprintf("%s-aiplatform.googleapis.com:443", state.location)
+	return aiplatform.NewJobClient(ctx, option.WithEndpoint(endpoint))
+}
+
+func translateBatchJob(job *aiplatformpb.BatchPredictionJob) *BatchPredictionJob {
+	b := &BatchPredictionJob{
+		Name:  job.GetName(),
+		State: job.GetState().String(),
+	}
+	switch job.GetState() {
+	case aiplatformpb.JobState_JOB_STATE_SUCCEEDED,
+		aiplatformpb.JobState_JOB_STATE_FAILED,
+		aiplatformpb.JobState_JOB_STATE_CANCELLED,
+		aiplatformpb.JobState_JOB_STATE_EXPIRED:
+		b.Done = true
+	}
+	if e := job.GetError(); e != nil {
+		b.Error = e.GetMessage()
+	}
+	if info := job.GetOutputInfo(); info != nil {
+		b.OutputDirectory = info.GetGcsOutputDirectory()
+	}
+	return b
+}
@@ -16,6 +16,7 @@ package vertexai
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"runtime"
@@ -24,9 +25,14 @@ import (
 	aiplatform "cloud.google.com/go/aiplatform/apiv1"
 	"cloud.google.com/go/vertexai/genai"
 	"github.com/firebase/genkit/go/ai"
+	"github.com/firebase/genkit/go/core"
 	"github.com/firebase/genkit/go/plugins/internal/uri"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/impersonate"
 	"google.golang.org/api/iterator"
 	"google.golang.org/api/option"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 const provider = "vertexai"
@@ -72,15 +78,74 @@ var state struct {
 	pclient   *aiplatform.PredictionClient
 }
 
+// Config configures the plugin's credential source and regions.
+// The zero value uses Application Default Credentials (which covers
+// workload identity on GKE and Cloud Run) and "us-central1".
+type Config struct {
+	// ProjectID is the Google Cloud project. If empty, it is read from
+	// the GCLOUD_PROJECT or GOOGLE_CLOUD_PROJECT environment variable.
+	ProjectID string
+	// Location is the region to use, "us-central1" by default.
+	Location string
+	// Locations is an ordered region failover list: the first region
+	// whose clients initialize successfully is used. It overrides
+	// Location.
+	Locations []string
+	// CredentialsFile is the path to a service account JSON key file
+	// to use instead of Application Default Credentials.
+	CredentialsFile string
+	// CredentialsJSON is raw service account JSON key material to use
+	// instead of Application Default Credentials.
+	CredentialsJSON []byte
+	// ImpersonateServiceAccount, if set, exchanges the base credentials
+	// for tokens impersonating this service account, e.g.
+	// "target@my-project.iam.gserviceaccount.com".
+	ImpersonateServiceAccount string
+}
+
+// clientOptions translates the config's credential source into client
+// options for the Vertex AI clients.
+func (c *Config) clientOptions(ctx context.Context) ([]option.ClientOption, error) {
+	var opts []option.ClientOption
+	if c.CredentialsFile != "" {
+		opts = append(opts, option.WithCredentialsFile(c.CredentialsFile))
+	}
+	if len(c.CredentialsJSON) > 0 {
+		opts = append(opts, option.WithCredentialsJSON(c.CredentialsJSON))
+	}
+	if c.ImpersonateServiceAccount != "" {
+		ts, err := impersonate.CredentialsTokenSource(ctx, impersonate.CredentialsConfig{
+			TargetPrincipal: c.ImpersonateServiceAccount,
+			Scopes:          []string{"https://www.googleapis.com/auth/cloud-platform"},
+		}, opts...)
+		if err != nil {
+			return nil, fmt.Errorf("impersonating %s: %w", c.ImpersonateServiceAccount, err)
+		}
+		// The impersonated token source replaces the base credentials.
+		opts = []option.ClientOption{option.WithTokenSource(ts)}
+	}
+	return opts, nil
+}
+
 // Init initializes the plugin and all known models and embedders.
 // After calling Init, you may call [DefineModel] and [DefineEmbedder] to create
 // and register any additional generative models and embedders
 func Init(ctx context.Context, projectID, location string) error {
+	return InitWithConfig(ctx, &Config{ProjectID: projectID, Location: location})
+}
+
+// InitWithConfig is like [Init] with explicit control over the
+// credential source and region failover.
+func InitWithConfig(ctx context.Context, cfg *Config) error {
 	state.mu.Lock()
 	defer state.mu.Unlock()
 	if state.initted {
 		panic("vertexai.Init already called")
 	}
+	if cfg == nil {
+		cfg = &Config{}
+	}
+	projectID := cfg.ProjectID
 	if projectID == "" {
 		projectID = os.Getenv("GCLOUD_PROJECT")
 		if projectID == "" {
@@ -91,26 +156,44 @@ func Init(ctx context.Context, projectID, location string) error {
 		}
 	}
 	state.projectID = projectID
-	if location == "" {
-		location = "us-central1"
+	locations := cfg.Locations
+	if len(locations) == 0 {
+		location := cfg.Location
+		if location == "" {
+			location = "us-central1"
+		}
+		locations = []string{location}
 	}
-	state.location = location
-	var err error
-	// Client for Gemini SDK.
-	state.gclient, err = genai.NewClient(ctx, projectID, location)
+	copts, err := cfg.clientOptions(ctx)
 	if err != nil {
-		return err
+		return fmt.Errorf("vertexai.Init: %w", err)
 	}
-	endpoint := fmt.Sprintf("%s-aiplatform.googleapis.com:443", location)
-	numConns := max(runtime.GOMAXPROCS(0), 4)
-	o := []option.ClientOption{
-		option.WithEndpoint(endpoint),
-		option.WithGRPCConnectionPool(numConns),
+	var errs []error
+	for _, location := range locations {
+		// Client for Gemini SDK.
+		gclient, err := genai.NewClient(ctx, projectID, location, copts...)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("region %s: %w", location, err))
+			continue
+		}
+		endpoint := fmt.Sprintf("%s-aiplatform.googleapis.com:443", location)
+		numConns := max(runtime.GOMAXPROCS(0), 4)
+		o := []option.ClientOption{
+			option.WithEndpoint(endpoint),
+			option.WithGRPCConnectionPool(numConns),
+		}
+		pclient, err := aiplatform.NewPredictionClient(ctx, append(o, copts...)...)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("region %s: %w", location, err))
+			continue
+		}
+		state.location = location
+		state.gclient = gclient
+		state.pclient = pclient
+		break
 	}
-
-	state.pclient, err = aiplatform.NewPredictionClient(ctx, o...)
-	if err != nil {
-		return err
+	if state.gclient == nil || state.pclient == nil {
+		return fmt.Errorf("vertexai.Init: no usable region: %w", errors.Join(errs...))
 	}
 	state.initted = true
 	for model, caps := range knownCaps {
@@ -205,9 +288,18 @@ func (g *generator) generate(ctx context.Context, input *ai.GenerateRequest, cb
 	// Translate from a ai.GenerateRequest to a genai request.
 	gm.SetCandidateCount(int32(input.Candidates))
 	if c, ok := input.Config.(*ai.GenerationCommonConfig); ok && c != nil {
+		if c.FrequencyPenalty != 0 {
+			fp := float32(c.FrequencyPenalty)
+			gm.FrequencyPenalty = &fp
+		}
 		if c.MaxOutputTokens != 0 {
 			gm.SetMaxOutputTokens(int32(c.MaxOutputTokens))
 		}
+		if c.PresencePenalty != 0 {
+			pp := float32(c.PresencePenalty)
+			gm.PresencePenalty = &pp
+		}
+		// c.Seed is not supported by Vertex AI; ignore it.
 		if len(c.StopSequences) > 0 {
 			gm.StopSequences = c.StopSequences
 		}
@@ -230,14 +322,11 @@ func (g *generator) generate(ctx context.Context, input *ai.GenerateRequest, cb
 	for len(messages) > 1 {
 		m := messages[0]
 		messages = messages[1:]
-		parts, err := convertParts(m.Content)
+		c, err := convertContent(m)
 		if err != nil {
 			return nil, err
 		}
-		cs.History = append(cs.History, &genai.Content{
-			Parts: parts,
-			Role:  string(m.Role),
-		})
+		cs.History = append(cs.History, c)
 	}
 	// The last message gets added to the parts slice.
 	var parts []genai.Part
@@ -288,7 +377,7 @@ func (g *generator) generate(ctx context.Context, input *ai.GenerateRequest, cb
 	if cb == nil {
 		resp, err := cs.SendMessage(ctx, parts...)
 		if err != nil {
-			return nil, err
+			return nil, normalizeError(err)
 		}
 
 		r := translateResponse(resp)
@@ -306,7 +395,7 @@ func (g *generator) generate(ctx context.Context, input *ai.GenerateRequest, cb
 				r = translateResponse(iter.MergedResponse())
 				break
 			}
-			return nil, err
+			return nil, normalizeError(err)
 		}
 
 		// Process each candidate.
@@ -332,6 +421,67 @@ func (g *generator) generate(ctx context.Context, input *ai.GenerateRequest, cb
 	return r, nil
 }
 
+// normalizeError converts an error from the Vertex AI SDK into a
+// [core.GenkitError], so callers can branch on [core.StatusOf] and
+// retry middleware can honor [core.IsRetryable] and [core.RetryAfter]
+// instead of matching provider error strings.
+func normalizeError(err error) error {
+	if err == nil {
+		return nil
+	}
+	var gerr *googleapi.Error
+	if errors.As(err, &gerr) {
+		return core.NormalizeHTTPError(provider, gerr.Code, gerr.Header.Get("Retry-After"), err)
+	}
+	if s, ok := status.FromError(err); ok && s.Code() != codes.OK {
+		return normalizeGRPCError(s, err)
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return &core.GenkitError{
+			Status:    core.StatusDeadlineExceeded,
+			Provider:  provider,
+			Retryable: true,
+			Err:       err,
+		}
+	}
+	return err
+}
+
+// normalizeGRPCError maps a gRPC status error into a [core.GenkitError].
+func normalizeGRPCError(s *status.Status, err error) error {
+	ge := &core.GenkitError{
+		Provider: provider,
+		RawCode:  s.Code().String(),
+		Err:      err,
+	}
+	switch s.Code() {
+	case codes.InvalidArgument:
+		ge.Status = core.StatusInvalidArgument
+	case codes.DeadlineExceeded:
+		ge.Status = core.StatusDeadlineExceeded
+		ge.Retryable = true
+	case codes.NotFound:
+		ge.Status = core.StatusNotFound
+	case codes.PermissionDenied:
+		ge.Status = core.StatusPermissionDenied
+	case codes.Unauthenticated:
+		ge.Status = core.StatusUnauthenticated
+	case codes.ResourceExhausted:
+		ge.Status = core.StatusResourceExhausted
+		ge.Retryable = true
+	case codes.FailedPrecondition:
+		ge.Status = core.StatusFailedPrecondition
+	case codes.Aborted:
+		ge.Status = core.StatusAborted
+	case codes.Unavailable:
+		ge.Status = core.StatusUnavailable
+		ge.Retryable = true
+	default:
+		ge.Status = core.StatusInternal
+	}
+	return ge
+}
+
 // translateCandidate translates from a genai.GenerateContentResponse to an ai.GenerateResponse.
 func translateCandidate(cand *genai.Candidate) *ai.Candidate {
 	c := &ai.Candidate{}
@@ -350,6 +500,22 @@ func translateCandidate(cand *genai.Candidate) *ai.Candidate {
 	default: // Unspecified
 		c.FinishReason = ai.FinishReasonUnknown
 	}
+	if c.FinishReason == ai.FinishReasonBlocked && len(cand.SafetyRatings) > 0 {
+		// Surface which safety categories triggered the block.
+		details := &ai.FinishDetails{}
+		for _, sr := range cand.SafetyRatings {
+			if sr.Blocked {
+				details.BlockedReasons = append(details.BlockedReasons, ai.BlockedReason{
+					Category: sr.Category.String(),
+					Score:    float64(sr.ProbabilityScore),
+					Blocked:  true,
+				})
+			}
+		}
+		if len(details.BlockedReasons) > 0 {
+			c.FinishDetails = details
+		}
+	}
 	m := &ai.Message{}
 	m.Role = ai.Role(cand.Content.Role)
 	for _, part := range cand.Content.Parts {